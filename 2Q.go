@@ -1,8 +1,9 @@
 package dailzLRU
 
 import (
-	"dailzLRU/lru"
 	"errors"
+	"fmt"
+	"github.com/dailz1/dailzLRU/lru"
 	"sync"
 )
 
@@ -17,7 +18,7 @@ type TwoQueueCache[K comparable, V any] struct {
 
 	recent      *lru.LRU[K, V]
 	frequent    *lru.LRU[K, V]
-	recentEvict *lru.LRU[K, V]
+	recentEvict *lru.LRU[K, struct{}]
 	lock        sync.RWMutex
 }
 
@@ -51,7 +52,7 @@ func New2QWithParam[K comparable, V any](size int, recentRatio, ghostRatio float
 		return nil, err
 	}
 
-	recentEvict, err := lru.NewLRU[K, V](evictSize, nil)
+	recentEvict, err := lru.NewLRU[K, struct{}](evictSize, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -69,6 +70,9 @@ func New2QWithParam[K comparable, V any](size int, recentRatio, ghostRatio float
 func (c *TwoQueueCache[K, V]) Get(key K) (value V, ok bool) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
+	if debugInvariants {
+		defer c.verify()
+	}
 	if value, ok = c.frequent.Get(key); ok {
 		return value, ok
 	}
@@ -81,45 +85,52 @@ func (c *TwoQueueCache[K, V]) Get(key K) (value V, ok bool) {
 	return
 }
 
-func (c *TwoQueueCache[K, V]) Add(key K, value V) {
+// Add adds a value to the cache. Returns true if an entry was evicted to
+// make room for it.
+func (c *TwoQueueCache[K, V]) Add(key K, value V) (evicted bool) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
+	if debugInvariants {
+		defer c.verify()
+	}
 
 	if c.frequent.Contains(key) {
 		c.frequent.Add(key, value)
-		return
+		return false
 	}
 
 	if c.recent.Contains(key) {
 		c.recent.Remove(key)
 		c.frequent.Add(key, value)
-		return
+		return false
 	}
 
 	if c.recentEvict.Contains(key) {
-		c.ensureSpace(true)
+		evicted = c.ensureSpace(true)
 		c.recentEvict.Remove(key)
 		c.frequent.Add(key, value)
-		return
+		return evicted
 	}
-	c.ensureSpace(false)
+	evicted = c.ensureSpace(false)
 	c.recent.Add(key, value)
+	return evicted
 }
 
-func (c *TwoQueueCache[K, V]) ensureSpace(recentEvict bool) {
+// ensureSpace evicts an entry if the cache is full, returning true if it did
+func (c *TwoQueueCache[K, V]) ensureSpace(recentEvict bool) bool {
 	recentLen := c.recent.Len()
 	freqLen := c.frequent.Len()
 	if recentLen+freqLen < c.size {
-		return
+		return false
 	}
 
 	if recentLen > 0 && (recentLen > c.recentSize || recentLen == c.recentSize && !recentEvict) {
 		k, _, _ := c.recent.RemoveOldest()
-		var empty V
-		c.recentEvict.Add(k, empty)
-		return
+		c.recentEvict.Add(k, struct{}{})
+		return true
 	}
-	c.frequent.RemoveOldest()
+	_, _, ok := c.frequent.RemoveOldest()
+	return ok
 }
 
 func (c *TwoQueueCache[K, V]) Len() int {
@@ -136,24 +147,32 @@ func (c *TwoQueueCache[K, V]) Keys() []K {
 	return append(k1, k2...)
 }
 
-func (c *TwoQueueCache[K, V]) Remove(key K) {
+// Remove removes the provided key from the cache, returning true if the key
+// was contained. Forgetting a key that is only tracked as recently evicted
+// does not count as it being contained.
+func (c *TwoQueueCache[K, V]) Remove(key K) (present bool) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
+	if debugInvariants {
+		defer c.verify()
+	}
 
 	if c.frequent.Remove(key) {
-		return
+		return true
 	}
 	if c.recent.Remove(key) {
-		return
-	}
-	if c.recentEvict.Remove(key) {
-		return
+		return true
 	}
+	c.recentEvict.Remove(key)
+	return false
 }
 
 func (c *TwoQueueCache[K, V]) Purge() {
 	c.lock.Lock()
 	defer c.lock.Unlock()
+	if debugInvariants {
+		defer c.verify()
+	}
 
 	c.recent.Purge()
 	c.frequent.Purge()
@@ -174,4 +193,51 @@ func (c *TwoQueueCache[K, V]) Peek(key K) (value V, ok bool) {
 		return value, ok
 	}
 	return c.recent.Peek(key)
-}
\ No newline at end of file
+}
+
+// CheckInvariants verifies the internal bookkeeping of the cache: each queue
+// is consistent, no key is in more than one of the recent, frequent and
+// ghost queues, and the cache holds no more than its size. It returns an
+// error describing the first violation found. Builds with the dailzlru_debug
+// tag run it after every mutation and panic on a violation.
+func (c *TwoQueueCache[K, V]) CheckInvariants() error {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.checkInvariants()
+}
+
+func (c *TwoQueueCache[K, V]) checkInvariants() error {
+	if err := c.recent.CheckInvariants(); err != nil {
+		return fmt.Errorf("recent queue: %w", err)
+	}
+	if err := c.frequent.CheckInvariants(); err != nil {
+		return fmt.Errorf("frequent queue: %w", err)
+	}
+	if err := c.recentEvict.CheckInvariants(); err != nil {
+		return fmt.Errorf("ghost queue: %w", err)
+	}
+	if n := c.recent.Len() + c.frequent.Len(); n > c.size {
+		return fmt.Errorf("%d entries exceed size %d", n, c.size)
+	}
+	for _, k := range c.recent.Keys() {
+		if c.frequent.Contains(k) {
+			return fmt.Errorf("key %v is both recent and frequent", k)
+		}
+		if c.recentEvict.Contains(k) {
+			return fmt.Errorf("key %v is both recent and a ghost", k)
+		}
+	}
+	for _, k := range c.frequent.Keys() {
+		if c.recentEvict.Contains(k) {
+			return fmt.Errorf("key %v is both frequent and a ghost", k)
+		}
+	}
+	return nil
+}
+
+// verify panics if an invariant is violated; the lock must be held
+func (c *TwoQueueCache[K, V]) verify() {
+	if err := c.checkInvariants(); err != nil {
+		panic("2q: invariant violated: " + err.Error())
+	}
+}
@@ -60,3 +60,28 @@ func Benchmark2Q_Freq(b *testing.B) {
 	}
 	b.Logf("hit: %d miss: %d ratio: %f", hit, miss, float64(hit)/float64(miss))
 }
+
+func Test2Q_CheckInvariants(t *testing.T) {
+	l, err := New2Q[int, int](16)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 200; i++ {
+		k := int(getRand(t) % 48)
+		if _, ok := l.Get(k); !ok {
+			l.Add(k, k)
+		}
+		if i%7 == 0 {
+			l.Remove(int(getRand(t) % 48))
+		}
+	}
+	if err := l.CheckInvariants(); err != nil {
+		t.Fatalf("2Q error: %v", err)
+	}
+
+	// a key in two queues at once is a violation
+	l.frequent.Add(l.recent.Keys()[0], 0)
+	if err := l.CheckInvariants(); err == nil {
+		t.Fatalf("2Q error: overlapping queues not detected")
+	}
+}
@@ -0,0 +1,283 @@
+// Package adapter exposes the caches of package dailzLRU through the
+// interfaces of other popular cache libraries, so they can replace those
+// libraries behind existing abstractions. Ristretto has the method set of a
+// ristretto v2 Cache, Store implements gocache's store.StoreInterface and
+// GorillaStore gorilla's sessions.Store. The package is a module of its own,
+// so that only its users depend on those libraries.
+package adapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/dailz1/dailzLRU"
+	"github.com/eko/gocache/lib/v4/store"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned by SessionBackend.Get when there is no such
+// session, and wrapped in the store.NotFound errors of Store.
+var ErrNotFound = errors.New("value not found in store")
+
+// ErrUnsupportedOption is returned by Store.Set for the options it cannot
+// honour.
+var ErrUnsupportedOption = errors.New("unsupported store option")
+
+// ristrettoEntry is a value held by a Ristretto adapter
+type ristrettoEntry[V any] struct {
+	value   V
+	expires time.Time // zero if the value does not expire
+}
+
+// Ristretto has the method set of a ristretto v2 Cache. It is backed by a
+// GDSFCache whose capacity plays the role of ristretto's MaxCost, so the
+// cost passed to Set is the entry's weight. Unlike ristretto, every write is
+// applied before Set returns and admitted unless its cost exceeds the
+// maximum. Expired values are dropped when looked up, or evicted as any
+// other.
+type Ristretto[K comparable, V any] struct {
+	c *dailzLRU.GDSFCache[K, *ristrettoEntry[V]]
+}
+
+// NewRistretto constructs a Ristretto adapter whose entries may weigh up to
+// maxCost in total.
+func NewRistretto[K comparable, V any](maxCost int64) (*Ristretto[K, V], error) {
+	c, err := dailzLRU.NewGDSF[K, *ristrettoEntry[V]](maxCost, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Ristretto[K, V]{c: c}, nil
+}
+
+// get returns the unexpired entry of key, dropping an expired one
+func (r *Ristretto[K, V]) get(key K) (*ristrettoEntry[V], bool) {
+	e, ok := r.c.Get(key)
+	if !ok {
+		return nil, false
+	}
+	if !e.expires.IsZero() && !time.Now().Before(e.expires) {
+		r.c.RemoveIf(key, func(cur *ristrettoEntry[V]) bool { return cur == e })
+		return nil, false
+	}
+	return e, true
+}
+
+// Get returns the value for key, if present and not expired.
+func (r *Ristretto[K, V]) Get(key K) (V, bool) {
+	e, ok := r.get(key)
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	return e.value, true
+}
+
+// GetTTL returns the time left before key expires, 0 if it does not, and
+// false if it is not present or has expired.
+func (r *Ristretto[K, V]) GetTTL(key K) (time.Duration, bool) {
+	e, ok := r.get(key)
+	if !ok {
+		return 0, false
+	}
+	if e.expires.IsZero() {
+		return 0, true
+	}
+	return max(time.Until(e.expires), 0), true
+}
+
+// Set adds a value with the given cost, returning false if it was dropped
+// because its cost exceeds the maximum. A cost of 0 counts as 1.
+func (r *Ristretto[K, V]) Set(key K, value V, cost int64) bool {
+	return r.SetWithTTL(key, value, cost, 0)
+}
+
+// SetWithTTL is like Set for a value expiring after ttl. A ttl of 0 means
+// the value does not expire; a negative one drops the value.
+func (r *Ristretto[K, V]) SetWithTTL(key K, value V, cost int64, ttl time.Duration) bool {
+	if ttl < 0 {
+		return false
+	}
+	e := &ristrettoEntry[V]{value: value}
+	if ttl > 0 {
+		e.expires = time.Now().Add(ttl)
+	}
+	if cost <= 0 {
+		cost = 1
+	}
+	_, err := r.c.Add(key, e, cost, 1)
+	return err == nil
+}
+
+// Del removes key from the cache.
+func (r *Ristretto[K, V]) Del(key K) {
+	r.c.Remove(key)
+}
+
+// Clear removes every entry from the cache.
+func (r *Ristretto[K, V]) Clear() {
+	r.c.Purge()
+}
+
+// MaxCost returns the total cost the cache can hold.
+func (r *Ristretto[K, V]) MaxCost() int64 {
+	return r.c.Capacity()
+}
+
+// UpdateMaxCost changes the total cost the cache can hold, evicting entries
+// if it shrinks below their cost.
+func (r *Ristretto[K, V]) UpdateMaxCost(maxCost int64) {
+	r.c.Resize(maxCost)
+}
+
+// RemainingCost returns the cost that can still be added without evicting.
+func (r *Ristretto[K, V]) RemainingCost() int64 {
+	return r.c.Capacity() - r.c.Size()
+}
+
+// Wait returns immediately: unlike ristretto, writes are applied
+// synchronously.
+func (r *Ristretto[K, V]) Wait() {}
+
+// Close releases nothing, as the adapter holds no background resources.
+func (r *Ristretto[K, V]) Close() {}
+
+// storeEntry is a value held by a Store
+type storeEntry struct {
+	value   any
+	expires time.Time // zero if the value does not expire
+	tags    []string
+}
+
+// expired reports whether e has expired at now
+func (e *storeEntry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires)
+}
+
+// Store implements gocache's store.StoreInterface, with keys and values of
+// any type. It honours the expiration and tags options of Set; its writes
+// are always synchronous, and the cost and client side caching options,
+// which it cannot honour, make Set fail with ErrUnsupportedOption.
+// Invalidate looks at every entry, so it takes time linear in the size of
+// the cache. Expired values are dropped when looked up, or evicted as any
+// other.
+type Store struct {
+	c    dailzLRU.Interface[any, any]
+	lock sync.Mutex // serializes removals, so a replaced value is kept
+}
+
+var _ store.StoreInterface = (*Store)(nil)
+
+// NewStore constructs a Store backed by c, which holds values of its own
+// and must not be used other than through the Store.
+func NewStore(c dailzLRU.Interface[any, any]) *Store {
+	return &Store{c: c}
+}
+
+// get returns the unexpired entry of key, dropping an expired one
+func (s *Store) get(key any) (*storeEntry, error) {
+	v, ok := s.c.Get(key)
+	if !ok {
+		return nil, store.NotFoundWithCause(ErrNotFound)
+	}
+	e := v.(*storeEntry)
+	if e.expired(time.Now()) {
+		s.lock.Lock()
+		if cur, ok := s.c.Peek(key); ok && cur == any(e) {
+			s.c.Remove(key)
+		}
+		s.lock.Unlock()
+		return nil, store.NotFoundWithCause(ErrNotFound)
+	}
+	return e, nil
+}
+
+// Get returns the value for key, or a store.NotFound error wrapping
+// ErrNotFound.
+func (s *Store) Get(ctx context.Context, key any) (any, error) {
+	e, err := s.get(key)
+	if err != nil {
+		return nil, err
+	}
+	return e.value, nil
+}
+
+// GetWithTTL is like Get, also returning the time left before the value
+// expires, 0 if it does not.
+func (s *Store) GetWithTTL(ctx context.Context, key any) (any, time.Duration, error) {
+	e, err := s.get(key)
+	if err != nil {
+		return nil, 0, err
+	}
+	if e.expires.IsZero() {
+		return e.value, 0, nil
+	}
+	return e.value, max(time.Until(e.expires), 0), nil
+}
+
+// Set stores value for key, with the expiration and tags given in options.
+func (s *Store) Set(ctx context.Context, key any, value any, options ...store.Option) error {
+	o := store.ApplyOptions(options...)
+	if o.Cost != 0 {
+		return fmt.Errorf("%w: cost", ErrUnsupportedOption)
+	}
+	if o.ClientSideCacheExpiration != 0 {
+		return fmt.Errorf("%w: client side caching", ErrUnsupportedOption)
+	}
+	e := &storeEntry{value: value, tags: append([]string(nil), o.Tags...)}
+	if o.Expiration > 0 {
+		e.expires = time.Now().Add(o.Expiration)
+	}
+	s.lock.Lock()
+	s.c.Add(key, e)
+	s.lock.Unlock()
+	return nil
+}
+
+// Delete removes key from the store.
+func (s *Store) Delete(ctx context.Context, key any) error {
+	s.lock.Lock()
+	s.c.Remove(key)
+	s.lock.Unlock()
+	return nil
+}
+
+// Invalidate removes the values with any of the tags given in options.
+func (s *Store) Invalidate(ctx context.Context, options ...store.InvalidateOption) error {
+	o := store.ApplyInvalidateOptions(options...)
+	if len(o.Tags) == 0 {
+		return nil
+	}
+	tags := make(map[string]bool, len(o.Tags))
+	for _, tag := range o.Tags {
+		tags[tag] = true
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, key := range s.c.Keys() {
+		v, ok := s.c.Peek(key)
+		if !ok {
+			continue
+		}
+		for _, tag := range v.(*storeEntry).tags {
+			if tags[tag] {
+				s.c.Remove(key)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// Clear removes every entry from the store.
+func (s *Store) Clear(ctx context.Context) error {
+	s.lock.Lock()
+	s.c.Purge()
+	s.lock.Unlock()
+	return nil
+}
+
+// GetType returns the store's type name.
+func (s *Store) GetType() string {
+	return "dailzlru"
+}
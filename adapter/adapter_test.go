@@ -0,0 +1,150 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"github.com/dailz1/dailzLRU"
+	"github.com/eko/gocache/lib/v4/store"
+	"testing"
+	"time"
+)
+
+// ristrettoCache is the method set of a ristretto v2 Cache
+type ristrettoCache[K comparable, V any] interface {
+	Get(key K) (V, bool)
+	GetTTL(key K) (time.Duration, bool)
+	Set(key K, value V, cost int64) bool
+	SetWithTTL(key K, value V, cost int64, ttl time.Duration) bool
+	Del(key K)
+	Clear()
+	MaxCost() int64
+	UpdateMaxCost(maxCost int64)
+	RemainingCost() int64
+	Wait()
+	Close()
+}
+
+var _ ristrettoCache[string, string] = (*Ristretto[string, string])(nil)
+
+func TestRistretto(t *testing.T) {
+	r, err := NewRistretto[string, string](10)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !r.Set("a", "1", 6) || !r.Set("b", "2", 4) {
+		t.Fatalf("set should succeed")
+	}
+	if r.Set("huge", "3", 11) {
+		t.Fatalf("set over the max cost should be dropped")
+	}
+	r.Wait()
+	if v, ok := r.Get("a"); !ok || v != "1" {
+		t.Fatalf("bad value: %v, %v", v, ok)
+	}
+	r.Set("c", "3", 4)
+	if _, ok := r.Get("b"); ok {
+		t.Fatalf("b should have been evicted to fit c")
+	}
+	if r.MaxCost() != 10 || r.RemainingCost() != 0 {
+		t.Fatalf("bad cost: %v, %v", r.MaxCost(), r.RemainingCost())
+	}
+	r.UpdateMaxCost(5)
+	if _, ok := r.Get("a"); ok || r.MaxCost() != 5 || r.RemainingCost() != 1 {
+		t.Fatalf("a should have been evicted: %v", r.RemainingCost())
+	}
+	r.Del("c")
+	r.Set("d", "4", 1)
+	r.Clear()
+	if _, ok := r.Get("d"); ok {
+		t.Fatalf("cache should be empty")
+	}
+}
+
+func TestRistretto_TTL(t *testing.T) {
+	r, err := NewRistretto[string, string](10)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if r.SetWithTTL("a", "1", 1, -time.Second) {
+		t.Fatalf("set with a negative ttl should be dropped")
+	}
+	r.Set("a", "1", 1)
+	r.SetWithTTL("b", "2", 1, 50*time.Millisecond)
+	if ttl, ok := r.GetTTL("a"); !ok || ttl != 0 {
+		t.Fatalf("bad ttl: %v, %v", ttl, ok)
+	}
+	if ttl, ok := r.GetTTL("b"); !ok || ttl <= 0 || ttl > 50*time.Millisecond {
+		t.Fatalf("bad ttl: %v, %v", ttl, ok)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if _, ok := r.Get("b"); ok {
+		t.Fatalf("b should have expired")
+	}
+	if _, ok := r.GetTTL("b"); ok || r.RemainingCost() != 9 {
+		t.Fatalf("b should have been dropped: %v", r.RemainingCost())
+	}
+}
+
+func TestStore(t *testing.T) {
+	c, err := dailzLRU.New[any, any](8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	s := NewStore(c)
+	ctx := context.Background()
+	if err := s.Set(ctx, "a", 1); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if v, err := s.Get(ctx, "a"); err != nil || v != 1 {
+		t.Fatalf("bad value: %v, %v", v, err)
+	}
+	s.Delete(ctx, "a")
+	if _, _, err := s.GetWithTTL(ctx, "a"); !errors.Is(err, ErrNotFound) || !errors.Is(err, store.NotFound{}) {
+		t.Fatalf("bad error: %v", err)
+	}
+
+	if err := s.Set(ctx, "a", 1, store.WithCost(2)); !errors.Is(err, ErrUnsupportedOption) {
+		t.Fatalf("bad error: %v", err)
+	}
+	if err := s.Set(ctx, "a", 1, store.WithSynchronousSet()); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	s.Clear(ctx)
+	if c.Len() != 0 {
+		t.Fatalf("store should be empty")
+	}
+}
+
+func TestStore_Options(t *testing.T) {
+	c, err := dailzLRU.New[any, any](8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	s := NewStore(c)
+	ctx := context.Background()
+	s.Set(ctx, "a", 1, store.WithTags([]string{"x"}))
+	s.Set(ctx, "b", 2, store.WithTags([]string{"x", "y"}))
+	s.Set(ctx, "c", 3, store.WithExpiration(50*time.Millisecond), store.WithTags([]string{"y"}))
+	if _, ttl, err := s.GetWithTTL(ctx, "c"); err != nil || ttl <= 0 || ttl > 50*time.Millisecond {
+		t.Fatalf("bad ttl: %v, %v", ttl, err)
+	}
+	if _, ttl, err := s.GetWithTTL(ctx, "a"); err != nil || ttl != 0 {
+		t.Fatalf("bad ttl: %v, %v", ttl, err)
+	}
+
+	s.Invalidate(ctx, store.WithInvalidateTags([]string{"x"}))
+	if _, err := s.Get(ctx, "a"); err == nil {
+		t.Fatalf("a should have been invalidated")
+	}
+	if _, err := s.Get(ctx, "b"); err == nil {
+		t.Fatalf("b should have been invalidated")
+	}
+	if v, err := s.Get(ctx, "c"); err != nil || v != 3 {
+		t.Fatalf("bad value: %v, %v", v, err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if _, err := s.Get(ctx, "c"); !errors.Is(err, ErrNotFound) || c.Len() != 0 {
+		t.Fatalf("c should have expired: %v", err)
+	}
+}
@@ -0,0 +1,17 @@
+module github.com/dailz1/dailzLRU/adapter
+
+go 1.22
+
+require (
+	github.com/dailz1/dailzLRU v0.0.0
+	github.com/eko/gocache/lib/v4 v4.2.0
+	github.com/gorilla/securecookie v1.1.2
+	github.com/gorilla/sessions v1.2.2
+)
+
+require (
+	go.uber.org/mock v0.4.0 // indirect
+	golang.org/x/exp v0.0.0-20240416160154-fe59bbe5cc7f // indirect
+)
+
+replace github.com/dailz1/dailzLRU => ../
@@ -0,0 +1,264 @@
+package adapter
+
+import (
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"github.com/dailz1/dailzLRU"
+	"github.com/dailz1/dailzLRU/persistent"
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+	"net/http"
+	"time"
+)
+
+// SessionBackend is a store of encoded sessions by ID, each with an expiry.
+// It has the Get/Save/Delete shape of common session stores; GorillaStore
+// turns one into a gorilla sessions.Store.
+type SessionBackend interface {
+	// Get returns the data and expiry of the session with the given ID, or
+	// ErrNotFound if it does not exist or has expired.
+	Get(id string) (data []byte, expiry time.Time, err error)
+	// Save stores the data of a session until expiry.
+	Save(id string, data []byte, expiry time.Time) error
+	// Delete removes a session.
+	Delete(id string) error
+}
+
+// session is a session held by a SessionStore
+type session struct {
+	data   []byte
+	expiry time.Time
+}
+
+// SessionStore is a SessionBackend keeping sessions in an ExpirableCache,
+// optionally writing them through to a slower backend that outlives it. A
+// session missing from memory is read from that backend and cached again.
+type SessionStore struct {
+	c    *dailzLRU.ExpirableCache[string, session]
+	next SessionBackend
+}
+
+// NewSessionStore constructs a SessionStore holding up to size sessions in
+// memory for at most maxAge each, however long their expiry, writing them
+// through to next if it is not nil. Close must be called to stop the
+// expiry of the cache.
+func NewSessionStore(size int, maxAge time.Duration, next SessionBackend) (*SessionStore, error) {
+	c, err := dailzLRU.NewExpirable[string, session](size, maxAge, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &SessionStore{c: c, next: next}, nil
+}
+
+// Get returns the data and expiry of the session with the given ID, or
+// ErrNotFound if it does not exist or has expired. The data must not be
+// modified.
+func (s *SessionStore) Get(id string) (data []byte, expiry time.Time, err error) {
+	if e, ok := s.c.Get(id); ok {
+		if time.Now().Before(e.expiry) {
+			return e.data, e.expiry, nil
+		}
+		s.c.Remove(id)
+	}
+	if s.next == nil {
+		return nil, time.Time{}, ErrNotFound
+	}
+	data, expiry, err = s.next.Get(id)
+	if err == nil {
+		s.c.Add(id, session{data: data, expiry: expiry})
+	}
+	return data, expiry, err
+}
+
+// Save stores the data of a session until expiry, in memory and then in
+// the backend it writes through to. An expiry in the past deletes the
+// session.
+func (s *SessionStore) Save(id string, data []byte, expiry time.Time) error {
+	if !time.Now().Before(expiry) {
+		return s.Delete(id)
+	}
+	data = append([]byte(nil), data...)
+	s.c.Add(id, session{data: data, expiry: expiry})
+	if s.next != nil {
+		return s.next.Save(id, data, expiry)
+	}
+	return nil
+}
+
+// Delete removes a session, from memory and from the backend it writes
+// through to.
+func (s *SessionStore) Delete(id string) error {
+	s.c.Remove(id)
+	if s.next != nil {
+		return s.next.Delete(id)
+	}
+	return nil
+}
+
+// Close stops the expiry of the cache. It does not close the backend.
+func (s *SessionStore) Close() {
+	s.c.Close()
+}
+
+// persistentSessions is a SessionBackend over a persistent.Cache
+type persistentSessions struct {
+	c *persistent.Cache
+}
+
+// PersistentSessions returns a SessionBackend storing sessions in c, for a
+// SessionStore to write through to so sessions survive restarts. The
+// expiry is stored in front of the data, so each slot of c must hold the
+// session ID, the data and 8 more bytes.
+func PersistentSessions(c *persistent.Cache) SessionBackend {
+	return persistentSessions{c: c}
+}
+
+func (p persistentSessions) Get(id string) (data []byte, expiry time.Time, err error) {
+	b, ok := p.c.Get(id)
+	if !ok {
+		return nil, time.Time{}, ErrNotFound
+	}
+	if len(b) < 8 {
+		return nil, time.Time{}, errors.New("corrupt session entry")
+	}
+	expiry = time.Unix(0, int64(binary.LittleEndian.Uint64(b)))
+	if !time.Now().Before(expiry) {
+		p.c.Remove(id)
+		return nil, time.Time{}, ErrNotFound
+	}
+	return b[8:], expiry, nil
+}
+
+func (p persistentSessions) Save(id string, data []byte, expiry time.Time) error {
+	b := make([]byte, 8+len(data))
+	binary.LittleEndian.PutUint64(b, uint64(expiry.UnixNano()))
+	copy(b[8:], data)
+	_, err := p.c.Add(id, b)
+	return err
+}
+
+func (p persistentSessions) Delete(id string) error {
+	p.c.Remove(id)
+	return nil
+}
+
+// GorillaStore is a gorilla sessions.Store keeping sessions in a
+// SessionBackend, such as a SessionStore. The session ID travels in a
+// cookie signed, and optionally encrypted, by Codecs; the session values
+// are encoded by Codecs as well and saved in the backend until MaxAge from
+// now.
+type GorillaStore struct {
+	Codecs  []securecookie.Codec
+	Options *sessions.Options // default configuration
+	backend SessionBackend
+}
+
+var _ sessions.Store = (*GorillaStore)(nil)
+
+// base32RawStdEncoding encodes session IDs
+var base32RawStdEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewGorillaStore constructs a GorillaStore keeping sessions in backend,
+// with codecs built from keyPairs as by securecookie.CodecsFromPairs.
+// Sessions last 30 days by default. As the cookie only holds the session
+// ID, the length of the session values is not limited.
+func NewGorillaStore(backend SessionBackend, keyPairs ...[]byte) *GorillaStore {
+	s := &GorillaStore{
+		Codecs: securecookie.CodecsFromPairs(keyPairs...),
+		Options: &sessions.Options{
+			Path:   "/",
+			MaxAge: 86400 * 30,
+		},
+		backend: backend,
+	}
+	for _, codec := range s.Codecs {
+		if sc, ok := codec.(*securecookie.SecureCookie); ok {
+			sc.MaxLength(0)
+		}
+	}
+	s.MaxAge(s.Options.MaxAge)
+	return s
+}
+
+// MaxAge sets the maximum age of the sessions of the store, in seconds.
+// Individual sessions can be deleted by setting their Options.MaxAge to -1.
+func (s *GorillaStore) MaxAge(age int) {
+	s.Options.MaxAge = age
+	for _, codec := range s.Codecs {
+		if sc, ok := codec.(*securecookie.SecureCookie); ok {
+			sc.MaxAge(age)
+		}
+	}
+}
+
+// Get returns the session for the given name after adding it to the
+// registry of r.
+func (s *GorillaStore) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return sessions.GetRegistry(r).Get(s, name)
+}
+
+// New returns the session for the given name without adding it to the
+// registry of r. It is loaded from the backend if r carries the cookie of
+// a live session; otherwise a new session is returned, along with an error
+// if the cookie could not be decoded.
+func (s *GorillaStore) New(r *http.Request, name string) (*sessions.Session, error) {
+	session := sessions.NewSession(s, name)
+	opts := *s.Options
+	session.Options = &opts
+	session.IsNew = true
+	c, err := r.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+	if err := securecookie.DecodeMulti(name, c.Value, &session.ID, s.Codecs...); err != nil {
+		session.ID = ""
+		return session, err
+	}
+	data, _, err := s.backend.Get(session.ID)
+	if errors.Is(err, ErrNotFound) {
+		// never revive an expired or deleted ID
+		session.ID = ""
+		return session, nil
+	}
+	if err != nil {
+		return session, err
+	}
+	if err := securecookie.DecodeMulti(name, string(data), &session.Values, s.Codecs...); err != nil {
+		return session, err
+	}
+	session.IsNew = false
+	return session, nil
+}
+
+// Save saves the session in the backend and sets its cookie on w. A
+// session whose Options.MaxAge is not positive is deleted from the backend
+// and its cookie cleared.
+func (s *GorillaStore) Save(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	if session.Options.MaxAge <= 0 {
+		if session.ID != "" {
+			if err := s.backend.Delete(session.ID); err != nil {
+				return err
+			}
+		}
+		http.SetCookie(w, sessions.NewCookie(session.Name(), "", session.Options))
+		return nil
+	}
+	if session.ID == "" {
+		session.ID = base32RawStdEncoding.EncodeToString(securecookie.GenerateRandomKey(32))
+	}
+	data, err := securecookie.EncodeMulti(session.Name(), session.Values, s.Codecs...)
+	if err != nil {
+		return err
+	}
+	expiry := time.Now().Add(time.Duration(session.Options.MaxAge) * time.Second)
+	if err := s.backend.Save(session.ID, []byte(data), expiry); err != nil {
+		return err
+	}
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.ID, s.Codecs...)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, sessions.NewCookie(session.Name(), encoded, session.Options))
+	return nil
+}
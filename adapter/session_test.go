@@ -0,0 +1,129 @@
+package adapter
+
+import (
+	"github.com/dailz1/dailzLRU/persistent"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessionStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions")
+	p, err := persistent.Open(path, 8, 128)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer p.Close()
+	s, err := NewSessionStore(8, time.Minute, PersistentSessions(p))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer s.Close()
+
+	if err := s.Save("a", []byte("alice"), time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if data, _, err := s.Get("a"); err != nil || string(data) != "alice" {
+		t.Fatalf("bad session: %q, %v", data, err)
+	}
+
+	// a new store reads the session written through
+	s2, err := NewSessionStore(8, time.Minute, PersistentSessions(p))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer s2.Close()
+	if data, _, err := s2.Get("a"); err != nil || string(data) != "alice" {
+		t.Fatalf("session not written through: %q, %v", data, err)
+	}
+
+	if err := s.Save("b", []byte("bob"), time.Now().Add(20*time.Millisecond)); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	time.Sleep(40 * time.Millisecond)
+	if _, _, err := s.Get("b"); err != ErrNotFound {
+		t.Fatalf("expired session returned: %v", err)
+	}
+	if p.Contains("b") {
+		t.Fatalf("expired session not removed from the backend")
+	}
+
+	if err := s.Delete("a"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if p.Contains("a") {
+		t.Fatalf("session not deleted from the backend")
+	}
+	if err := s.Save("c", []byte("carol"), time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, _, err := s.Get("c"); err != ErrNotFound {
+		t.Fatalf("session saved with a past expiry")
+	}
+}
+
+func TestGorillaStore(t *testing.T) {
+	backend, err := NewSessionStore(8, time.Minute, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer backend.Close()
+	s := NewGorillaStore(backend, []byte("0123456789abcdef0123456789abcdef"))
+
+	// a request without a cookie gets a new session, saved with a cookie
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	session, err := s.Get(req, "sid")
+	if err != nil || !session.IsNew {
+		t.Fatalf("bad new session: %v, %v", session, err)
+	}
+	session.Values["user"] = "alice"
+	rec := httptest.NewRecorder()
+	if err := s.Save(req, rec, session); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "sid" || strings.Contains(cookies[0].Value, "alice") {
+		t.Fatalf("bad cookies: %v", cookies)
+	}
+	if _, _, err := backend.Get(session.ID); err != nil {
+		t.Fatalf("session not saved: %v", err)
+	}
+
+	// the cookie loads the session back
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookies[0])
+	loaded, err := s.New(req, "sid")
+	if err != nil || loaded.IsNew || loaded.ID != session.ID || loaded.Values["user"] != "alice" {
+		t.Fatalf("bad loaded session: %v, %v", loaded, err)
+	}
+
+	// a tampered cookie is rejected
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "sid", Value: cookies[0].Value + "x"})
+	if tampered, err := s.New(req, "sid"); err == nil || !tampered.IsNew || tampered.ID != "" {
+		t.Fatalf("tampered cookie accepted: %v, %v", tampered, err)
+	}
+
+	// a negative MaxAge deletes the session and clears the cookie
+	loaded.Options.MaxAge = -1
+	rec = httptest.NewRecorder()
+	if err := s.Save(req, rec, loaded); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, _, err := backend.Get(session.ID); err != ErrNotFound {
+		t.Fatalf("session not deleted: %v", err)
+	}
+	if cookies := rec.Result().Cookies(); len(cookies) != 1 || cookies[0].MaxAge >= 0 {
+		t.Fatalf("cookie not cleared: %v", cookies)
+	}
+
+	// a deleted session is not revived by its cookie
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookies[0])
+	if revived, err := s.New(req, "sid"); err != nil || !revived.IsNew || revived.ID != "" {
+		t.Fatalf("deleted session revived: %v, %v", revived, err)
+	}
+}
@@ -0,0 +1,158 @@
+package dailzLRU
+
+import (
+	"sort"
+	"time"
+)
+
+// DefaultAgeBounds are the bucket bounds TrackEvictionAges uses when given
+// none.
+var DefaultAgeBounds = []time.Duration{
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+	10 * time.Second,
+	time.Minute,
+	10 * time.Minute,
+	time.Hour,
+}
+
+// AgeHistogram counts entries by age. Counts[i] is the number of entries no
+// older than Bounds[i] and older than any earlier bound; the extra last
+// count is for entries older than every bound.
+type AgeHistogram struct {
+	Bounds []time.Duration
+	Counts []uint64
+}
+
+// Total returns the number of entries counted.
+func (h AgeHistogram) Total() (n uint64) {
+	for _, c := range h.Counts {
+		n += c
+	}
+	return n
+}
+
+// observe counts an entry of the given age
+func (h AgeHistogram) observe(age time.Duration) {
+	h.Counts[sort.Search(len(h.Bounds), func(i int) bool { return age <= h.Bounds[i] })]++
+}
+
+// EvictionAges holds the ages of the entries evicted to make room, as
+// recorded by TrackEvictionAges. Many evictions of entries last accessed
+// shortly before suggest the cache is too small for its working set.
+type EvictionAges struct {
+	// SinceAdd is the time from an entry being added to its eviction.
+	SinceAdd AgeHistogram
+	// SinceAccess is the time from an entry's last Get or Add to its
+	// eviction.
+	SinceAccess AgeHistogram
+}
+
+// entryTimes are the timestamps of an entry tracked for EvictionAges
+type entryTimes struct {
+	added    time.Time
+	accessed time.Time
+}
+
+// ageTracker keeps the timestamps of the entries of a cache and the ages
+// of its evicted entries
+type ageTracker[K comparable] struct {
+	times map[K]entryTimes
+	ages  EvictionAges
+	now   func() time.Time
+}
+
+// newAgeTracker returns an ageTracker with the given bucket bounds
+func newAgeTracker[K comparable](bounds []time.Duration) *ageTracker[K] {
+	bounds = append([]time.Duration(nil), bounds...)
+	sort.Slice(bounds, func(i, j int) bool { return bounds[i] < bounds[j] })
+	return &ageTracker[K]{
+		times: make(map[K]entryTimes),
+		ages: EvictionAges{
+			SinceAdd:    AgeHistogram{Bounds: bounds, Counts: make([]uint64, len(bounds)+1)},
+			SinceAccess: AgeHistogram{Bounds: bounds, Counts: make([]uint64, len(bounds)+1)},
+		},
+		now: time.Now,
+	}
+}
+
+// add records key being added, keeping the time it was first added if it is
+// already present
+func (t *ageTracker[K]) add(key K) {
+	now := t.now()
+	e, ok := t.times[key]
+	if !ok {
+		e.added = now
+	}
+	e.accessed = now
+	t.times[key] = e
+}
+
+// access records a read of key
+func (t *ageTracker[K]) access(key K) {
+	if e, ok := t.times[key]; ok {
+		e.accessed = t.now()
+		t.times[key] = e
+	}
+}
+
+// remove forgets key, counting its ages if it was evicted to make room
+func (t *ageTracker[K]) remove(key K, evicted bool) {
+	e, ok := t.times[key]
+	if !ok {
+		return
+	}
+	delete(t.times, key)
+	if evicted {
+		now := t.now()
+		t.ages.SinceAdd.observe(now.Sub(e.added))
+		t.ages.SinceAccess.observe(now.Sub(e.accessed))
+	}
+}
+
+// snapshot returns a copy of the recorded ages
+func (t *ageTracker[K]) snapshot() EvictionAges {
+	return EvictionAges{
+		SinceAdd: AgeHistogram{
+			Bounds: append([]time.Duration(nil), t.ages.SinceAdd.Bounds...),
+			Counts: append([]uint64(nil), t.ages.SinceAdd.Counts...),
+		},
+		SinceAccess: AgeHistogram{
+			Bounds: append([]time.Duration(nil), t.ages.SinceAccess.Bounds...),
+			Counts: append([]uint64(nil), t.ages.SinceAccess.Counts...),
+		},
+	}
+}
+
+// TrackEvictionAges starts recording how long entries evicted to make room
+// had been in the cache and how long since they were last accessed, in
+// histograms with the given bucket bounds, or DefaultAgeBounds if there are
+// none. Entries removed explicitly are not counted. Tracking keeps two
+// timestamps per entry and makes Get on a FIFO cache take the write lock.
+// Calling it again resets the histograms; entries added before the first
+// call are not counted.
+func (c *Cache[K, V]) TrackEvictionAges(bounds ...time.Duration) {
+	if len(bounds) == 0 {
+		bounds = DefaultAgeBounds
+	}
+	c.lock.Lock()
+	t := newAgeTracker[K](bounds)
+	if c.ages != nil {
+		t.times = c.ages.times
+	}
+	c.ages = t
+	c.lock.Unlock()
+}
+
+// EvictionAges returns the ages recorded since TrackEvictionAges was called,
+// or empty histograms if it was not.
+func (c *Cache[K, V]) EvictionAges() EvictionAges {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if c.ages == nil {
+		return EvictionAges{}
+	}
+	return c.ages.snapshot()
+}
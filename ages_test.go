@@ -0,0 +1,74 @@
+package dailzLRU
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCache_EvictionAges(t *testing.T) {
+	l, err := New[int, int](2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if ages := l.EvictionAges(); ages.SinceAdd.Total() != 0 {
+		t.Fatalf("ages recorded without tracking: %+v", ages)
+	}
+	l.TrackEvictionAges(time.Second, time.Minute)
+	now := time.Unix(0, 0)
+	l.ages.now = func() time.Time { return now }
+
+	l.Add(1, 1)
+	l.Add(2, 2)
+	now = now.Add(30 * time.Second)
+	l.Get(1)
+	now = now.Add(2 * time.Minute)
+	l.Add(3, 3) // evicts 2: added and accessed 150s ago
+	now = now.Add(500 * time.Millisecond)
+	l.Remove(3) // not counted
+	l.Add(4, 4)
+	l.Add(5, 5) // evicts 1: added 151s and accessed 121s ago
+	l.Add(6, 6) // evicts 4: added and accessed now
+
+	ages := l.EvictionAges()
+	if got := ages.SinceAdd.Counts; len(got) != 3 || got[0] != 1 || got[1] != 0 || got[2] != 2 {
+		t.Fatalf("bad ages since add: %v", got)
+	}
+	if got := ages.SinceAccess.Counts; got[0] != 1 || got[1] != 0 || got[2] != 2 {
+		t.Fatalf("bad ages since access: %v", got)
+	}
+	if ages.SinceAccess.Total() != 3 {
+		t.Fatalf("bad total: %v", ages.SinceAccess.Total())
+	}
+
+	// the histograms are snapshots
+	ages.SinceAdd.Counts[0] = 100
+	if l.EvictionAges().SinceAdd.Counts[0] != 1 {
+		t.Fatalf("snapshot shares counts with the cache")
+	}
+	l.Purge()
+	if len(l.ages.times) != 0 {
+		t.Fatalf("purge left timestamps: %v", l.ages.times)
+	}
+}
+
+func TestCache_EvictionAgesFIFO(t *testing.T) {
+	l, err := NewFIFO[int, int](1)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.TrackEvictionAges()
+	now := time.Unix(0, 0)
+	l.ages.now = func() time.Time { return now }
+	l.Add(1, 1)
+	now = now.Add(time.Hour)
+	l.Get(1)
+	now = now.Add(time.Millisecond)
+	l.Add(2, 2)
+	ages := l.EvictionAges()
+	if len(ages.SinceAdd.Bounds) != len(DefaultAgeBounds) {
+		t.Fatalf("bad bounds: %v", ages.SinceAdd.Bounds)
+	}
+	if ages.SinceAccess.Counts[0] != 1 || ages.SinceAdd.Counts[len(DefaultAgeBounds)] != 1 {
+		t.Fatalf("bad ages: %+v", ages)
+	}
+}
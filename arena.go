@@ -0,0 +1,267 @@
+package dailzLRU
+
+import (
+	"errors"
+	"github.com/dailz1/dailzLRU/lru"
+	"sort"
+	"sync"
+)
+
+// arenaRef locates a value inside the arena
+type arenaRef struct {
+	seg, off, n int
+}
+
+// arenaSegment is a fixed size block of the arena values are appended to
+type arenaSegment struct {
+	buf       []byte
+	used      int // bytes handed out since the segment was last recycled
+	live      int // number of values still referenced
+	liveBytes int // bytes of the values still referenced
+}
+
+// ArenaCache is a thread-safe fixed size LRU cache for []byte values. Values
+// are copied into a small number of large pre-allocated segments instead of
+// being kept as individual allocations, so millions of entries do not become
+// millions of objects for the garbage collector to track. A segment is
+// recycled once every value written to it has been evicted or removed. When
+// no segment is free, the segment with the fewest live bytes is compacted,
+// moving its values together to make room, so a few long-lived values
+// cannot pin a segment; only if no segment can be compacted enough are the
+// oldest entries evicted.
+type ArenaCache[K comparable] struct {
+	lru         *lru.LRU[K, arenaRef]
+	segments    []arenaSegment
+	free        []int
+	active      int
+	evictedKeys []K
+	evictedVals [][]byte
+	onEvictedCB func(k K, v []byte)
+	dropping    bool // set while a value is released without a callback
+	lock        sync.Mutex
+}
+
+// NewArena constructs an ArenaCache holding up to size entries whose values
+// share segments*segmentSize bytes of arena memory.
+func NewArena[K comparable](size, segmentSize, segments int) (*ArenaCache[K], error) {
+	return NewArenaWithEvict[K](size, segmentSize, segments, nil)
+}
+
+// NewArenaWithEvict constructs an ArenaCache with an eviction callback. The
+// callback receives a copy of the evicted value and is invoked outside of the
+// cache's lock.
+func NewArenaWithEvict[K comparable](size, segmentSize, segments int, onEvicted func(key K, value []byte)) (*ArenaCache[K], error) {
+	if segmentSize <= 0 || segments <= 0 {
+		return nil, errors.New("must provide a positive segment size and count")
+	}
+	c := &ArenaCache[K]{
+		segments:    make([]arenaSegment, segments),
+		free:        make([]int, 0, segments),
+		onEvictedCB: onEvicted,
+	}
+	for i := range c.segments {
+		c.segments[i].buf = make([]byte, segmentSize)
+		if i > 0 {
+			c.free = append(c.free, i)
+		}
+	}
+	l, err := lru.NewLRU[K, arenaRef](size, c.release)
+	if err != nil {
+		return nil, err
+	}
+	c.lru = l
+	return c, nil
+}
+
+// release gives a value's space back to its segment, recycling the segment
+// once nothing references it anymore
+func (c *ArenaCache[K]) release(key K, ref arenaRef) {
+	seg := &c.segments[ref.seg]
+	if c.onEvictedCB != nil && !c.dropping {
+		c.evictedKeys = append(c.evictedKeys, key)
+		c.evictedVals = append(c.evictedVals, append([]byte(nil), seg.buf[ref.off:ref.off+ref.n]...))
+	}
+	seg.live--
+	seg.liveBytes -= ref.n
+	if seg.live > 0 {
+		return
+	}
+	seg.used = 0
+	if ref.seg != c.active {
+		c.free = append(c.free, ref.seg)
+	}
+}
+
+// alloc reserves n bytes of arena space, compacting a segment or, failing
+// that, evicting the oldest entries if no segment has room. Returns true if
+// an eviction occurred.
+func (c *ArenaCache[K]) alloc(n int) (ref arenaRef, evicted bool) {
+	for {
+		seg := &c.segments[c.active]
+		if len(seg.buf)-seg.used >= n {
+			ref = arenaRef{seg: c.active, off: seg.used, n: n}
+			seg.used += n
+			seg.live++
+			seg.liveBytes += n
+			return ref, evicted
+		}
+		if last := len(c.free) - 1; last >= 0 {
+			// the full segment goes back to the free list once release
+			// drops its last value
+			c.active = c.free[last]
+			c.free = c.free[:last]
+			continue
+		}
+		sparsest := 0
+		for i := range c.segments {
+			if c.segments[i].liveBytes < c.segments[sparsest].liveBytes {
+				sparsest = i
+			}
+		}
+		if s := &c.segments[sparsest]; len(s.buf)-s.liveBytes >= n {
+			c.compact(sparsest)
+			continue
+		}
+		if _, _, ok := c.lru.RemoveOldest(); !ok {
+			// every segment is empty, so the active one has room
+			continue
+		}
+		evicted = true
+	}
+}
+
+// compact moves the values of segment i to its start and makes it the
+// active segment; the full segment it replaces goes back to the free list
+// once release drops its last value
+func (c *ArenaCache[K]) compact(i int) {
+	type moved struct {
+		key K
+		ref arenaRef
+	}
+	var values []moved
+	c.lru.Range(func(k K, ref arenaRef) bool {
+		if ref.seg == i {
+			values = append(values, moved{k, ref})
+		}
+		return true
+	})
+	// moving values in order of their offsets never overwrites one not yet
+	// moved
+	sort.Slice(values, func(a, b int) bool { return values[a].ref.off < values[b].ref.off })
+	seg := &c.segments[i]
+	off := 0
+	for _, v := range values {
+		copy(seg.buf[off:], seg.buf[v.ref.off:v.ref.off+v.ref.n])
+		c.lru.Replace(v.key, arenaRef{seg: i, off: off, n: v.ref.n})
+		off += v.ref.n
+	}
+	seg.used = off
+	c.active = i
+}
+
+// Add copies value into the arena and adds it to the cache. Returns true if
+// an eviction occurred, either to stay within size or to free arena space,
+// or ErrEntryTooLarge if value is larger than a segment.
+func (c *ArenaCache[K]) Add(key K, value []byte) (evicted bool, err error) {
+	if len(value) > len(c.segments[0].buf) {
+		return false, ErrEntryTooLarge
+	}
+	var ks []K
+	var vs [][]byte
+	c.lock.Lock()
+	c.dropping = true
+	c.lru.Remove(key)
+	c.dropping = false
+	ref, evicted := c.alloc(len(value))
+	copy(c.segments[ref.seg].buf[ref.off:], value)
+	if c.lru.Add(key, ref) {
+		evicted = true
+	}
+	ks, vs = c.takeEvicted()
+	c.lock.Unlock()
+	for i := 0; i < len(ks); i++ {
+		c.onEvictedCB(ks[i], vs[i])
+	}
+	return evicted, nil
+}
+
+// takeEvicted hands back the buffered evictions and resets the buffers
+func (c *ArenaCache[K]) takeEvicted() (ks []K, vs [][]byte) {
+	ks, vs = c.evictedKeys, c.evictedVals
+	c.evictedKeys, c.evictedVals = nil, nil
+	return ks, vs
+}
+
+// Get looks up a key's value from the cache, returning a copy of it.
+func (c *ArenaCache[K]) Get(key K) (value []byte, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	ref, ok := c.lru.Get(key)
+	if !ok {
+		return nil, false
+	}
+	return c.copyOut(ref), true
+}
+
+// Peek returns a copy of the key's value without updating the "recently
+// used"-ness of the key.
+func (c *ArenaCache[K]) Peek(key K) (value []byte, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	ref, ok := c.lru.Peek(key)
+	if !ok {
+		return nil, false
+	}
+	return c.copyOut(ref), true
+}
+
+// copyOut copies a value out of the arena
+func (c *ArenaCache[K]) copyOut(ref arenaRef) []byte {
+	return append([]byte(nil), c.segments[ref.seg].buf[ref.off:ref.off+ref.n]...)
+}
+
+// Contains checks if a key is in the cache, without updating its recent-ness.
+func (c *ArenaCache[K]) Contains(key K) (ok bool) {
+	c.lock.Lock()
+	ok = c.lru.Contains(key)
+	c.lock.Unlock()
+	return
+}
+
+// Remove removes the provided key from the cache, returning true if the key
+// was contained.
+func (c *ArenaCache[K]) Remove(key K) (present bool) {
+	c.lock.Lock()
+	present = c.lru.Remove(key)
+	ks, vs := c.takeEvicted()
+	c.lock.Unlock()
+	for i := 0; i < len(ks); i++ {
+		c.onEvictedCB(ks[i], vs[i])
+	}
+	return
+}
+
+// Keys returns a slice of the keys in the cache, from oldest to newest.
+func (c *ArenaCache[K]) Keys() []K {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.Keys()
+}
+
+// Len returns the number of items in the cache.
+func (c *ArenaCache[K]) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.Len()
+}
+
+// Purge is used to completely clear the cache.
+func (c *ArenaCache[K]) Purge() {
+	c.lock.Lock()
+	c.lru.Purge()
+	ks, vs := c.takeEvicted()
+	c.lock.Unlock()
+	for i := 0; i < len(ks); i++ {
+		c.onEvictedCB(ks[i], vs[i])
+	}
+}
@@ -0,0 +1,113 @@
+package dailzLRU
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestArenaCache(t *testing.T) {
+	var evicted []int
+	cache, err := NewArenaWithEvict(8, 16, 2, func(k int, v []byte) {
+		if len(v) != 8 || v[0] != byte(k) {
+			t.Fatalf("Arena error: bad evicted value for %v: %v", k, v)
+		}
+		evicted = append(evicted, k)
+	})
+	if err != nil {
+		t.Fatalf("Arena error: %v", err)
+	}
+
+	// each segment holds two values, so the fifth value needs room made by
+	// evicting the oldest one and compacting its segment
+	for i := 0; i < 4; i++ {
+		if evict, err := cache.Add(i, bytes.Repeat([]byte{byte(i)}, 8)); err != nil || evict {
+			t.Fatalf("Arena error: adding %v: evicted = %v, err = %v", i, evict, err)
+		}
+	}
+	if evict, _ := cache.Add(4, bytes.Repeat([]byte{4}, 8)); !evict {
+		t.Fatalf("Arena error: a full arena should evict")
+	}
+	if len(evicted) != 1 || evicted[0] != 0 {
+		t.Fatalf("Arena error: bad evicted keys: %v", evicted)
+	}
+	for i := 1; i < 5; i++ {
+		if v, ok := cache.Get(i); !ok || !bytes.Equal(v, bytes.Repeat([]byte{byte(i)}, 8)) {
+			t.Fatalf("Arena error: bad value for %v: %v", i, v)
+		}
+	}
+
+	// updating a key releases its old value without a callback
+	if _, err := cache.Add(2, bytes.Repeat([]byte{2}, 8)); err != nil {
+		t.Fatalf("Arena error: %v", err)
+	}
+	if len(evicted) != 1 {
+		t.Fatalf("Arena error: update should not fire the callback: %v", evicted)
+	}
+
+	if _, err := cache.Add(9, make([]byte, 17)); err != ErrEntryTooLarge {
+		t.Fatalf("Arena error: expected ErrEntryTooLarge, got %v", err)
+	}
+
+	cache.Purge()
+	if cache.Len() != 0 || len(evicted) != 5 {
+		t.Fatalf("Arena error: bad len after purge: %v, evicted: %v", cache.Len(), evicted)
+	}
+	for i := 0; i < 4; i++ {
+		if evict, _ := cache.Add(i+10, bytes.Repeat([]byte{byte(i + 10)}, 8)); evict {
+			t.Fatalf("Arena error: purged segments should be reusable")
+		}
+	}
+}
+
+func TestArenaCache_Compact(t *testing.T) {
+	var evicted []int
+	cache, err := NewArenaWithEvict(64, 16, 2, func(k int, v []byte) {
+		evicted = append(evicted, k)
+	})
+	if err != nil {
+		t.Fatalf("Arena error: %v", err)
+	}
+	value := func(k int) []byte { return bytes.Repeat([]byte{byte(k)}, 4) }
+
+	// each segment holds four values; 0 stays in use while its neighbours
+	// are removed, so no segment empties
+	for i := 0; i < 8; i++ {
+		cache.Add(i, value(i))
+	}
+	for _, k := range []int{1, 2, 3, 5, 6} {
+		cache.Remove(k)
+	}
+	cache.Get(0)
+	evicted = nil
+
+	// the first segment is compacted rather than the cache evicted
+	for i := 8; i < 11; i++ {
+		if evict, err := cache.Add(i, value(i)); err != nil || evict {
+			t.Fatalf("Arena error: adding %v: evicted = %v, err = %v", i, evict, err)
+		}
+	}
+	if len(evicted) != 0 || cache.Len() != 6 {
+		t.Fatalf("Arena error: evicted %v, len %v", evicted, cache.Len())
+	}
+	for _, k := range []int{0, 4, 7, 8, 9, 10} {
+		if v, ok := cache.Get(k); !ok || !bytes.Equal(v, value(k)) {
+			t.Fatalf("Arena error: bad value for %v: %v", k, v)
+		}
+	}
+
+	// so is the second segment, until every byte is live
+	for i := 11; i < 13; i++ {
+		if evict, _ := cache.Add(i, value(i)); evict {
+			t.Fatalf("Arena error: adding %v evicted %v", i, evicted)
+		}
+	}
+	// then the oldest entry is evicted, and only it
+	if evict, _ := cache.Add(13, value(13)); !evict || len(evicted) != 1 || evicted[0] != 0 {
+		t.Fatalf("Arena error: bad eviction: %v, %v", evict, evicted)
+	}
+	for _, k := range []int{4, 7, 8, 9, 10, 11, 12, 13} {
+		if v, ok := cache.Peek(k); !ok || !bytes.Equal(v, value(k)) {
+			t.Fatalf("Arena error: bad value for %v: %v", k, v)
+		}
+	}
+}
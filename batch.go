@@ -0,0 +1,121 @@
+package dailzLRU
+
+import "errors"
+
+// OpKind identifies the operation an Op performs.
+type OpKind int
+
+const (
+	// OpAdd adds Op.Value under Op.Key.
+	OpAdd OpKind = iota
+	// OpRemove removes Op.Key.
+	OpRemove
+	// OpTouch marks Op.Key as recently used without changing its value.
+	OpTouch
+)
+
+// Op is a single operation of a batch passed to ApplyBatch.
+type Op[K comparable, V any] struct {
+	Kind  OpKind
+	Key   K
+	Value V
+}
+
+// OpResult reports the outcome of the Op at the same index of a batch.
+type OpResult struct {
+	// Ok is true if the key was present for OpRemove and OpTouch, and
+	// always true for OpAdd.
+	Ok bool
+	// Evicted is true if an OpAdd caused an eviction.
+	Evicted bool
+}
+
+// ApplyBatch executes ops in order as one atomic step and returns a result
+// per op. A batch containing an unknown OpKind is rejected before any op is
+// applied, and the whole batch fails with ErrFrozen while the cache is
+// frozen. Eviction callbacks are invoked after the lock is released.
+func (c *Cache[K, V]) ApplyBatch(ops []Op[K, V]) ([]OpResult, error) {
+	for _, op := range ops {
+		if op.Kind < OpAdd || op.Kind > OpTouch {
+			return nil, errors.New("unknown op kind")
+		}
+	}
+
+	results := make([]OpResult, len(ops))
+	err := c.Do(func(tx Txn[K, V]) error {
+		for i, op := range ops {
+			switch op.Kind {
+			case OpAdd:
+				results[i] = OpResult{Ok: true, Evicted: tx.Add(op.Key, op.Value)}
+			case OpRemove:
+				results[i].Ok = tx.Remove(op.Key)
+			case OpTouch:
+				_, results[i].Ok = tx.Get(op.Key)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// NewFromMap constructs a cache of the given size holding the entries of m,
+// as added by AddMap.
+func NewFromMap[K comparable, V any](size int, m map[K]V) (*Cache[K, V], error) {
+	c, err := New[K, V](size)
+	if err != nil {
+		return nil, err
+	}
+	c.AddMap(m)
+	return c, nil
+}
+
+// AddMap adds the entries of m to the cache under a single acquisition of
+// the lock, which makes seeding a cache with many entries much cheaper than
+// calling Add for each. Room is made for the new keys in one pass before
+// they are added, so the entries of m never evict each other: if m holds
+// more entries than fit, only as many as fit are added, picked in map
+// order, and the others are dropped without a callback. Eviction callbacks
+// are invoked after the lock is released. Returns the number of entries
+// evicted; nothing is added while the cache is frozen.
+func (c *Cache[K, V]) AddMap(m map[K]V) (evicted int) {
+	var ks []K
+	var vs []V
+	c.lock.Lock()
+	if c.frozen {
+		c.lock.Unlock()
+		return 0
+	}
+	// update the keys already present first, so making room never evicts
+	// them
+	var added []K
+	for k, v := range m {
+		if c.lru.Contains(k) {
+			c.beforeAdd(k, v)
+			c.lru.Add(k, v)
+		} else {
+			added = append(added, k)
+		}
+	}
+	if room := c.lru.Capacity() - (len(m) - len(added)); len(added) > room {
+		added = added[:max(room, 0)]
+	}
+	evicted = c.lru.MakeRoom(len(added), func(k K) bool {
+		_, ok := m[k]
+		return ok
+	})
+	for _, k := range added {
+		c.beforeAdd(k, m[k])
+		c.lru.Add(k, m[k])
+	}
+	if c.onEvictedCB != nil && len(c.evictedKeys) > 0 {
+		ks = c.evictedKeys
+		vs = c.evictedVals
+		c.initEvictBuffers()
+	}
+	c.lock.Unlock()
+	c.notifyAll(ks, vs)
+	return evicted
+}
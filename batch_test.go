@@ -0,0 +1,125 @@
+package dailzLRU
+
+import "testing"
+
+func TestCache_ApplyBatch(t *testing.T) {
+	evictCounter := 0
+	cache, err := NewWithEvict(2, func(k int, v int) {
+		evictCounter++
+	})
+	if err != nil {
+		t.Fatalf("LRU error: %v", err)
+	}
+	cache.Add(1, 1)
+	cache.Add(2, 2)
+
+	results, err := cache.ApplyBatch([]Op[int, int]{
+		{Kind: OpTouch, Key: 1},
+		{Kind: OpAdd, Key: 3, Value: 3},
+		{Kind: OpRemove, Key: 2},
+		{Kind: OpTouch, Key: 2},
+	})
+	if err != nil {
+		t.Fatalf("ApplyBatch error: %v", err)
+	}
+	expected := []OpResult{{Ok: true}, {Ok: true, Evicted: true}, {Ok: false}, {Ok: false}}
+	for i := range expected {
+		if results[i] != expected[i] {
+			t.Fatalf("bad result %d: %+v", i, results[i])
+		}
+	}
+	if evictCounter != 1 {
+		t.Fatalf("bad evict count: %v", evictCounter)
+	}
+	if !cache.Contains(1) || !cache.Contains(3) {
+		t.Fatalf("bad keys after batch: %v", cache.Keys())
+	}
+
+	if _, err := cache.ApplyBatch([]Op[int, int]{{Kind: OpRemove, Key: 1}, {Kind: OpKind(42)}}); err == nil {
+		t.Fatalf("unknown op kind should be rejected")
+	}
+	if !cache.Contains(1) {
+		t.Fatalf("rejected batch should not be applied")
+	}
+}
+
+func TestCache_AddMap(t *testing.T) {
+	m := make(map[int]int)
+	for i := 0; i < 100; i++ {
+		m[i] = i * 2
+	}
+	c, err := NewFromMap(128, m)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if c.Len() != 100 {
+		t.Fatalf("bad len: %v", c.Len())
+	}
+	for i := 0; i < 100; i++ {
+		if v, ok := c.Peek(i); !ok || v != i*2 {
+			t.Fatalf("bad value for %v: %v, %v", i, v, ok)
+		}
+	}
+
+	var evictions []int
+	c2, err := NewWithEvict(64, func(k, v int) { evictions = append(evictions, k) })
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	c2.Add(-1, -1)
+	c2.Add(0, -1)
+	c2.Add(-2, -2)
+
+	// the map holds more entries than fit: only the old entries not in
+	// the map are evicted, and surplus entries of the map are dropped
+	if n := c2.AddMap(m); n != 2 || len(evictions) != 2 || evictions[0] != -1 || evictions[1] != -2 {
+		t.Fatalf("bad evictions: %v, %v", n, evictions)
+	}
+	if v, ok := c2.Peek(0); c2.Len() != 64 || !ok || v != 0 {
+		t.Fatalf("bad contents: %v", c2.Keys())
+	}
+	for _, k := range c2.Keys() {
+		if v, _ := c2.Peek(k); v != k*2 {
+			t.Fatalf("bad value for %v: %v", k, v)
+		}
+	}
+
+	c2.Freeze()
+	if n := c2.AddMap(map[int]int{1000: 1}); n != 0 || c2.Contains(1000) {
+		t.Fatalf("frozen cache modified")
+	}
+}
+
+func TestCache_AddMapPolicies(t *testing.T) {
+	m := make(map[int]int)
+	for i := 0; i < 20; i++ {
+		m[i] = i
+	}
+	for name, newCache := range map[string]func(int, func(int, int)) (*Cache[int, int], error){
+		"lru":  NewWithEvict[int, int],
+		"mru":  NewMRUWithEvict[int, int],
+		"fifo": NewFIFOWithEvict[int, int],
+	} {
+		var evictions []int
+		c, err := newCache(8, func(k, v int) { evictions = append(evictions, k) })
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		for i := 0; i < 8; i++ {
+			c.Add(i+100, i)
+		}
+		c.Add(3, 3)
+		evictions = nil
+		if n := c.AddMap(m); n != 7 || len(evictions) != 7 {
+			t.Fatalf("%s: bad evictions: %v, %v", name, n, evictions)
+		}
+		for _, k := range evictions {
+			if k < 100 {
+				t.Fatalf("%s: an entry of the map was evicted: %v", name, evictions)
+			}
+		}
+		if c.Len() != 8 || !c.Contains(3) {
+			t.Fatalf("%s: bad contents: %v", name, c.Keys())
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package dailzLRU
+
+import (
+	"hash/maphash"
+	"math/bits"
+	"sync/atomic"
+)
+
+const (
+	// bloomCountersPerKey is the number of counters a bloom filter keeps
+	// per entry of the cache, before rounding up to a power of two
+	bloomCountersPerKey = 10
+	// bloomHashes is the number of counters each key maps to, which makes
+	// about 1% of lookups of absent keys fall through at full occupancy
+	bloomHashes = 7
+)
+
+// bloomFilter is a counting bloom filter of the keys of a cache. Counters
+// are only changed under the cache's lock but read without it.
+type bloomFilter[K comparable] struct {
+	counters []atomic.Uint32
+	mask     uint64
+	capacity int
+	seed     maphash.Seed
+}
+
+// newBloomFilter returns an empty bloomFilter for up to capacity keys
+func newBloomFilter[K comparable](capacity int) *bloomFilter[K] {
+	n := uint64(1) << bits.Len64(uint64(capacity*bloomCountersPerKey-1))
+	return &bloomFilter[K]{
+		counters: make([]atomic.Uint32, n),
+		mask:     n - 1,
+		capacity: capacity,
+		seed:     maphash.MakeSeed(),
+	}
+}
+
+// indexes calls fn with each counter of key, derived from one hash by
+// double hashing
+func (f *bloomFilter[K]) indexes(key K, fn func(i uint64) bool) {
+	h := hashKey(f.seed, key)
+	h1, h2 := h, h>>32|1
+	for i := uint64(0); i < bloomHashes; i++ {
+		if !fn((h1 + i*h2) & f.mask) {
+			return
+		}
+	}
+}
+
+// add counts key in
+func (f *bloomFilter[K]) add(key K) {
+	f.indexes(key, func(i uint64) bool {
+		f.counters[i].Add(1)
+		return true
+	})
+}
+
+// remove counts key out; it must have been added. A counter already at 0
+// is left there rather than wrapped around, which would corrupt the filter
+// for every key sharing it.
+func (f *bloomFilter[K]) remove(key K) {
+	f.indexes(key, func(i uint64) bool {
+		if f.counters[i].Load() > 0 {
+			f.counters[i].Add(^uint32(0))
+		}
+		return true
+	})
+}
+
+// mayContain reports false if key is definitely not in the filter
+func (f *bloomFilter[K]) mayContain(key K) (ok bool) {
+	ok = true
+	f.indexes(key, func(i uint64) bool {
+		ok = f.counters[i].Load() > 0
+		return ok
+	})
+	return ok
+}
+
+// EnableBloomFilter makes the cache keep a counting bloom filter of its
+// keys, so Get, Peek and Contains answer most lookups of absent keys
+// without taking the lock. It suits workloads dominated by misses; hits pay
+// for the extra hashing. The filter takes 40 bytes or so per entry and is
+// rebuilt when the cache grows past the size it was built for. Keys are
+// hashed as NewSharded hashes them, consistently with ==, so a pointer key
+// is found whatever it points to; keys other than strings and integers are
+// hashed by reflection, which is slower.
+func (c *Cache[K, V]) EnableBloomFilter() {
+	c.lock.Lock()
+	c.rebuildBloomFilter(c.lru.Size())
+	c.lock.Unlock()
+}
+
+// rebuildBloomFilter replaces the bloom filter with one holding the current
+// keys and sized for at least capacity of them; the lock must be held
+func (c *Cache[K, V]) rebuildBloomFilter(capacity int) {
+	if n := c.lru.Len(); n > capacity {
+		capacity = n
+	}
+	f := newBloomFilter[K](capacity)
+	c.lru.Range(func(k K, v V) bool {
+		f.add(k)
+		return true
+	})
+	c.bloom.Store(f)
+}
+
+// mayContain reports false if key is definitely not in the cache, without
+// taking the lock
+func (c *Cache[K, V]) mayContain(key K) bool {
+	f := c.bloom.Load()
+	return f == nil || f.mayContain(key)
+}
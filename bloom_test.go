@@ -0,0 +1,134 @@
+package dailzLRU
+
+import (
+	"math"
+	"sync"
+	"testing"
+)
+
+func TestCache_BloomFilter(t *testing.T) {
+	c, err := New[int, int](128)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 64; i++ {
+		c.Add(i, i)
+	}
+	c.EnableBloomFilter()
+	for i := 64; i < 256; i++ {
+		c.Add(i, i)
+	}
+	// 0..127 were evicted, 128..255 are resident
+	for i := 128; i < 256; i++ {
+		if v, ok := c.Get(i); !ok || v != i {
+			t.Fatalf("bad value for %v: %v, %v", i, v, ok)
+		}
+		if !c.bloom.Load().mayContain(i) {
+			t.Fatalf("false negative for %v", i)
+		}
+	}
+	falsePositives := 0
+	for i := 0; i < 128; i++ {
+		if c.Contains(i) {
+			t.Fatalf("%v should have been evicted", i)
+		}
+		if c.bloom.Load().mayContain(i) {
+			falsePositives++
+		}
+	}
+	for i := 1000; i < 2000; i++ {
+		if c.bloom.Load().mayContain(i) {
+			falsePositives++
+		}
+	}
+	if falsePositives > 50 {
+		t.Fatalf("too many false positives: %v", falsePositives)
+	}
+
+	c.Remove(200)
+	if _, ok := c.Peek(200); ok {
+		t.Fatalf("200 should have been removed")
+	}
+	c.Resize(1024)
+	if f := c.bloom.Load(); f.capacity != 1024 || !f.mayContain(255) {
+		t.Fatalf("filter not rebuilt on growth")
+	}
+	c.Purge()
+	for i := 128; i < 256; i++ {
+		if c.bloom.Load().mayContain(i) {
+			t.Fatalf("%v still in the filter after purge", i)
+		}
+	}
+	c.Add(1, 1)
+	if v, ok := c.Get(1); !ok || v != 1 {
+		t.Fatalf("bad value: %v, %v", v, ok)
+	}
+}
+
+func TestCache_BloomFilterConcurrent(t *testing.T) {
+	c, err := New[int, int](64)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	c.EnableBloomFilter()
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		w := w
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				k := w*1000 + i
+				c.Add(k, k)
+				if v, ok := c.Get(k); ok && v != k {
+					t.Errorf("bad value for %v: %v", k, v)
+				}
+				c.Remove(k - 10)
+			}
+		}()
+	}
+	wg.Wait()
+	for _, k := range c.Keys() {
+		if !c.Contains(k) {
+			t.Fatalf("false negative for %v", k)
+		}
+	}
+}
+
+func TestCache_BloomFilterKeys(t *testing.T) {
+	p, q := new(int), new(int)
+	ptrs, err := New[*int, int](4)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	ptrs.EnableBloomFilter()
+	ptrs.Add(p, 1)
+	ptrs.Add(q, 2)
+	*p = 42
+	if !ptrs.Contains(p) {
+		t.Fatalf("pointer key lost after its pointee changed")
+	}
+	ptrs.Remove(p)
+	if !ptrs.Contains(q) {
+		t.Fatalf("removing a key hid another")
+	}
+
+	floats, err := New[float64, int](4)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	floats.EnableBloomFilter()
+	floats.Add(math.Copysign(0, -1), 1)
+	if v, ok := floats.Get(0.0); !ok || v != 1 {
+		t.Fatalf("0.0 not found under -0.0: %v, %v", v, ok)
+	}
+
+	// removing a key never added leaves the counters at 0
+	f := newBloomFilter[int](4)
+	f.remove(1)
+	for i := range f.counters {
+		if n := f.counters[i].Load(); n != 0 {
+			t.Fatalf("counter %d wrapped around to %d", i, n)
+		}
+	}
+}
@@ -0,0 +1,370 @@
+package dailzLRU
+
+import (
+	"errors"
+	"sync"
+)
+
+// clockProKind is the state of a CLOCK-Pro entry
+type clockProKind uint8
+
+const (
+	clockProCold clockProKind = iota // resident, evicted when not referenced
+	clockProHot                      // resident, demoted to cold when not referenced
+	clockProTest                     // non-resident, remembered to detect reuse
+)
+
+// clockProEntry is an entry on the clock of a ClockProCache
+type clockProEntry[K comparable, V any] struct {
+	key        K
+	value      V
+	kind       clockProKind
+	ref        bool
+	prev, next *clockProEntry[K, V]
+}
+
+// ClockProCache is a thread-safe fixed size cache using CLOCK-Pro, an
+// approximation of LIRS built on a clock. Entries are hot or cold: a new
+// entry starts cold and becomes hot only if it is referenced again before
+// the cold hand reaches it, so a scan of keys used once only ever displaces
+// cold entries. Evicted cold entries are remembered, without their value,
+// as test entries; adding one of them back makes it hot straight away and
+// grows the share of the cache kept for cold entries, while test entries
+// expiring unused shrink it. As with CLOCK, a hit only sets a reference bit.
+type ClockProCache[K comparable, V any] struct {
+	size        int
+	coldTarget  int // number of resident cold entries aimed for
+	items       map[K]*clockProEntry[K, V]
+	handHot     *clockProEntry[K, V]
+	handCold    *clockProEntry[K, V]
+	handTest    *clockProEntry[K, V]
+	hot         int
+	cold        int
+	test        int
+	evictedKeys []K
+	evictedVals []V
+	onEvictedCB func(k K, v V)
+	lock        sync.Mutex
+}
+
+// NewClockPro constructs a ClockProCache of the given size. onEvicted, if
+// not nil, is called outside of the cache's lock for entries that are
+// evicted or removed.
+func NewClockPro[K comparable, V any](size int, onEvicted func(key K, value V)) (*ClockProCache[K, V], error) {
+	if size <= 0 {
+		return nil, errors.New("must provide a positive size")
+	}
+	return &ClockProCache[K, V]{
+		size:        size,
+		coldTarget:  initialColdTarget(size),
+		items:       make(map[K]*clockProEntry[K, V]),
+		onEvictedCB: onEvicted,
+	}, nil
+}
+
+// initialColdTarget returns the cold target a cache of the given size
+// starts from: 1% of its entries, leaving the rest to entries proving their
+// reuse, from which the target adapts to the workload
+func initialColdTarget(size int) int {
+	return max(1, size/100)
+}
+
+// link inserts e at the head of the clock, just behind the hot hand
+func (c *ClockProCache[K, V]) link(e *clockProEntry[K, V]) {
+	if c.handHot == nil {
+		e.prev, e.next = e, e
+		c.handHot, c.handCold, c.handTest = e, e, e
+		return
+	}
+	e.next = c.handHot
+	e.prev = c.handHot.prev
+	e.prev.next = e
+	c.handHot.prev = e
+}
+
+// unlink takes e off the clock, moving any hand pointing at it forward
+func (c *ClockProCache[K, V]) unlink(e *clockProEntry[K, V]) {
+	if e.next == e {
+		c.handHot, c.handCold, c.handTest = nil, nil, nil
+	} else {
+		if c.handHot == e {
+			c.handHot = e.next
+		}
+		if c.handCold == e {
+			c.handCold = e.next
+		}
+		if c.handTest == e {
+			c.handTest = e.next
+		}
+		e.prev.next = e.next
+		e.next.prev = e.prev
+	}
+	e.prev, e.next = nil, nil
+}
+
+// buffer queues the eviction callback of an entry losing its value
+func (c *ClockProCache[K, V]) buffer(k K, v V) {
+	if c.onEvictedCB != nil {
+		c.evictedKeys = append(c.evictedKeys, k)
+		c.evictedVals = append(c.evictedVals, v)
+	}
+}
+
+// makeRoom runs the cold hand until at most size-n entries are resident
+func (c *ClockProCache[K, V]) makeRoom(n int) {
+	for c.hot+c.cold > c.size-n {
+		c.runHandCold()
+	}
+}
+
+// runHandCold promotes the cold entry under the cold hand if it was
+// referenced and evicts it otherwise, then lets the hot hand demote hot
+// entries while there are too many of them
+func (c *ClockProCache[K, V]) runHandCold() {
+	e := c.handCold
+	c.handCold = e.next
+	if e.kind == clockProCold {
+		if e.ref {
+			e.kind = clockProHot
+			e.ref = false
+			c.cold--
+			c.hot++
+		} else {
+			c.buffer(e.key, e.value)
+			var zero V
+			e.value = zero
+			e.kind = clockProTest
+			c.cold--
+			c.test++
+			for c.test > c.size {
+				c.runHandTest()
+			}
+		}
+	}
+	for c.hot > c.size-c.coldTarget {
+		c.runHandHot()
+	}
+}
+
+// runHandHot demotes the hot entry under the hot hand unless it was
+// referenced, and ends the test period of a test entry it passes
+func (c *ClockProCache[K, V]) runHandHot() {
+	e := c.handHot
+	c.handHot = e.next
+	switch e.kind {
+	case clockProHot:
+		if e.ref {
+			e.ref = false
+		} else {
+			e.kind = clockProCold
+			c.hot--
+			c.cold++
+		}
+	case clockProTest:
+		c.expire(e)
+	}
+}
+
+// runHandTest moves the test hand to the next test entry and ends its test
+// period
+func (c *ClockProCache[K, V]) runHandTest() {
+	for c.handTest.kind != clockProTest {
+		c.handTest = c.handTest.next
+	}
+	c.expire(c.handTest)
+}
+
+// expire forgets test entry e, which was not added back during its test
+// period, so fewer cold entries are needed
+func (c *ClockProCache[K, V]) expire(e *clockProEntry[K, V]) {
+	c.unlink(e)
+	delete(c.items, e.key)
+	c.test--
+	if c.coldTarget > 1 {
+		c.coldTarget--
+	}
+}
+
+// Add adds a value to the cache. Returns true if an eviction occurred.
+func (c *ClockProCache[K, V]) Add(key K, value V) (evicted bool) {
+	c.lock.Lock()
+	e, ok := c.items[key]
+	if ok && e.kind != clockProTest {
+		e.value = value
+		e.ref = true
+		c.lock.Unlock()
+		return false
+	}
+	n := c.hot + c.cold
+	if ok {
+		// reused during its test period: the cold share was too small
+		c.unlink(e)
+		c.test--
+		if c.coldTarget < c.size {
+			c.coldTarget++
+		}
+		c.makeRoom(1)
+		e.kind = clockProHot
+	} else {
+		c.makeRoom(1)
+		e = &clockProEntry[K, V]{key: key, kind: clockProCold}
+		c.items[key] = e
+	}
+	evicted = c.hot+c.cold < n
+	e.value = value
+	e.ref = false
+	c.link(e)
+	if e.kind == clockProHot {
+		c.hot++
+	} else {
+		c.cold++
+	}
+	ks, vs := c.takeEvicted()
+	c.lock.Unlock()
+	c.notify(ks, vs)
+	return evicted
+}
+
+// takeEvicted hands back the buffered evictions and resets the buffers
+func (c *ClockProCache[K, V]) takeEvicted() (ks []K, vs []V) {
+	ks, vs = c.evictedKeys, c.evictedVals
+	c.evictedKeys, c.evictedVals = nil, nil
+	return ks, vs
+}
+
+// notify invokes the eviction callback for buffered evictions
+func (c *ClockProCache[K, V]) notify(ks []K, vs []V) {
+	for i := 0; i < len(ks); i++ {
+		c.onEvictedCB(ks[i], vs[i])
+	}
+}
+
+// Get looks up a key's value from the cache, marking it referenced.
+func (c *ClockProCache[K, V]) Get(key K) (value V, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	e, ok := c.items[key]
+	if !ok || e.kind == clockProTest {
+		return value, false
+	}
+	e.ref = true
+	return e.value, true
+}
+
+// Peek returns the key value (or undefined if not found) without marking
+// it referenced.
+func (c *ClockProCache[K, V]) Peek(key K) (value V, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if e, ok := c.items[key]; ok && e.kind != clockProTest {
+		return e.value, true
+	}
+	return value, false
+}
+
+// Contains checks if a key is in the cache, without marking it referenced.
+func (c *ClockProCache[K, V]) Contains(key K) bool {
+	_, ok := c.Peek(key)
+	return ok
+}
+
+// Remove removes the provided key from the cache, returning true if the key
+// was contained. The key is forgotten entirely, so adding it back does not
+// count as reuse.
+func (c *ClockProCache[K, V]) Remove(key K) (present bool) {
+	c.lock.Lock()
+	e, ok := c.items[key]
+	if ok {
+		c.unlink(e)
+		delete(c.items, key)
+		switch e.kind {
+		case clockProHot:
+			c.hot--
+			present = true
+		case clockProCold:
+			c.cold--
+			present = true
+		case clockProTest:
+			c.test--
+		}
+		if present {
+			c.buffer(e.key, e.value)
+		}
+	}
+	ks, vs := c.takeEvicted()
+	c.lock.Unlock()
+	c.notify(ks, vs)
+	return present
+}
+
+// Keys returns a slice of the keys in the cache, in clock order starting
+// at the hot hand.
+func (c *ClockProCache[K, V]) Keys() []K {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	keys := make([]K, 0, c.hot+c.cold)
+	if c.handHot == nil {
+		return keys
+	}
+	e := c.handHot
+	for {
+		if e.kind != clockProTest {
+			keys = append(keys, e.key)
+		}
+		if e = e.next; e == c.handHot {
+			return keys
+		}
+	}
+}
+
+// Len returns the number of items in the cache, not counting the keys
+// remembered for their test period.
+func (c *ClockProCache[K, V]) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.hot + c.cold
+}
+
+// Resize changes the cache size, returning the number of entries evicted.
+func (c *ClockProCache[K, V]) Resize(size int) (evicted int) {
+	if size <= 0 {
+		return 0
+	}
+	c.lock.Lock()
+	n := c.hot + c.cold
+	c.size = size
+	if c.coldTarget > size {
+		c.coldTarget = size
+	}
+	c.makeRoom(0)
+	for c.test > size {
+		c.runHandTest()
+	}
+	evicted = n - c.hot - c.cold
+	ks, vs := c.takeEvicted()
+	c.lock.Unlock()
+	c.notify(ks, vs)
+	return evicted
+}
+
+// Purge is used to completely clear the cache.
+func (c *ClockProCache[K, V]) Purge() {
+	c.lock.Lock()
+	if e := c.handHot; e != nil {
+		for {
+			if e.kind != clockProTest {
+				c.buffer(e.key, e.value)
+			}
+			if e = e.next; e == c.handHot {
+				break
+			}
+		}
+	}
+	c.items = make(map[K]*clockProEntry[K, V])
+	c.handHot, c.handCold, c.handTest = nil, nil, nil
+	c.hot, c.cold, c.test = 0, 0, 0
+	c.coldTarget = initialColdTarget(c.size)
+	ks, vs := c.takeEvicted()
+	c.lock.Unlock()
+	c.notify(ks, vs)
+}
@@ -0,0 +1,154 @@
+package dailzLRU
+
+import "testing"
+
+// checkClockPro verifies the counts of c against its clock
+func checkClockPro[K comparable, V any](t *testing.T, c *ClockProCache[K, V]) {
+	t.Helper()
+	var counts [3]int
+	if e := c.handHot; e != nil {
+		for {
+			if e.next.prev != e {
+				t.Fatalf("broken clock at %v", e.key)
+			}
+			counts[e.kind]++
+			if e = e.next; e == c.handHot {
+				break
+			}
+		}
+	}
+	if counts[clockProCold] != c.cold || counts[clockProHot] != c.hot || counts[clockProTest] != c.test {
+		t.Fatalf("bad counts: clock %v, cache %v %v %v", counts, c.cold, c.hot, c.test)
+	}
+	if len(c.items) != c.hot+c.cold+c.test || c.hot+c.cold > c.size || c.test > c.size {
+		t.Fatalf("bad sizes: %d items, %d hot, %d cold, %d test", len(c.items), c.hot, c.cold, c.test)
+	}
+}
+
+func TestClockPro(t *testing.T) {
+	evictCounter := 0
+	l, err := NewClockPro(128, func(k, v int) {
+		if k != v {
+			t.Fatalf("Evict values not equal (%v!=%v)", k, v)
+		}
+		evictCounter++
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 256; i++ {
+		if l.Add(i, i) != (i >= 128) {
+			t.Fatalf("bad eviction for %v", i)
+		}
+	}
+	checkClockPro(t, l)
+	if l.Len() != 128 || len(l.Keys()) != 128 || evictCounter != 128 {
+		t.Fatalf("bad len: %v, %v, %v", l.Len(), len(l.Keys()), evictCounter)
+	}
+	for i := 128; i < 256; i++ {
+		if v, ok := l.Get(i); !ok || v != i {
+			t.Fatalf("bad value for %v: %v, %v", i, v, ok)
+		}
+	}
+	if _, ok := l.Get(0); ok {
+		t.Fatalf("evicted key should miss")
+	}
+
+	if !l.Remove(200) || l.Contains(200) || l.Remove(200) {
+		t.Fatalf("bad remove")
+	}
+	if evicted := l.Resize(64); evicted != 63 || l.Len() != 64 {
+		t.Fatalf("bad resize: %v, %v", evicted, l.Len())
+	}
+	checkClockPro(t, l)
+	l.Purge()
+	if l.Len() != 0 || len(l.items) != 0 || evictCounter != 256 {
+		t.Fatalf("bad purge: %v, %v, %v", l.Len(), len(l.items), evictCounter)
+	}
+}
+
+// Test that keys added back during their test period become hot and
+// survive a scan
+func TestClockPro_ScanResistance(t *testing.T) {
+	l, err := NewClockPro[int, int](100, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for round := 0; round < 4; round++ {
+		for i := 0; i < 50; i++ {
+			if _, ok := l.Get(i); !ok {
+				l.Add(i, i)
+			}
+		}
+		for i := 0; i < 100; i++ {
+			l.Add(1000*(round+1)+i, i)
+		}
+	}
+	checkClockPro(t, l)
+	if l.hot == 0 {
+		t.Fatalf("no hot entries")
+	}
+	for i := 0; i < 1000; i++ {
+		l.Add(1<<20+i, i)
+	}
+	kept := 0
+	for i := 0; i < 50; i++ {
+		if l.Contains(i) {
+			kept++
+		}
+	}
+	if kept < 25 {
+		t.Fatalf("scan evicted the working set: %d of 50 kept", kept)
+	}
+}
+
+func TestClockPro_RandomOps(t *testing.T) {
+	l, err := NewClockPro[int64, int64](64, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 20000; i++ {
+		k := getRand(t) % 256
+		switch getRand(t) % 8 {
+		case 0:
+			l.Remove(k)
+		case 1:
+			l.Resize(32 + int(getRand(t)%64))
+		case 2, 3, 4:
+			l.Get(k)
+		default:
+			l.Add(k, k)
+		}
+		if i%1000 == 0 {
+			checkClockPro(t, l)
+		}
+	}
+	checkClockPro(t, l)
+}
+
+func TestClockPro_ColdTarget(t *testing.T) {
+	l, err := NewClockPro[int, int](1000, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if l.coldTarget != 10 {
+		t.Fatalf("bad initial cold target: %v", l.coldTarget)
+	}
+	// keys coming back right after their eviction grow the cold share
+	for round := 0; round < 3; round++ {
+		for i := 0; i < 1100; i++ {
+			l.Add(i, i)
+		}
+	}
+	checkClockPro(t, l)
+	if l.coldTarget <= 10 {
+		t.Fatalf("cold target did not adapt: %v", l.coldTarget)
+	}
+	l.Purge()
+	if l.coldTarget != 10 {
+		t.Fatalf("bad cold target after purge: %v", l.coldTarget)
+	}
+	if l, _ := NewClockPro[int, int](10, nil); l.coldTarget != 1 {
+		t.Fatalf("bad initial cold target: %v", l.coldTarget)
+	}
+}
@@ -0,0 +1,194 @@
+// Command dailzlru inspects and converts cache snapshots and replays access
+// traces through the simulator.
+//
+// Usage:
+//
+//	dailzlru inspect [-format gob|json] [-key type] [-value type] [-keys] snapshot
+//	dailzlru convert [-from gob|json] [-to gob|json] [-key type] [-value type] in out
+//	dailzlru replay [-policies lru,2q] [-sizes 100,1000] trace...
+//
+// Snapshots are typed, so the key and value types of the cache that wrote
+// them must be given. Key types are string, int, int64 and uint64; value
+// types are string, bytes, int, int64, float64 and json, the latter only
+// for JSON snapshots. A trace has one key per line; "-" reads it from stdin.
+// Besides the cache policies, replay runs opt, Belady's offline optimal
+// policy, as a ceiling for the others.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/dailz1/dailzLRU"
+	"github.com/dailz1/dailzLRU/sim"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "dailzlru:", err)
+		os.Exit(1)
+	}
+}
+
+// run executes the command line args
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("usage: dailzlru inspect|convert|replay [flags] args...")
+	}
+	switch args[0] {
+	case "inspect":
+		return inspectCmd(args[1:], stdout)
+	case "convert":
+		return convertCmd(args[1:])
+	case "replay":
+		return replayCmd(args[1:], stdin, stdout)
+	}
+	return fmt.Errorf("unknown command %q", args[0])
+}
+
+func inspectCmd(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	format := fs.String("format", "gob", "snapshot format: gob or json")
+	keyType := fs.String("key", "string", "key type")
+	valueType := fs.String("value", "bytes", "value type")
+	listKeys := fs.Bool("keys", false, "list every key with the size of its value")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("inspect needs exactly one snapshot file")
+	}
+	f, err := parseFormat(*format)
+	if err != nil {
+		return err
+	}
+	t, err := newTool(*keyType, *valueType)
+	if err != nil {
+		return err
+	}
+	in, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	return t.inspect(in, f, stdout, *listKeys)
+}
+
+func convertCmd(args []string) error {
+	fs := flag.NewFlagSet("convert", flag.ContinueOnError)
+	from := fs.String("from", "gob", "input snapshot format: gob or json")
+	to := fs.String("to", "json", "output snapshot format: gob or json")
+	keyType := fs.String("key", "string", "key type")
+	valueType := fs.String("value", "bytes", "value type")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return errors.New("convert needs an input and an output file")
+	}
+	fromFormat, err := parseFormat(*from)
+	if err != nil {
+		return err
+	}
+	toFormat, err := parseFormat(*to)
+	if err != nil {
+		return err
+	}
+	t, err := newTool(*keyType, *valueType)
+	if err != nil {
+		return err
+	}
+	in, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := dailzLRU.CreateAtomicFile(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	if err := t.convert(in, fromFormat, out, toFormat); err != nil {
+		out.Abort()
+		return err
+	}
+	return out.Close()
+}
+
+func replayCmd(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	policies := fs.String("policies", strings.Join(sim.Policies(), ","), "comma separated policies")
+	sizes := fs.String("sizes", "100,1000,10000", "comma separated cache sizes")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("replay needs at least one trace file")
+	}
+	var cacheSizes []int
+	for _, s := range strings.Split(*sizes, ",") {
+		size, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil {
+			return fmt.Errorf("bad size %q", s)
+		}
+		cacheSizes = append(cacheSizes, size)
+	}
+	names := strings.Split(*policies, ",")
+
+	for _, name := range fs.Args() {
+		trace, err := readTrace(name, stdin)
+		if err != nil {
+			return err
+		}
+		results, err := sim.Table(names, cacheSizes, trace)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "%s: %d accesses\n", name, len(trace))
+		w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprint(w, "policy\t")
+		for _, size := range cacheSizes {
+			fmt.Fprintf(w, "%d\t", size)
+		}
+		fmt.Fprintln(w)
+		for i, name := range names {
+			fmt.Fprintf(w, "%s\t", name)
+			for _, r := range results[i] {
+				fmt.Fprintf(w, "%.2f%%\t", 100*r.HitRatio())
+			}
+			fmt.Fprintln(w)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readTrace reads the trace file name, or stdin for "-"
+func readTrace(name string, stdin io.Reader) ([]uint64, error) {
+	if name == "-" {
+		return sim.ReadTrace(stdin)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return sim.ReadTrace(f)
+}
+
+// parseFormat maps a format flag to a snapshot format
+func parseFormat(s string) (dailzLRU.SnapshotFormat, error) {
+	switch s {
+	case "gob":
+		return dailzLRU.SnapshotGob, nil
+	case "json":
+		return dailzLRU.SnapshotJSON, nil
+	}
+	return 0, fmt.Errorf("unknown snapshot format %q", s)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"github.com/dailz1/dailzLRU"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInspectAndConvert(t *testing.T) {
+	dir := t.TempDir()
+	gobPath := filepath.Join(dir, "snapshot.gob")
+	jsonPath := filepath.Join(dir, "snapshot.json")
+
+	cache, _ := dailzLRU.New[string, string](8)
+	cache.Add("a", "xx")
+	cache.Add("b", "yyy")
+	f, err := os.Create(gobPath)
+	if err != nil {
+		t.Fatalf("create error: %v", err)
+	}
+	if err := cache.WriteSnapshot(f); err != nil {
+		t.Fatalf("WriteSnapshot error: %v", err)
+	}
+	f.Close()
+
+	if err := run([]string{"convert", "-value", "string", gobPath, jsonPath}, nil, nil); err != nil {
+		t.Fatalf("convert error: %v", err)
+	}
+	var out bytes.Buffer
+	if err := run([]string{"inspect", "-format", "json", "-value", "json", "-keys", jsonPath}, nil, &out); err != nil {
+		t.Fatalf("inspect error: %v", err)
+	}
+	// JSON values are measured with their quotes
+	for _, want := range []string{"a    4", "b    5", "entries: 2", "value bytes: 9"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("inspect output misses %q:\n%s", want, out.String())
+		}
+	}
+
+	if err := run([]string{"inspect", "-key", "float", gobPath}, nil, &out); err == nil {
+		t.Fatalf("unknown key types should be rejected")
+	}
+}
+
+func TestReplay(t *testing.T) {
+	var out bytes.Buffer
+	trace := strings.NewReader("1\n2\n1\n2\n")
+	if err := run([]string{"replay", "-policies", "lru", "-sizes", "1,2", "-"}, trace, &out); err != nil {
+		t.Fatalf("replay error: %v", err)
+	}
+	if !strings.Contains(out.String(), "lru  0.00%  50.00%") {
+		t.Fatalf("bad replay output:\n%s", out.String())
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/dailz1/dailzLRU"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// tool works on snapshots of one key and value type
+type tool interface {
+	inspect(r io.Reader, format dailzLRU.SnapshotFormat, w io.Writer, listKeys bool) error
+	convert(r io.Reader, from dailzLRU.SnapshotFormat, w io.Writer, to dailzLRU.SnapshotFormat) error
+}
+
+// newTool returns the tool for the named key and value types
+func newTool(keyType, valueType string) (tool, error) {
+	switch keyType {
+	case "string":
+		return newTypedTool[string](valueType)
+	case "int":
+		return newTypedTool[int](valueType)
+	case "int64":
+		return newTypedTool[int64](valueType)
+	case "uint64":
+		return newTypedTool[uint64](valueType)
+	}
+	return nil, fmt.Errorf("unknown key type %q", keyType)
+}
+
+func newTypedTool[K comparable](valueType string) (tool, error) {
+	switch valueType {
+	case "string":
+		return typedTool[K, string]{}, nil
+	case "bytes":
+		return typedTool[K, []byte]{}, nil
+	case "int":
+		return typedTool[K, int]{}, nil
+	case "int64":
+		return typedTool[K, int64]{}, nil
+	case "float64":
+		return typedTool[K, float64]{}, nil
+	case "json":
+		return typedTool[K, json.RawMessage]{}, nil
+	}
+	return nil, fmt.Errorf("unknown value type %q", valueType)
+}
+
+type typedTool[K comparable, V any] struct{}
+
+func (typedTool[K, V]) inspect(r io.Reader, format dailzLRU.SnapshotFormat, w io.Writer, listKeys bool) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if listKeys {
+		fmt.Fprintln(tw, "KEY\tSIZE")
+	}
+	var total int
+	info, n, err := dailzLRU.ReadSnapshotEntries(r, format, func(key K, value V) error {
+		size := valueSize(value)
+		total += size
+		if listKeys {
+			fmt.Fprintf(tw, "%v\t%d\n", key, size)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("after %d entries: %w", n, err)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	created := "unknown"
+	if !info.Created.IsZero() {
+		created = fmt.Sprintf("%s (%s ago)", info.Created.Format(time.RFC3339), time.Since(info.Created).Round(time.Second))
+	}
+	fmt.Fprintf(w, "version: %d\ncreated: %s\nentries: %d\nvalue bytes: %d\n", info.Version, created, n, total)
+	return nil
+}
+
+func (typedTool[K, V]) convert(r io.Reader, from dailzLRU.SnapshotFormat, w io.Writer, to dailzLRU.SnapshotFormat) error {
+	var entries []dailzLRU.SnapshotEntry[K, V]
+	_, _, err := dailzLRU.ReadSnapshotEntries(r, from, func(key K, value V) error {
+		entries = append(entries, dailzLRU.SnapshotEntry[K, V]{Key: key, Value: value})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return dailzLRU.WriteSnapshotEntries(w, to, entries)
+}
+
+// valueSize returns the size of a value: the length of strings and byte
+// slices, and the length of the JSON encoding of anything else
+func valueSize(v any) int {
+	switch v := v.(type) {
+	case string:
+		return len(v)
+	case []byte:
+		return len(v)
+	case json.RawMessage:
+		return len(v)
+	}
+	data, _ := json.Marshal(v)
+	return len(data)
+}
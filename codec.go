@@ -0,0 +1,92 @@
+package dailzLRU
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// Codec converts values to and from their serialized form.
+type Codec[V any] interface {
+	Marshal(v V) ([]byte, error)
+	Unmarshal(data []byte) (V, error)
+}
+
+// BufferedCodec is a Codec that can work in a scratch buffer. A CodecCache
+// lends it buffers from a pool, so values are encoded without allocating a
+// growing buffer each time and stored without its spare capacity, and
+// codecs that must expand values before decoding them, such as ones
+// decompressing them, need not allocate a buffer on every read.
+type BufferedCodec[V any] interface {
+	Codec[V]
+	// MarshalTo appends the serialized form of v to buf.
+	MarshalTo(buf *bytes.Buffer, v V) error
+	// UnmarshalFrom decodes data, using buf as scratch space. buf is empty
+	// and must not be retained.
+	UnmarshalFrom(buf *bytes.Buffer, data []byte) (V, error)
+}
+
+// CodecFuncs adapts a pair of functions to the Codec interface.
+type CodecFuncs[V any] struct {
+	MarshalFunc   func(v V) ([]byte, error)
+	UnmarshalFunc func(data []byte) (V, error)
+}
+
+func (c CodecFuncs[V]) Marshal(v V) ([]byte, error) {
+	return c.MarshalFunc(v)
+}
+
+func (c CodecFuncs[V]) Unmarshal(data []byte) (V, error) {
+	return c.UnmarshalFunc(data)
+}
+
+// JSONCodec serializes values with encoding/json.
+type JSONCodec[V any] struct{}
+
+func (JSONCodec[V]) Marshal(v V) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec[V]) Unmarshal(data []byte) (v V, err error) {
+	err = json.Unmarshal(data, &v)
+	return
+}
+
+func (JSONCodec[V]) MarshalTo(buf *bytes.Buffer, v V) error {
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		return err
+	}
+	// drop the newline the encoder ends values with, which Marshal omits
+	buf.Truncate(buf.Len() - 1)
+	return nil
+}
+
+func (c JSONCodec[V]) UnmarshalFrom(buf *bytes.Buffer, data []byte) (V, error) {
+	return c.Unmarshal(data)
+}
+
+// GobCodec serializes values with encoding/gob. Every value carries its own
+// type information, so it is larger than a stream encoding but can be
+// decoded on its own.
+type GobCodec[V any] struct{}
+
+func (c GobCodec[V]) Marshal(v V) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := c.MarshalTo(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobCodec[V]) Unmarshal(data []byte) (v V, err error) {
+	err = gob.NewDecoder(bytes.NewReader(data)).Decode(&v)
+	return
+}
+
+func (GobCodec[V]) MarshalTo(buf *bytes.Buffer, v V) error {
+	return gob.NewEncoder(buf).Encode(&v)
+}
+
+func (c GobCodec[V]) UnmarshalFrom(buf *bytes.Buffer, data []byte) (V, error) {
+	return c.Unmarshal(data)
+}
@@ -0,0 +1,146 @@
+package dailzLRU
+
+import (
+	"bytes"
+	"sync"
+)
+
+// maxPooledCodecBuffer is the capacity beyond which a codec buffer is not
+// returned to the pool, so one huge value does not pin its buffer
+const maxPooledCodecBuffer = 64 << 10
+
+// codecBuffers pools the scratch buffers lent to BufferedCodecs
+var codecBuffers = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// getCodecBuffer returns an empty buffer from the pool
+func getCodecBuffer() *bytes.Buffer {
+	buf := codecBuffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+// putCodecBuffer returns buf to the pool, unless it grew too large
+func putCodecBuffer(buf *bytes.Buffer) {
+	if buf.Cap() <= maxPooledCodecBuffer {
+		codecBuffers.Put(buf)
+	}
+}
+
+// CodecCache is a thread-safe fixed size LRU cache that keeps its values
+// serialized. Values are marshaled on Add and unmarshaled on every read, so
+// the memory held by the cache is the size of the encoded values rather than
+// of the decoded Go objects. A BufferedCodec, such as JSONCodec or GobCodec,
+// works in buffers drawn from a pool. Since a Cache hands out values as it
+// stores them, a codec is not an option of Cache but makes a cache of its
+// own type, whose reads may fail to decode.
+type CodecCache[K comparable, V any] struct {
+	cache *Cache[K, []byte]
+	codec Codec[V]
+}
+
+// NewWithCodec constructs a CodecCache of the given size that serializes
+// values with codec.
+func NewWithCodec[K comparable, V any](size int, codec Codec[V]) (*CodecCache[K, V], error) {
+	return NewWithCodecEvict[K, V](size, codec, nil)
+}
+
+// NewWithCodecEvict constructs a CodecCache with an eviction callback. The
+// callback receives the serialized value, which it may decode with the
+// codec, and must not modify it.
+func NewWithCodecEvict[K comparable, V any](size int, codec Codec[V], onEvicted func(key K, data []byte)) (*CodecCache[K, V], error) {
+	cache, err := NewWithEvict[K, []byte](size, onEvicted)
+	if err != nil {
+		return nil, err
+	}
+	return &CodecCache[K, V]{cache: cache, codec: codec}, nil
+}
+
+// marshal serializes value, into a pooled buffer if the codec can use one
+func (c *CodecCache[K, V]) marshal(value V) ([]byte, error) {
+	bc, ok := c.codec.(BufferedCodec[V])
+	if !ok {
+		return c.codec.Marshal(value)
+	}
+	buf := getCodecBuffer()
+	defer putCodecBuffer(buf)
+	if err := bc.MarshalTo(buf, value); err != nil {
+		return nil, err
+	}
+	data := make([]byte, buf.Len())
+	copy(data, buf.Bytes())
+	return data, nil
+}
+
+// unmarshal decodes data, in a pooled buffer if the codec can use one
+func (c *CodecCache[K, V]) unmarshal(data []byte) (V, error) {
+	bc, ok := c.codec.(BufferedCodec[V])
+	if !ok {
+		return c.codec.Unmarshal(data)
+	}
+	buf := getCodecBuffer()
+	defer putCodecBuffer(buf)
+	return bc.UnmarshalFrom(buf, data)
+}
+
+// Add marshals value and adds it to the cache. Returns true if an eviction
+// occurred. Nothing is added if marshaling fails.
+func (c *CodecCache[K, V]) Add(key K, value V) (evicted bool, err error) {
+	data, err := c.marshal(value)
+	if err != nil {
+		return false, err
+	}
+	return c.cache.Add(key, data), nil
+}
+
+// Get looks up a key's value from the cache and unmarshals it.
+func (c *CodecCache[K, V]) Get(key K) (value V, ok bool, err error) {
+	data, ok := c.cache.Get(key)
+	if !ok {
+		return value, false, nil
+	}
+	value, err = c.unmarshal(data)
+	return value, true, err
+}
+
+// Peek unmarshals the key's value without updating the "recently used"-ness
+// of the key.
+func (c *CodecCache[K, V]) Peek(key K) (value V, ok bool, err error) {
+	data, ok := c.cache.Peek(key)
+	if !ok {
+		return value, false, nil
+	}
+	value, err = c.unmarshal(data)
+	return value, true, err
+}
+
+// GetBytes returns the serialized form of a key's value. The returned slice
+// is shared with the cache and must not be modified.
+func (c *CodecCache[K, V]) GetBytes(key K) (data []byte, ok bool) {
+	return c.cache.Get(key)
+}
+
+// Contains checks if a key is in the cache, without updating its recent-ness.
+func (c *CodecCache[K, V]) Contains(key K) bool {
+	return c.cache.Contains(key)
+}
+
+// Remove removes the provided key from the cache, returning true if the key
+// was contained.
+func (c *CodecCache[K, V]) Remove(key K) (present bool) {
+	return c.cache.Remove(key)
+}
+
+// Keys returns a slice of the keys in the cache, from oldest to newest.
+func (c *CodecCache[K, V]) Keys() []K {
+	return c.cache.Keys()
+}
+
+// Len returns the number of items in the cache.
+func (c *CodecCache[K, V]) Len() int {
+	return c.cache.Len()
+}
+
+// Purge is used to completely clear the cache.
+func (c *CodecCache[K, V]) Purge() {
+	c.cache.Purge()
+}
@@ -0,0 +1,153 @@
+package dailzLRU
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"testing"
+)
+
+type codecTestValue struct {
+	Name  string
+	Count int
+}
+
+func TestCodecCache(t *testing.T) {
+	for name, codec := range map[string]Codec[codecTestValue]{
+		"json": JSONCodec[codecTestValue]{},
+		"gob":  GobCodec[codecTestValue]{},
+	} {
+		cache, err := NewWithCodec[string](2, codec)
+		if err != nil {
+			t.Fatalf("%s: CodecCache error: %v", name, err)
+		}
+		for i, k := range []string{"a", "b", "c"} {
+			if _, err := cache.Add(k, codecTestValue{Name: k, Count: i}); err != nil {
+				t.Fatalf("%s: Add error: %v", name, err)
+			}
+		}
+		if cache.Contains("a") || cache.Len() != 2 {
+			t.Fatalf("%s: bad keys: %v", name, cache.Keys())
+		}
+		v, ok, err := cache.Get("c")
+		if !ok || err != nil || v.Name != "c" || v.Count != 2 {
+			t.Fatalf("%s: bad value: %+v, ok = %v, err = %v", name, v, ok, err)
+		}
+		if _, ok, err := cache.Peek("a"); ok || err != nil {
+			t.Fatalf("%s: evicted key should be missing", name)
+		}
+	}
+
+	failing := CodecFuncs[int]{
+		MarshalFunc:   func(v int) ([]byte, error) { return nil, errors.New("boom") },
+		UnmarshalFunc: func(data []byte) (int, error) { return 0, nil },
+	}
+	cache, err := NewWithCodec[string, int](2, failing)
+	if err != nil {
+		t.Fatalf("CodecCache error: %v", err)
+	}
+	if _, err := cache.Add("a", 1); err == nil || cache.Contains("a") {
+		t.Fatalf("a marshal error should prevent the add")
+	}
+}
+
+func TestCodecCache_Evict(t *testing.T) {
+	codec := GobCodec[codecTestValue]{}
+	var evicted []codecTestValue
+	cache, err := NewWithCodecEvict[string](1, codec, func(k string, data []byte) {
+		v, err := codec.Unmarshal(data)
+		if err != nil {
+			t.Fatalf("Unmarshal error: %v", err)
+		}
+		evicted = append(evicted, v)
+	})
+	if err != nil {
+		t.Fatalf("CodecCache error: %v", err)
+	}
+	cache.Add("a", codecTestValue{Name: "a", Count: 1})
+	cache.Add("b", codecTestValue{Name: "b", Count: 2})
+	if len(evicted) != 1 || evicted[0].Name != "a" || evicted[0].Count != 1 {
+		t.Fatalf("bad evictions: %+v", evicted)
+	}
+	// the value is stored without the spare capacity of the pooled buffer
+	if data, _ := cache.GetBytes("b"); cap(data) != len(data) {
+		t.Fatalf("bad stored value: len %d, cap %d", len(data), cap(data))
+	}
+}
+
+// gzipCodec is a BufferedCodec compressing JSON values, which it expands in
+// the buffer it is lent
+type gzipCodec struct {
+	unmarshals int
+}
+
+func (c *gzipCodec) Marshal(v string) ([]byte, error) {
+	var buf bytes.Buffer
+	err := c.MarshalTo(&buf, v)
+	return buf.Bytes(), err
+}
+
+func (c *gzipCodec) Unmarshal(data []byte) (string, error) {
+	return c.UnmarshalFrom(new(bytes.Buffer), data)
+}
+
+func (c *gzipCodec) MarshalTo(buf *bytes.Buffer, v string) error {
+	data, err := JSONCodec[string]{}.Marshal(v)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(buf)
+	zw.Write(data)
+	return zw.Close()
+}
+
+func (c *gzipCodec) UnmarshalFrom(buf *bytes.Buffer, data []byte) (string, error) {
+	c.unmarshals++
+	if buf.Len() != 0 {
+		return "", errors.New("buffer not empty")
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(buf, zr); err != nil {
+		return "", err
+	}
+	return JSONCodec[string]{}.Unmarshal(buf.Bytes())
+}
+
+func TestCodecCache_BufferedCodec(t *testing.T) {
+	codec := &gzipCodec{}
+	cache, err := NewWithCodec[string, string](2, codec)
+	if err != nil {
+		t.Fatalf("CodecCache error: %v", err)
+	}
+	long := string(bytes.Repeat([]byte("abc"), 1000))
+	cache.Add("a", long)
+	cache.Add("b", "short")
+	if data, _ := cache.GetBytes("a"); len(data) >= len(long) {
+		t.Fatalf("value not compressed: %d bytes", len(data))
+	}
+	for i := 0; i < 3; i++ {
+		for k, want := range map[string]string{"a": long, "b": "short"} {
+			if v, ok, err := cache.Get(k); !ok || err != nil || v != want {
+				t.Fatalf("bad value of %s: %.10q, ok = %v, err = %v", k, v, ok, err)
+			}
+		}
+	}
+	if codec.unmarshals != 6 {
+		t.Fatalf("buffered decoding not used: %d", codec.unmarshals)
+	}
+}
+
+func TestJSONCodec_MarshalTo(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (JSONCodec[codecTestValue]{}).MarshalTo(&buf, codecTestValue{Name: "<a>"}); err != nil {
+		t.Fatalf("MarshalTo error: %v", err)
+	}
+	data, _ := JSONCodec[codecTestValue]{}.Marshal(codecTestValue{Name: "<a>"})
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Fatalf("MarshalTo and Marshal differ: %q, %q", buf.Bytes(), data)
+	}
+}
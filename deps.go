@@ -0,0 +1,107 @@
+package dailzLRU
+
+import "errors"
+
+// ErrMissingDependency is returned by AddWithDeps when a dependency is not
+// in the cache.
+var ErrMissingDependency = errors.New("dependency is not in the cache")
+
+// ErrNoRoom is returned by AddWithDeps when the dependencies fill the cache,
+// leaving no room for the value without evicting one of them.
+var ErrNoRoom = errors.New("dependencies leave no room in the cache")
+
+// AddWithDeps adds a value that is derived from the entries deps, so that
+// when any of them leaves the cache, whether removed, evicted or handed to a
+// victim cache, the value is removed too. Removals cascade: entries that
+// depend on the value are removed in turn. Updating a dependency does not
+// remove its dependents. The dependencies replace any declared by an
+// earlier AddWithDeps for key; a plain Add keeps them. Nothing is added if
+// a dependency is not in the cache, since it could never be removed to
+// invalidate the value; AddWithDeps then fails with ErrMissingDependency.
+// Room for a new key is made by evicting entries other than its
+// dependencies, since evicting one would remove the value right away; if
+// only dependencies are left to evict, AddWithDeps fails with ErrNoRoom.
+// It fails with ErrFrozen while the cache is frozen. Returns true if an
+// eviction occurred.
+func (c *Cache[K, V]) AddWithDeps(key K, value V, deps ...K) (evicted bool, err error) {
+	err = c.Do(func(tx Txn[K, V]) error {
+		if !c.containsAll(deps) {
+			return ErrMissingDependency
+		}
+		if !c.lru.Contains(key) {
+			keep := make(map[K]struct{}, len(deps))
+			for _, d := range deps {
+				keep[d] = struct{}{}
+			}
+			evicted = c.lru.MakeRoom(1, func(k K) bool {
+				_, ok := keep[k]
+				return ok
+			}) > 0
+			// an eviction may have cascaded to a dependency
+			if !c.containsAll(deps) {
+				return ErrMissingDependency
+			}
+			if c.lru.Len() >= c.lru.Capacity() {
+				return ErrNoRoom
+			}
+		}
+		c.setDeps(key, deps)
+		if tx.Add(key, value) {
+			evicted = true
+		}
+		return nil
+	})
+	return evicted, err
+}
+
+// containsAll reports whether every key is in the cache; the lock must be
+// held
+func (c *Cache[K, V]) containsAll(keys []K) bool {
+	for _, k := range keys {
+		if !c.lru.Contains(k) {
+			return false
+		}
+	}
+	return true
+}
+
+// setDeps records that key depends on deps; the lock must be held
+func (c *Cache[K, V]) setDeps(key K, deps []K) {
+	c.unlinkDeps(key)
+	if len(deps) == 0 {
+		return
+	}
+	if c.deps == nil {
+		c.deps = make(map[K][]K)
+		c.dependents = make(map[K]map[K]struct{})
+	}
+	c.deps[key] = append([]K(nil), deps...)
+	for _, d := range deps {
+		if c.dependents[d] == nil {
+			c.dependents[d] = make(map[K]struct{})
+		}
+		c.dependents[d][key] = struct{}{}
+	}
+}
+
+// unlinkDeps forgets the dependencies of key; the lock must be held
+func (c *Cache[K, V]) unlinkDeps(key K) {
+	for _, d := range c.deps[key] {
+		delete(c.dependents[d], key)
+		if len(c.dependents[d]) == 0 {
+			delete(c.dependents, d)
+		}
+	}
+	delete(c.deps, key)
+}
+
+// dropDeps forgets the dependencies of key, which left the cache, and
+// removes its dependents; the lock must be held
+func (c *Cache[K, V]) dropDeps(key K) {
+	c.unlinkDeps(key)
+	dependents := c.dependents[key]
+	delete(c.dependents, key)
+	for d := range dependents {
+		c.lru.Remove(d)
+	}
+}
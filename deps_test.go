@@ -0,0 +1,116 @@
+package dailzLRU
+
+import "testing"
+
+func TestCache_AddWithDeps(t *testing.T) {
+	var evicted []string
+	l, err := NewWithEvict[string, int](4, func(k string, v int) {
+		evicted = append(evicted, k)
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add("a", 1)
+	l.Add("b", 2)
+	if _, err := l.AddWithDeps("sum", 3, "a", "b"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := l.AddWithDeps("double", 6, "sum"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// a missing dependency is rejected
+	if _, err := l.AddWithDeps("bad", 0, "a", "missing"); err != ErrMissingDependency || l.Contains("bad") {
+		t.Fatalf("missing dependency accepted: %v", err)
+	}
+	if _, ok := l.dependents["a"]["bad"]; ok {
+		t.Fatalf("rejected entry linked to its dependencies")
+	}
+
+	// updating a dependency keeps its dependents
+	l.Add("a", 10)
+	if !l.Contains("sum") {
+		t.Fatalf("sum should still be cached")
+	}
+
+	// removing one cascades through the dependency chain
+	l.Remove("b")
+	if l.Contains("sum") || l.Contains("double") || !l.Contains("a") {
+		t.Fatalf("bad keys: %v", l.Keys())
+	}
+	if len(evicted) != 3 || evicted[0] != "b" || evicted[1] != "sum" || evicted[2] != "double" {
+		t.Fatalf("bad evicted keys: %v", evicted)
+	}
+	if len(l.deps) != 0 || len(l.dependents) != 0 {
+		t.Fatalf("dependencies leaked: %v, %v", l.deps, l.dependents)
+	}
+
+	// so does evicting one for space
+	if _, err := l.AddWithDeps("x", 1, "a"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add("c", 3)
+	l.Add("d", 4)
+	l.Add("e", 5) // evicts a
+	if l.Contains("a") || l.Contains("x") || l.Len() != 3 {
+		t.Fatalf("bad keys: %v", l.Keys())
+	}
+}
+
+func TestCache_AddWithDepsVictim(t *testing.T) {
+	victim, err := New[int, int](8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l, err := NewWithVictim[int, int](2, nil, victim, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add(1, 1)
+	l.AddWithDeps(2, 2, 1)
+	l.Add(3, 3) // evicts 1, which cascades to 2
+
+	// only the entry evicted for space is handed over
+	if !victim.Contains(1) || victim.Contains(2) || l.Contains(2) {
+		t.Fatalf("bad keys: %v, %v", l.Keys(), victim.Keys())
+	}
+}
+
+func TestCache_AddWithDepsFrozen(t *testing.T) {
+	l, err := New[int, int](4)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add(1, 1)
+	l.Freeze()
+	if _, err := l.AddWithDeps(2, 2, 1); err != ErrFrozen || l.Contains(2) {
+		t.Fatalf("bad error: %v", err)
+	}
+}
+
+func TestCache_AddWithDepsFull(t *testing.T) {
+	l, err := New[int, int](2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add(1, 1)
+	l.Add(2, 2)
+	// 1 is the oldest entry, but making room evicts 2 instead
+	if evicted, err := l.AddWithDeps(3, 3, 1); err != nil || !evicted {
+		t.Fatalf("bad add: %v, %v", evicted, err)
+	}
+	if !l.Contains(1) || !l.Contains(3) || l.Contains(2) {
+		t.Fatalf("bad keys: %v", l.Keys())
+	}
+
+	// with only dependencies left to evict, nothing is added
+	if _, err := l.AddWithDeps(4, 4, 1, 3); err != ErrNoRoom {
+		t.Fatalf("bad error: %v", err)
+	}
+	if l.Contains(4) || l.Len() != 2 {
+		t.Fatalf("bad keys: %v", l.Keys())
+	}
+	if _, ok := l.dependents[1][4]; ok {
+		t.Fatalf("rejected entry linked to its dependencies")
+	}
+}
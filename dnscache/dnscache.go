@@ -0,0 +1,223 @@
+// Package dnscache caches host and SRV lookups in memory, so hot paths such
+// as dialers stop resolving the same names over and over.
+package dnscache
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/dailz1/dailzLRU"
+)
+
+// Lookuper performs the lookups a Resolver caches; *net.Resolver implements
+// it.
+type Lookuper interface {
+	LookupHost(ctx context.Context, host string) (addrs []string, err error)
+	LookupSRV(ctx context.Context, service, proto, name string) (cname string, addrs []*net.SRV, err error)
+}
+
+// TTLLookuper is a Lookuper that also reports the TTL of each answer: the
+// lowest TTL of the records returned, or, for a name that does not exist,
+// the negative caching TTL of its zone. A Resolver caching a TTLLookuper
+// keeps each lookup for the reported TTL, capped by the configured one.
+type TTLLookuper interface {
+	Lookuper
+	LookupHostTTL(ctx context.Context, host string) (addrs []string, ttl time.Duration, err error)
+	LookupSRVTTL(ctx context.Context, service, proto, name string) (cname string, addrs []*net.SRV, ttl time.Duration, err error)
+}
+
+// Options configures a Resolver.
+type Options struct {
+	// Size is the number of lookups kept. It must be positive.
+	Size int
+	// TTL is how long a successful lookup is kept, at most if the Lookuper
+	// reports TTLs. It must be positive.
+	TTL time.Duration
+	// NegativeTTL is how long a lookup of a name that does not exist is
+	// kept, at most if the Lookuper reports TTLs. Zero disables negative
+	// caching.
+	NegativeTTL time.Duration
+}
+
+// result is the outcome of a lookup
+type result struct {
+	addrs   []string
+	cname   string
+	srvs    []*net.SRV
+	err     error
+	ttl     time.Duration // the TTL reported by a TTLLookuper
+	hasTTL  bool
+	expires time.Time
+}
+
+// errLookupPanic is returned to callers waiting on a lookup that panicked
+var errLookupPanic = errors.New("dnscache: lookup panicked")
+
+// call is a lookup in flight, shared by the callers asking for it meanwhile
+type call struct {
+	done chan struct{}
+	res  *result
+}
+
+// Resolver caches the lookups of a Lookuper. Successful lookups are kept for
+// the configured TTL and lookups failing because the name does not exist
+// for the negative TTL; other failures are not cached. If the Lookuper is a
+// TTLLookuper, a lookup is kept no longer than the TTL it reports. The
+// standard library does not expose the TTLs of DNS records, so with a
+// *net.Resolver the configured TTL should not exceed the record TTLs of the
+// names looked up. Concurrent lookups of the same name share a single query.
+type Resolver struct {
+	next        Lookuper
+	nextTTL     TTLLookuper // next, if it reports TTLs
+	ttl         time.Duration
+	negativeTTL time.Duration
+	cache       *dailzLRU.ExpirableCache[string, *result]
+	calls       map[string]*call
+	lock        sync.Mutex
+}
+
+// New constructs a Resolver caching the lookups of next, or of
+// net.DefaultResolver if next is nil. Close must be called to stop the
+// background expiry of the cache.
+func New(next Lookuper, opts Options) (*Resolver, error) {
+	if opts.TTL <= 0 {
+		return nil, errors.New("must provide a positive ttl")
+	}
+	if opts.NegativeTTL < 0 {
+		return nil, errors.New("must provide a non-negative negative ttl")
+	}
+	if next == nil {
+		next = net.DefaultResolver
+	}
+	// the cache reclaims entries after the longest TTL; shorter ones are
+	// checked on every lookup
+	ttl := opts.TTL
+	if opts.NegativeTTL > ttl {
+		ttl = opts.NegativeTTL
+	}
+	cache, err := dailzLRU.NewExpirable[string, *result](opts.Size, ttl, nil)
+	if err != nil {
+		return nil, err
+	}
+	nextTTL, _ := next.(TTLLookuper)
+	return &Resolver{
+		next:        next,
+		nextTTL:     nextTTL,
+		ttl:         opts.TTL,
+		negativeTTL: opts.NegativeTTL,
+		cache:       cache,
+		calls:       make(map[string]*call),
+	}, nil
+}
+
+// LookupHost looks up the addresses of host, from the cache if possible.
+func (r *Resolver) LookupHost(ctx context.Context, host string) (addrs []string, err error) {
+	res := r.lookup(ctx, "host\x00"+host, func(ctx context.Context) *result {
+		if r.nextTTL != nil {
+			addrs, ttl, err := r.nextTTL.LookupHostTTL(ctx, host)
+			return &result{addrs: addrs, err: err, ttl: ttl, hasTTL: true}
+		}
+		addrs, err := r.next.LookupHost(ctx, host)
+		return &result{addrs: addrs, err: err}
+	})
+	return append([]string(nil), res.addrs...), res.err
+}
+
+// LookupSRV looks up the SRV records of the service, from the cache if
+// possible. Its arguments are those of net.Resolver.LookupSRV.
+func (r *Resolver) LookupSRV(ctx context.Context, service, proto, name string) (cname string, addrs []*net.SRV, err error) {
+	res := r.lookup(ctx, "srv\x00"+service+"\x00"+proto+"\x00"+name, func(ctx context.Context) *result {
+		if r.nextTTL != nil {
+			cname, srvs, ttl, err := r.nextTTL.LookupSRVTTL(ctx, service, proto, name)
+			return &result{cname: cname, srvs: srvs, err: err, ttl: ttl, hasTTL: true}
+		}
+		cname, srvs, err := r.next.LookupSRV(ctx, service, proto, name)
+		return &result{cname: cname, srvs: srvs, err: err}
+	})
+	addrs = make([]*net.SRV, len(res.srvs))
+	for i, srv := range res.srvs {
+		s := *srv
+		addrs[i] = &s
+	}
+	return res.cname, addrs, res.err
+}
+
+// lookup returns the cached result of key, or runs fn to get it. Callers
+// waiting on a lookup that failed with the context error of the caller
+// that made it try again.
+func (r *Resolver) lookup(ctx context.Context, key string, fn func(context.Context) *result) *result {
+	for {
+		if res, ok := r.cache.Get(key); ok && time.Now().Before(res.expires) {
+			return res
+		}
+		r.lock.Lock()
+		c, ok := r.calls[key]
+		if !ok {
+			c = &call{done: make(chan struct{})}
+			r.calls[key] = c
+		}
+		r.lock.Unlock()
+		if !ok {
+			r.run(ctx, key, fn, c)
+			return c.res
+		}
+
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			return &result{err: ctx.Err()}
+		}
+		if err := c.res.err; (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) && ctx.Err() == nil {
+			continue
+		}
+		return c.res
+	}
+}
+
+// run makes the lookup c, caching its result, and releases the callers
+// waiting on it even if fn panics
+func (r *Resolver) run(ctx context.Context, key string, fn func(context.Context) *result, c *call) {
+	c.res = &result{err: errLookupPanic}
+	defer func() {
+		r.lock.Lock()
+		delete(r.calls, key)
+		r.lock.Unlock()
+		close(c.done)
+	}()
+	c.res = fn(ctx)
+	if ttl := r.ttlOf(c.res); ttl > 0 {
+		c.res.expires = time.Now().Add(ttl)
+		r.cache.Add(key, c.res)
+	}
+}
+
+// ttlOf returns how long the result of a lookup is cached
+func (r *Resolver) ttlOf(res *result) time.Duration {
+	var ttl time.Duration
+	var dnsErr *net.DNSError
+	switch {
+	case res.err == nil:
+		ttl = r.ttl
+	case errors.As(res.err, &dnsErr) && dnsErr.IsNotFound:
+		ttl = r.negativeTTL
+	default:
+		return 0
+	}
+	if res.hasTTL {
+		ttl = min(ttl, res.ttl)
+	}
+	return ttl
+}
+
+// Flush drops every cached lookup.
+func (r *Resolver) Flush() {
+	r.cache.Purge()
+}
+
+// Close stops the background expiry of the cache.
+func (r *Resolver) Close() {
+	r.cache.Close()
+}
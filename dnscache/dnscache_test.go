@@ -0,0 +1,271 @@
+package dnscache
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeLookuper answers lookups from fixed data, counting them
+type fakeLookuper struct {
+	calls atomic.Int32
+	delay time.Duration
+}
+
+func (f *fakeLookuper) LookupHost(ctx context.Context, host string) ([]string, error) {
+	f.calls.Add(1)
+	time.Sleep(f.delay)
+	switch host {
+	case "example.com":
+		return []string{"192.0.2.1"}, nil
+	case "flaky.example.com":
+		return nil, &net.DNSError{Err: "server misbehaving", Name: host, IsTemporary: true}
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func (f *fakeLookuper) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	f.calls.Add(1)
+	return "_" + service + "._" + proto + "." + name + ".", []*net.SRV{{Target: "a." + name + ".", Port: 80}}, nil
+}
+
+func TestResolver(t *testing.T) {
+	f := &fakeLookuper{}
+	r, err := New(f, Options{Size: 16, TTL: 50 * time.Millisecond, NegativeTTL: time.Minute})
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	defer r.Close()
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		addrs, err := r.LookupHost(ctx, "example.com")
+		if err != nil || len(addrs) != 1 || addrs[0] != "192.0.2.1" {
+			t.Fatalf("bad lookup: %v, %v", addrs, err)
+		}
+		addrs[0] = "mutated"
+	}
+	if n := f.calls.Load(); n != 1 {
+		t.Fatalf("lookup not cached: %d calls", n)
+	}
+
+	for i := 0; i < 3; i++ {
+		var dnsErr *net.DNSError
+		if _, err := r.LookupHost(ctx, "missing.example.com"); !errors.As(err, &dnsErr) || !dnsErr.IsNotFound {
+			t.Fatalf("bad error: %v", err)
+		}
+	}
+	if n := f.calls.Load(); n != 2 {
+		t.Fatalf("missing name not cached: %d calls", n)
+	}
+
+	r.LookupHost(ctx, "flaky.example.com")
+	r.LookupHost(ctx, "flaky.example.com")
+	if n := f.calls.Load(); n != 4 {
+		t.Fatalf("temporary failure cached: %d calls", n)
+	}
+
+	for i := 0; i < 2; i++ {
+		cname, srvs, err := r.LookupSRV(ctx, "http", "tcp", "example.com")
+		if err != nil || cname != "_http._tcp.example.com." || len(srvs) != 1 || srvs[0].Port != 80 {
+			t.Fatalf("bad SRV lookup: %q, %v, %v", cname, srvs, err)
+		}
+	}
+	if n := f.calls.Load(); n != 5 {
+		t.Fatalf("SRV lookup not cached: %d calls", n)
+	}
+
+	// the positive TTL is shorter than the cache's own
+	time.Sleep(100 * time.Millisecond)
+	r.LookupHost(ctx, "example.com")
+	r.LookupHost(ctx, "missing.example.com")
+	if n := f.calls.Load(); n != 6 {
+		t.Fatalf("expired lookup served or missing name expired: %d calls", n)
+	}
+
+	r.Flush()
+	r.LookupHost(ctx, "missing.example.com")
+	if n := f.calls.Load(); n != 7 {
+		t.Fatalf("lookup not flushed: %d calls", n)
+	}
+}
+
+func TestResolver_Concurrent(t *testing.T) {
+	f := &fakeLookuper{delay: 50 * time.Millisecond}
+	r, err := New(f, Options{Size: 16, TTL: time.Minute})
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	defer r.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if addrs, err := r.LookupHost(context.Background(), "example.com"); err != nil || len(addrs) != 1 {
+				t.Errorf("bad lookup: %v, %v", addrs, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := f.calls.Load(); n != 1 {
+		t.Fatalf("concurrent lookups not shared: %d calls", n)
+	}
+
+	// negative caching is off
+	r.LookupHost(context.Background(), "missing.example.com")
+	r.LookupHost(context.Background(), "missing.example.com")
+	if n := f.calls.Load(); n != 3 {
+		t.Fatalf("missing name cached: %d calls", n)
+	}
+}
+
+// blockingLookuper answers host lookups once released, or fails with the
+// context's error
+type blockingLookuper struct {
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func (b *blockingLookuper) LookupHost(ctx context.Context, host string) ([]string, error) {
+	b.calls.Add(1)
+	if host == "panic.example.com" {
+		panic("lookup failed")
+	}
+	select {
+	case <-b.release:
+		return []string{"192.0.2.1"}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (b *blockingLookuper) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	return "", nil, errors.New("not implemented")
+}
+
+func TestResolver_Leader(t *testing.T) {
+	b := &blockingLookuper{release: make(chan struct{})}
+	r, err := New(b, Options{Size: 16, TTL: time.Minute})
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	defer r.Close()
+
+	// the first caller gives up, and a waiter takes over the lookup
+	first, cancel := context.WithCancel(context.Background())
+	go r.LookupHost(first, "example.com")
+	for b.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if addrs, err := r.LookupHost(context.Background(), "example.com"); err != nil || len(addrs) != 1 {
+				t.Errorf("bad lookup: %v, %v", addrs, err)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	for b.calls.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	close(b.release)
+	wg.Wait()
+	if n := b.calls.Load(); n != 2 {
+		t.Fatalf("concurrent lookups not shared: %d calls", n)
+	}
+
+	// a panicking lookup does not block later ones
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatalf("expected panic")
+			}
+		}()
+		r.LookupHost(context.Background(), "panic.example.com")
+	}()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer func() { recover() }()
+		r.LookupHost(context.Background(), "panic.example.com")
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("lookup hung after a panic")
+	}
+}
+
+func TestNew_Errors(t *testing.T) {
+	if _, err := New(nil, Options{Size: 1}); err == nil {
+		t.Fatalf("expected error for zero ttl")
+	}
+	if _, err := New(nil, Options{Size: 1, TTL: time.Second, NegativeTTL: -1}); err == nil {
+		t.Fatalf("expected error for negative ttl")
+	}
+	if _, err := New(nil, Options{TTL: time.Second}); err == nil {
+		t.Fatalf("expected error for zero size")
+	}
+}
+
+// ttlLookuper is a fakeLookuper reporting record TTLs
+type ttlLookuper struct {
+	fakeLookuper
+}
+
+func (f *ttlLookuper) LookupHostTTL(ctx context.Context, host string) ([]string, time.Duration, error) {
+	addrs, err := f.LookupHost(ctx, host)
+	if host == "short.example.com" {
+		return addrs, 50 * time.Millisecond, nil
+	}
+	return addrs, time.Hour, err
+}
+
+func (f *ttlLookuper) LookupSRVTTL(ctx context.Context, service, proto, name string) (string, []*net.SRV, time.Duration, error) {
+	cname, srvs, err := f.LookupSRV(ctx, service, proto, name)
+	return cname, srvs, 0, err
+}
+
+func TestResolver_RecordTTL(t *testing.T) {
+	f := &ttlLookuper{}
+	r, err := New(f, Options{Size: 16, TTL: time.Minute, NegativeTTL: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	defer r.Close()
+	ctx := context.Background()
+
+	for _, host := range []string{"example.com", "short.example.com", "missing.example.com"} {
+		r.LookupHost(ctx, host)
+		r.LookupHost(ctx, host)
+	}
+	if n := f.calls.Load(); n != 3 {
+		t.Fatalf("lookups not cached: %d calls", n)
+	}
+	// a record TTL of 0 is not cached
+	r.LookupSRV(ctx, "http", "tcp", "example.com")
+	r.LookupSRV(ctx, "http", "tcp", "example.com")
+	if n := f.calls.Load(); n != 5 {
+		t.Fatalf("SRV lookup with a TTL of 0 cached: %d calls", n)
+	}
+
+	// the short record TTL and the negative TTL, lower than the reported
+	// one, expire, while example.com is still cached
+	time.Sleep(100 * time.Millisecond)
+	for _, host := range []string{"example.com", "short.example.com", "missing.example.com"} {
+		r.LookupHost(ctx, host)
+	}
+	if n := f.calls.Load(); n != 7 {
+		t.Fatalf("bad expiry: %d calls", n)
+	}
+}
@@ -0,0 +1,446 @@
+package dailzLRU
+
+import (
+	"errors"
+	"github.com/dailz1/dailzLRU/lru"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultExpirableBuckets is the number of expiry buckets used by
+// NewExpirable.
+const DefaultExpirableBuckets = 100
+
+// expirableEntry is a value along with its expiry bookkeeping
+type expirableEntry[V any] struct {
+	value     V
+	expiresAt time.Time
+	slot      int64 // expiry slot, as returned by expiryBuckets.add
+}
+
+// ExpirableCache is a thread-safe fixed size LRU cache whose entries expire
+// a fixed time after they were last added. Instead of tracking every expiry
+// precisely, entries are grouped into coarse buckets by expiry time and a
+// background sweeper drops a whole bucket at a time. Lookups never return
+// an expired entry, but an expired entry can hold on to its memory, and
+// take up room in the cache, for up to one bucket width after it expires.
+type ExpirableCache[K comparable, V any] struct {
+	lru         *lru.LRU[K, expirableEntry[V]]
+	ttl         time.Duration
+	expiry      *expiryBuckets[K]
+	evictedKeys []K
+	evictedVals []V
+	onEvictedCB func(k K, v V)
+	expiredKeys []K
+	expiredVals []V
+	onExpireCB  func(k K, v V)
+	expiring    bool // set while the sweeper removes expired entries
+	lenExpired  bool // Len counts expired entries
+	logger      atomic.Pointer[slog.Logger]
+	stop        chan struct{}
+	done        chan struct{}
+	stopOnce    sync.Once
+	lock        sync.Mutex
+}
+
+// NewExpirable constructs an ExpirableCache of the given size whose entries
+// expire ttl after they were added, with expiries grouped into
+// DefaultExpirableBuckets buckets. onEvicted, if not nil, is called outside
+// of the cache's lock for entries that are evicted, removed or, unless
+// OnExpire sets a callback of their own, expired.
+// Close must be called to stop the background sweeper.
+func NewExpirable[K comparable, V any](size int, ttl time.Duration, onEvicted func(key K, value V)) (*ExpirableCache[K, V], error) {
+	return NewExpirableWithBuckets[K, V](size, ttl, DefaultExpirableBuckets, onEvicted)
+}
+
+// NewExpirableWithBuckets is like NewExpirable with the given number of
+// expiry buckets. Fewer buckets mean less frequent sweeps and coarser
+// reclamation of expired entries.
+func NewExpirableWithBuckets[K comparable, V any](size int, ttl time.Duration, buckets int, onEvicted func(key K, value V)) (*ExpirableCache[K, V], error) {
+	return newExpirable(size, ttl, buckets, onEvicted, lru.NewLRU[K, expirableEntry[V]])
+}
+
+// newExpirable constructs an ExpirableCache around the lru.LRU built by
+// newLRU
+func newExpirable[K comparable, V any](size int, ttl time.Duration, buckets int, onEvicted func(key K, value V), newLRU func(int, lru.EvictCallback[K, expirableEntry[V]]) (*lru.LRU[K, expirableEntry[V]], error)) (*ExpirableCache[K, V], error) {
+	expiry, err := newExpiryBuckets[K](ttl, buckets)
+	if err != nil {
+		return nil, err
+	}
+	c := &ExpirableCache[K, V]{
+		ttl:         ttl,
+		expiry:      expiry,
+		onEvictedCB: onEvicted,
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	l, err := newLRU(size, c.onEvicted)
+	if err != nil {
+		return nil, err
+	}
+	c.lru = l
+	go runSweeper(expiry.width, c.stop, c.done, func(now time.Time) { c.sweep(now) })
+	return c, nil
+}
+
+// OnExpire sets a callback invoked outside of the cache's lock for entries
+// removed because they expired, instead of the eviction callback, which is
+// then left with the entries evicted for space or removed. This tells apart
+// entries that are stale from those that may still be worth writing back.
+// A nil fn reports expired entries to the eviction callback again.
+func (c *ExpirableCache[K, V]) OnExpire(fn func(key K, value V)) {
+	c.lock.Lock()
+	c.onExpireCB = fn
+	c.lock.Unlock()
+}
+
+// onEvicted drops an entry from its bucket and buffers the eviction or
+// expiry callback
+func (c *ExpirableCache[K, V]) onEvicted(k K, e expirableEntry[V]) {
+	c.expiry.remove(k, e.slot)
+	if c.expiring && c.onExpireCB != nil {
+		c.expiredKeys = append(c.expiredKeys, k)
+		c.expiredVals = append(c.expiredVals, e.value)
+	} else if c.onEvictedCB != nil {
+		c.evictedKeys = append(c.evictedKeys, k)
+		c.evictedVals = append(c.evictedVals, e.value)
+	}
+}
+
+// takeEvicted hands back the buffered evictions and resets the buffers
+func (c *ExpirableCache[K, V]) takeEvicted() (ks []K, vs []V) {
+	ks, vs = c.evictedKeys, c.evictedVals
+	c.evictedKeys, c.evictedVals = nil, nil
+	return ks, vs
+}
+
+// notify invokes the eviction callback for buffered evictions
+func (c *ExpirableCache[K, V]) notify(ks []K, vs []V) {
+	for i := 0; i < len(ks); i++ {
+		c.onEvictedCB(ks[i], vs[i])
+	}
+}
+
+// sweep removes the entries expired by now, returning how many
+func (c *ExpirableCache[K, V]) sweep(now time.Time) (n int) {
+	c.lock.Lock()
+	c.expiring = true
+	c.expiry.sweep(now, func(k K) {
+		if e, _ := c.lru.Peek(k); !now.Before(e.expiresAt) {
+			c.lru.Remove(k)
+			n++
+		}
+	})
+	c.expiring = false
+	ks, vs := c.takeEvicted()
+	xks, xvs := c.expiredKeys, c.expiredVals
+	c.expiredKeys, c.expiredVals = nil, nil
+	onExpire := c.onExpireCB
+	c.lock.Unlock()
+	if l := c.logger.Load(); l != nil && n > 0 {
+		l.Debug("expired entries swept", "removed", n)
+	}
+	c.notify(ks, vs)
+	for i := 0; i < len(xks); i++ {
+		onExpire(xks[i], xvs[i])
+	}
+	return n
+}
+
+// EvictExpired removes every expired entry right away, invoking the expiry
+// callback set by OnExpire for each, or the eviction callback if there is
+// none, and returns how many were removed. It suits
+// callers who prefer to reclaim expired entries at times of their choosing,
+// such as idle periods, over waiting for the background sweeper.
+func (c *ExpirableCache[K, V]) EvictExpired() int {
+	return c.sweep(time.Now())
+}
+
+// Add adds a value to the cache, resetting its expiry. Returns true if an
+// eviction occurred.
+func (c *ExpirableCache[K, V]) Add(key K, value V) (evicted bool) {
+	c.lock.Lock()
+	if old, ok := c.lru.Peek(key); ok {
+		c.expiry.remove(key, old.slot)
+	}
+	expiresAt := time.Now().Add(c.ttl)
+	slot := c.expiry.add(key, expiresAt)
+	evicted = c.lru.Add(key, expirableEntry[V]{value: value, expiresAt: expiresAt, slot: slot})
+	ks, vs := c.takeEvicted()
+	c.lock.Unlock()
+	c.notify(ks, vs)
+	return evicted
+}
+
+// Get looks up a key's value from the cache, updating its recent-ness but
+// not its expiry.
+func (c *ExpirableCache[K, V]) Get(key K) (value V, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if e, ok := c.lru.Peek(key); !ok || !time.Now().Before(e.expiresAt) {
+		return value, false
+	}
+	e, _ := c.lru.Get(key)
+	return e.value, true
+}
+
+// GetWithExpiry is like Get, also returning when the value expires.
+func (c *ExpirableCache[K, V]) GetWithExpiry(key K) (value V, expiresAt time.Time, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if e, ok := c.lru.Peek(key); !ok || !time.Now().Before(e.expiresAt) {
+		return value, expiresAt, false
+	}
+	e, _ := c.lru.Get(key)
+	return e.value, e.expiresAt, true
+}
+
+// Peek returns the key value (or undefined if not found or expired) without
+// updating the "recently used"-ness of the key.
+func (c *ExpirableCache[K, V]) Peek(key K) (value V, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if e, ok := c.lru.Peek(key); ok && time.Now().Before(e.expiresAt) {
+		return e.value, true
+	}
+	return value, false
+}
+
+// Contains checks if an unexpired key is in the cache, without updating its
+// recent-ness.
+func (c *ExpirableCache[K, V]) Contains(key K) bool {
+	_, ok := c.Peek(key)
+	return ok
+}
+
+// Remove removes the provided key from the cache, returning true if the key
+// was contained.
+func (c *ExpirableCache[K, V]) Remove(key K) (present bool) {
+	c.lock.Lock()
+	present = c.lru.Remove(key)
+	ks, vs := c.takeEvicted()
+	c.lock.Unlock()
+	c.notify(ks, vs)
+	return
+}
+
+// Keys returns a slice of the unexpired keys in the cache, from oldest to
+// newest.
+func (c *ExpirableCache[K, V]) Keys() []K {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	now := time.Now()
+	keys := make([]K, 0, c.lru.Len())
+	c.lru.Range(func(k K, e expirableEntry[V]) bool {
+		if now.Before(e.expiresAt) {
+			keys = append(keys, k)
+		}
+		return true
+	})
+	return keys
+}
+
+// Len returns the number of unexpired items in the cache, or of all items
+// if LenCountsExpired(true) was called. Expired items are counted as
+// ExpiredLen counts them.
+func (c *ExpirableCache[K, V]) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.lenExpired {
+		return c.lru.Len()
+	}
+	return c.lru.Len() - c.expiry.expiredLen(time.Now())
+}
+
+// LenCountsExpired sets whether Len counts the expired items that have not
+// been swept yet, as the underlying cache does, or only unexpired ones,
+// which is the default. Counting them suits callers comparing Len with the
+// size of the cache, since expired items take up room until swept.
+func (c *ExpirableCache[K, V]) LenCountsExpired(count bool) {
+	c.lock.Lock()
+	c.lenExpired = count
+	c.lock.Unlock()
+}
+
+// ExpiredLen returns the number of expired items that have not been swept
+// yet. They still hold on to their memory and count towards the size of
+// the cache, but are never returned. The count is kept up to date as
+// entries come and go and as their buckets expire, so it takes constant
+// time, but an item is only counted once its whole bucket has expired, up
+// to one bucket width after the item itself.
+func (c *ExpirableCache[K, V]) ExpiredLen() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.expiry.expiredLen(time.Now())
+}
+
+// Resize changes the cache size, returning the number of entries evicted.
+func (c *ExpirableCache[K, V]) Resize(size int) (evicted int) {
+	c.lock.Lock()
+	evicted = c.lru.Resize(size)
+	ks, vs := c.takeEvicted()
+	c.lock.Unlock()
+	c.notify(ks, vs)
+	return evicted
+}
+
+// Purge is used to completely clear the cache.
+func (c *ExpirableCache[K, V]) Purge() {
+	c.lock.Lock()
+	c.lru.Purge()
+	ks, vs := c.takeEvicted()
+	c.lock.Unlock()
+	c.notify(ks, vs)
+}
+
+// Close stops the background sweeper. The cache keeps working afterwards,
+// but expired entries are no longer reclaimed until they are evicted.
+func (c *ExpirableCache[K, V]) Close() {
+	c.stopOnce.Do(func() { close(c.stop) })
+	<-c.done
+}
+
+// expiryBuckets groups keys into coarse buckets by expiry time, so expired
+// keys can be swept a bucket at a time, and counts the keys of the slots
+// that ended; it is not safe for concurrent use
+type expiryBuckets[K comparable] struct {
+	width    time.Duration // time span covered by a bucket
+	buckets  []map[K]struct{}
+	swept    int64         // last bucket slot the sweeper visited
+	counts   map[int64]int // keys in each slot
+	counted  int64         // last slot whose keys were counted as expired
+	nexpired int           // keys in the slots up to counted
+}
+
+// newExpiryBuckets constructs expiryBuckets for keys living ttl, spread over
+// the given number of buckets
+func newExpiryBuckets[K comparable](ttl time.Duration, buckets int) (*expiryBuckets[K], error) {
+	if ttl <= 0 {
+		return nil, errors.New("must provide a positive ttl")
+	}
+	if buckets <= 0 {
+		return nil, errors.New("must provide a positive bucket count")
+	}
+	width := ttl / time.Duration(buckets)
+	if width <= 0 {
+		width = 1
+	}
+	b := &expiryBuckets[K]{
+		width: width,
+		// a key expires at most buckets+1 slots ahead of the current one,
+		// so this many buckets never mix keys of unswept slots
+		buckets: make([]map[K]struct{}, buckets+1),
+		counts:  make(map[int64]int),
+	}
+	for i := range b.buckets {
+		b.buckets[i] = make(map[K]struct{})
+	}
+	b.swept = b.slot(time.Now())
+	b.counted = b.swept
+	return b, nil
+}
+
+// slot returns the number of the bucket width interval t falls in
+func (b *expiryBuckets[K]) slot(t time.Time) int64 {
+	return t.UnixNano() / int64(b.width)
+}
+
+// bucket returns the bucket of the keys of slot
+func (b *expiryBuckets[K]) bucket(slot int64) map[K]struct{} {
+	return b.buckets[slot%int64(len(b.buckets))]
+}
+
+// add places key in the bucket for expiresAt and returns its slot, which
+// remove takes
+func (b *expiryBuckets[K]) add(key K, expiresAt time.Time) (slot int64) {
+	// round the slot up so the key is swept, and counted as expired, only
+	// once it has expired
+	slot = b.slot(expiresAt) + 1
+	b.bucket(slot)[key] = struct{}{}
+	b.counts[slot]++
+	if slot <= b.counted {
+		// the clock went back
+		b.nexpired++
+	}
+	return slot
+}
+
+// remove drops key, added with the given slot
+func (b *expiryBuckets[K]) remove(key K, slot int64) {
+	delete(b.bucket(slot), key)
+	if b.counts[slot]--; b.counts[slot] <= 0 {
+		delete(b.counts, slot)
+	}
+	if slot <= b.counted {
+		b.nexpired--
+	}
+}
+
+// expiredLen returns the number of keys whose slot ended by now, counting
+// those of the slots that ended since the last call
+func (b *expiryBuckets[K]) expiredLen(now time.Time) int {
+	cur := b.slot(now)
+	if cur <= b.counted {
+		return b.nexpired
+	}
+	if cur-b.counted > int64(len(b.counts)) {
+		for s, n := range b.counts {
+			if s > b.counted && s <= cur {
+				b.nexpired += n
+			}
+		}
+	} else {
+		for s := b.counted + 1; s <= cur; s++ {
+			b.nexpired += b.counts[s]
+		}
+	}
+	b.counted = cur
+	return b.nexpired
+}
+
+// sweep calls fn for every key of the buckets that may hold keys expired
+// by now, as expired does, and marks the buckets whose slot ended before
+// now as swept. fn may remove the key.
+func (b *expiryBuckets[K]) sweep(now time.Time, fn func(key K)) {
+	b.expired(now, fn)
+	b.swept = b.slot(now)
+}
+
+// expired calls fn for every key of the buckets that may hold keys expired
+// by now: those not swept yet whose slot ended before now, and the one of
+// the keys expiring during the current slot. A bucket can also hold keys
+// of a later slot if the sweeper fell behind, so fn must check each key's
+// expiry.
+func (b *expiryBuckets[K]) expired(now time.Time, fn func(key K)) {
+	b.each(b.swept+1, b.slot(now)+1, fn)
+}
+
+// each calls fn for every key of the buckets of slots from to to
+func (b *expiryBuckets[K]) each(from, to int64, fn func(key K)) {
+	if n := int64(len(b.buckets)); to-from >= n {
+		from = to - n + 1
+	}
+	for s := from; s <= to; s++ {
+		for k := range b.bucket(s) {
+			fn(k)
+		}
+	}
+}
+
+// runSweeper calls sweep every interval until stop is closed, then closes
+// done
+func runSweeper(interval time.Duration, stop <-chan struct{}, done chan<- struct{}, sweep func(now time.Time)) {
+	defer close(done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			sweep(now)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package dailzLRU
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestExpirable(t *testing.T) {
+	var lock sync.Mutex
+	var expired []int
+	l, err := NewExpirableWithBuckets[int, int](8, 50*time.Millisecond, 5, func(k, v int) {
+		lock.Lock()
+		expired = append(expired, k)
+		lock.Unlock()
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer l.Close()
+
+	l.Add(1, 1)
+	l.Add(2, 2)
+	if v, ok := l.Get(1); !ok || v != 1 {
+		t.Fatalf("bad value: %v, %v", v, ok)
+	}
+	time.Sleep(30 * time.Millisecond)
+	l.Add(2, 20) // resets the expiry
+	time.Sleep(30 * time.Millisecond)
+
+	if l.Contains(1) {
+		t.Fatalf("1 should have expired")
+	}
+	if v, ok := l.Peek(2); !ok || v != 20 {
+		t.Fatalf("bad value: %v, %v", v, ok)
+	}
+	if keys := l.Keys(); len(keys) != 1 || keys[0] != 2 {
+		t.Fatalf("bad keys: %v", keys)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for l.Len()+l.ExpiredLen() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expired entries were not swept: %v", l.ExpiredLen())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	if len(expired) != 2 || expired[0] != 1 || expired[1] != 2 {
+		t.Fatalf("bad expired keys: %v", expired)
+	}
+}
+
+func TestExpirable_Len(t *testing.T) {
+	l, err := NewExpirable[int, int](8, 20*time.Millisecond, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	// without the sweeper, expired entries stay in the cache
+	l.Close()
+
+	l.Add(1, 1)
+	l.Add(2, 2)
+	if l.Len() != 2 || l.ExpiredLen() != 0 {
+		t.Fatalf("bad lengths: %v, %v", l.Len(), l.ExpiredLen())
+	}
+	time.Sleep(30 * time.Millisecond)
+	l.Add(3, 3)
+	if l.Len() != 1 || l.ExpiredLen() != 2 {
+		t.Fatalf("bad lengths: %v, %v", l.Len(), l.ExpiredLen())
+	}
+	// the count follows expired entries leaving the cache
+	l.Remove(1)
+	if l.Len() != 1 || l.ExpiredLen() != 1 {
+		t.Fatalf("bad lengths: %v, %v", l.Len(), l.ExpiredLen())
+	}
+	l.Add(2, 2)
+	if l.Len() != 2 || l.ExpiredLen() != 0 {
+		t.Fatalf("bad lengths: %v, %v", l.Len(), l.ExpiredLen())
+	}
+	l.Add(4, 4)
+	time.Sleep(30 * time.Millisecond)
+	l.LenCountsExpired(true)
+	if l.Len() != 3 || l.ExpiredLen() != 3 {
+		t.Fatalf("bad lengths: %v, %v", l.Len(), l.ExpiredLen())
+	}
+}
+
+func TestExpirable_EvictExpired(t *testing.T) {
+	var evicted []int
+	l, err := NewExpirable[int, int](8, 20*time.Millisecond, func(k, v int) {
+		evicted = append(evicted, k)
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Close()
+
+	l.Add(1, 1)
+	l.Add(2, 2)
+	if n := l.EvictExpired(); n != 0 {
+		t.Fatalf("unexpired entries evicted: %v", n)
+	}
+	time.Sleep(30 * time.Millisecond)
+	l.Add(3, 3)
+	if n := l.EvictExpired(); n != 2 || len(evicted) != 2 || l.ExpiredLen() != 0 {
+		t.Fatalf("bad eviction: %v, %v", n, evicted)
+	}
+	if l.Len() != 1 || !l.Contains(3) {
+		t.Fatalf("bad keys: %v", l.Keys())
+	}
+
+	// with an expiry callback, it is called instead
+	var expired []int
+	l.OnExpire(func(k, v int) { expired = append(expired, k) })
+	time.Sleep(30 * time.Millisecond)
+	if n := l.EvictExpired(); n != 1 || len(evicted) != 2 || len(expired) != 1 || expired[0] != 3 {
+		t.Fatalf("bad callbacks: %v, %v, %v", n, evicted, expired)
+	}
+}
+
+func TestExpirable_OnExpire(t *testing.T) {
+	var evicted, expired []int
+	l, err := NewExpirable[int, int](2, 20*time.Millisecond, func(k, v int) {
+		evicted = append(evicted, k)
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Close()
+	l.OnExpire(func(k, v int) {
+		expired = append(expired, k)
+	})
+
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Add(3, 3) // evicts 1
+	time.Sleep(30 * time.Millisecond)
+	l.EvictExpired()
+	if len(evicted) != 1 || evicted[0] != 1 {
+		t.Fatalf("bad evicted keys: %v", evicted)
+	}
+	if len(expired) != 2 {
+		t.Fatalf("bad expired keys: %v", expired)
+	}
+
+	// without an expiry callback, expired entries are reported as evicted
+	l.OnExpire(nil)
+	l.Add(4, 4)
+	time.Sleep(30 * time.Millisecond)
+	l.EvictExpired()
+	if len(evicted) != 2 || evicted[1] != 4 || len(expired) != 2 {
+		t.Fatalf("bad callbacks: %v, %v", evicted, expired)
+	}
+}
+
+func TestExpirableCache_GetWithExpiry(t *testing.T) {
+	c, err := NewExpirable[string, int](8, time.Minute, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer c.Close()
+	before := time.Now()
+	c.Add("a", 1)
+	v, expiresAt, ok := c.GetWithExpiry("a")
+	if !ok || v != 1 || expiresAt.Before(before.Add(time.Minute)) || expiresAt.After(time.Now().Add(time.Minute)) {
+		t.Fatalf("bad result: %v, %v, %v", v, expiresAt, ok)
+	}
+	if _, _, ok := c.GetWithExpiry("missing"); ok {
+		t.Fatalf("missing key found")
+	}
+}
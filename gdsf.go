@@ -0,0 +1,327 @@
+package dailzLRU
+
+import (
+	"container/heap"
+	"errors"
+	"sync"
+)
+
+// ErrEntryTooLarge is returned when an entry cannot fit in the cache it is
+// added to: its size exceeds the capacity of a GDSFCache, or its value the
+// segment size of an ArenaCache.
+var ErrEntryTooLarge = errors.New("entry is too large for the cache")
+
+// gdsfEntry is an entry of a GDSFCache
+type gdsfEntry[K comparable, V any] struct {
+	key       K
+	value     V
+	size      int64
+	cost      float64
+	freq      float64
+	inflation float64 // the inflation the priority was computed at
+	priority  float64
+	index     int // position in the heap
+}
+
+// gdsfHeap orders entries by priority, lowest first
+type gdsfHeap[K comparable, V any] []*gdsfEntry[K, V]
+
+func (h gdsfHeap[K, V]) Len() int           { return len(h) }
+func (h gdsfHeap[K, V]) Less(i, j int) bool { return h[i].priority < h[j].priority }
+
+func (h gdsfHeap[K, V]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *gdsfHeap[K, V]) Push(x any) {
+	e := x.(*gdsfEntry[K, V])
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *gdsfHeap[K, V]) Pop() any {
+	old := *h
+	e := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return e
+}
+
+// GDSFCache is a thread-safe cache bounded by the total size of its entries
+// that evicts using GreedyDual-Size-Frequency. Every entry has a size and a
+// cost to fetch it again; its priority is
+//
+//	inflation + frequency * cost / size
+//
+// and the entry with the lowest priority is evicted first. Small, expensive
+// and frequently used entries are thus kept over large, cheap and rarely
+// used ones. The inflation value rises to the priority of every evicted
+// entry, so entries that stop being used eventually age out.
+type GDSFCache[K comparable, V any] struct {
+	capacity    int64
+	size        int64
+	inflation   float64
+	items       map[K]*gdsfEntry[K, V]
+	heap        gdsfHeap[K, V]
+	evictedKeys []K
+	evictedVals []V
+	onEvictedCB func(k K, v V)
+	lock        sync.Mutex
+}
+
+// NewGDSF constructs a GDSFCache holding entries with a total size of up to
+// capacity. onEvicted, if not nil, is called outside of the cache's lock for
+// entries that are evicted or removed.
+func NewGDSF[K comparable, V any](capacity int64, onEvicted func(key K, value V)) (*GDSFCache[K, V], error) {
+	if capacity <= 0 {
+		return nil, errors.New("must provide a positive capacity")
+	}
+	return &GDSFCache[K, V]{
+		capacity:    capacity,
+		items:       make(map[K]*gdsfEntry[K, V]),
+		onEvictedCB: onEvicted,
+	}, nil
+}
+
+// prioritize recomputes the priority of e at the current inflation
+func (c *GDSFCache[K, V]) prioritize(e *gdsfEntry[K, V]) {
+	e.inflation = c.inflation
+	e.weigh()
+}
+
+// weigh recomputes the priority of e at the inflation it was last
+// prioritized at
+func (e *gdsfEntry[K, V]) weigh() {
+	e.priority = e.inflation + e.freq*e.cost/float64(e.size)
+}
+
+// removeEntry removes e, buffering the eviction callback
+func (c *GDSFCache[K, V]) removeEntry(e *gdsfEntry[K, V]) {
+	heap.Remove(&c.heap, e.index)
+	delete(c.items, e.key)
+	c.size -= e.size
+	if c.onEvictedCB != nil {
+		c.evictedKeys = append(c.evictedKeys, e.key)
+		c.evictedVals = append(c.evictedVals, e.value)
+	}
+}
+
+// evictFor evicts the lowest priority entries until n more bytes fit,
+// returning true if any entry was evicted
+func (c *GDSFCache[K, V]) evictFor(n int64) (evicted bool) {
+	for c.size+n > c.capacity && len(c.heap) > 0 {
+		e := c.heap[0]
+		c.inflation = e.priority
+		c.removeEntry(e)
+		evicted = true
+	}
+	return evicted
+}
+
+// takeEvicted hands back the buffered evictions and resets the buffers
+func (c *GDSFCache[K, V]) takeEvicted() (ks []K, vs []V) {
+	ks, vs = c.evictedKeys, c.evictedVals
+	c.evictedKeys, c.evictedVals = nil, nil
+	return ks, vs
+}
+
+// notify invokes the eviction callback for buffered evictions
+func (c *GDSFCache[K, V]) notify(ks []K, vs []V) {
+	for i := 0; i < len(ks); i++ {
+		c.onEvictedCB(ks[i], vs[i])
+	}
+}
+
+// Add adds a value of the given size, which must be positive, and cost of
+// fetching it again to the cache. Re-adding a key replaces its value, size
+// and cost and counts as a use. Returns true if an eviction occurred, or
+// ErrEntryTooLarge if size exceeds the capacity of the cache.
+func (c *GDSFCache[K, V]) Add(key K, value V, size int64, cost float64) (evicted bool, err error) {
+	if size <= 0 {
+		return false, errors.New("must provide a positive size")
+	}
+	if size > c.capacity {
+		return false, ErrEntryTooLarge
+	}
+	c.lock.Lock()
+	freq := 1.0
+	if e, ok := c.items[key]; ok {
+		// the old value is replaced, not evicted
+		freq = e.freq + 1
+		heap.Remove(&c.heap, e.index)
+		delete(c.items, key)
+		c.size -= e.size
+	}
+	evicted = c.evictFor(size)
+	e := &gdsfEntry[K, V]{key: key, value: value, size: size, cost: cost, freq: freq}
+	c.prioritize(e)
+	heap.Push(&c.heap, e)
+	c.items[key] = e
+	c.size += size
+	ks, vs := c.takeEvicted()
+	c.lock.Unlock()
+	c.notify(ks, vs)
+	return evicted, nil
+}
+
+// UpdateSize changes the size of the entry of key, which must be positive,
+// for values that grow or shrink after being added. Only the size term of
+// the entry's priority changes: neither a use nor the current inflation
+// value is counted, so resizing does not refresh an entry. Other entries are
+// evicted if it no longer fits. Returns false if the key is not in the cache, true if an
+// eviction occurred, or ErrEntryTooLarge, leaving the entry as it was, if
+// size exceeds the capacity of the cache.
+func (c *GDSFCache[K, V]) UpdateSize(key K, size int64) (ok, evicted bool, err error) {
+	if size <= 0 {
+		return false, false, errors.New("must provide a positive size")
+	}
+	if size > c.capacity {
+		return false, false, ErrEntryTooLarge
+	}
+	c.lock.Lock()
+	e, ok := c.items[key]
+	if !ok {
+		c.lock.Unlock()
+		return false, false, nil
+	}
+	// take the entry out so it is not evicted to make room for itself
+	heap.Remove(&c.heap, e.index)
+	delete(c.items, key)
+	c.size -= e.size
+	evicted = c.evictFor(size)
+	e.size = size
+	e.weigh()
+	heap.Push(&c.heap, e)
+	c.items[key] = e
+	c.size += size
+	ks, vs := c.takeEvicted()
+	c.lock.Unlock()
+	c.notify(ks, vs)
+	return true, evicted, nil
+}
+
+// Get looks up a key's value from the cache, counting it as a use.
+func (c *GDSFCache[K, V]) Get(key K) (value V, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	e, ok := c.items[key]
+	if !ok {
+		return value, false
+	}
+	e.freq++
+	c.prioritize(e)
+	heap.Fix(&c.heap, e.index)
+	return e.value, true
+}
+
+// Peek returns the key value (or undefined if not found) without counting
+// it as a use.
+func (c *GDSFCache[K, V]) Peek(key K) (value V, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if e, ok := c.items[key]; ok {
+		return e.value, true
+	}
+	return value, false
+}
+
+// Contains checks if a key is in the cache, without counting it as a use.
+func (c *GDSFCache[K, V]) Contains(key K) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	_, ok := c.items[key]
+	return ok
+}
+
+// Remove removes the provided key from the cache, returning true if the key
+// was contained.
+func (c *GDSFCache[K, V]) Remove(key K) (present bool) {
+	c.lock.Lock()
+	e, present := c.items[key]
+	if present {
+		c.removeEntry(e)
+	}
+	ks, vs := c.takeEvicted()
+	c.lock.Unlock()
+	c.notify(ks, vs)
+	return present
+}
+
+// RemoveIf removes the provided key from the cache if fn, called with its
+// value under the cache's lock, returns true. Returns true if the key was
+// removed.
+func (c *GDSFCache[K, V]) RemoveIf(key K, fn func(value V) bool) (removed bool) {
+	c.lock.Lock()
+	e, ok := c.items[key]
+	if ok && fn(e.value) {
+		c.removeEntry(e)
+		removed = true
+	}
+	ks, vs := c.takeEvicted()
+	c.lock.Unlock()
+	c.notify(ks, vs)
+	return removed
+}
+
+// Keys returns a slice of the keys in the cache, in no particular order.
+func (c *GDSFCache[K, V]) Keys() []K {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	keys := make([]K, len(c.heap))
+	for i, e := range c.heap {
+		keys[i] = e.key
+	}
+	return keys
+}
+
+// Len returns the number of items in the cache.
+func (c *GDSFCache[K, V]) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.heap)
+}
+
+// Size returns the total size of the items in the cache.
+func (c *GDSFCache[K, V]) Size() int64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.size
+}
+
+// Capacity returns the total size of the items the cache can hold.
+func (c *GDSFCache[K, V]) Capacity() int64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.capacity
+}
+
+// Resize changes the capacity of the cache, returning the number of entries
+// evicted. A capacity that is not positive is ignored.
+func (c *GDSFCache[K, V]) Resize(capacity int64) (evicted int) {
+	if capacity <= 0 {
+		return 0
+	}
+	c.lock.Lock()
+	c.capacity = capacity
+	n := len(c.heap)
+	c.evictFor(0)
+	evicted = n - len(c.heap)
+	ks, vs := c.takeEvicted()
+	c.lock.Unlock()
+	c.notify(ks, vs)
+	return evicted
+}
+
+// Purge is used to completely clear the cache.
+func (c *GDSFCache[K, V]) Purge() {
+	c.lock.Lock()
+	for len(c.heap) > 0 {
+		c.removeEntry(c.heap[len(c.heap)-1])
+	}
+	c.inflation = 0
+	ks, vs := c.takeEvicted()
+	c.lock.Unlock()
+	c.notify(ks, vs)
+}
@@ -0,0 +1,137 @@
+package dailzLRU
+
+import "testing"
+
+func TestGDSF(t *testing.T) {
+	var evicted []string
+	l, err := NewGDSF[string, int](100, func(k string, v int) {
+		evicted = append(evicted, k)
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add("large", 1, 50, 1)
+	l.Add("small", 2, 10, 1)
+	l.Add("costly", 3, 10, 100)
+
+	// the large cheap entry has the lowest priority
+	if ev, err := l.Add("large2", 4, 50, 1); !ev || err != nil {
+		t.Fatalf("should have evicted: %v", err)
+	}
+	if len(evicted) != 1 || evicted[0] != "large" {
+		t.Fatalf("bad evicted keys: %v", evicted)
+	}
+	if l.Size() != 70 || l.Len() != 3 {
+		t.Fatalf("bad size: %v, %v", l.Size(), l.Len())
+	}
+
+	// frequently used entries are kept over entries added later
+	for i := 0; i < 5; i++ {
+		l.Get("small")
+	}
+	l.Add("medium", 5, 40, 1)
+	if len(evicted) != 2 || evicted[1] != "large2" {
+		t.Fatalf("bad evicted keys: %v", evicted)
+	}
+
+	if _, err := l.Add("huge", 6, 101, 1); err != ErrEntryTooLarge {
+		t.Fatalf("bad error: %v", err)
+	}
+	if v, ok := l.Peek("costly"); !ok || v != 3 {
+		t.Fatalf("bad value: %v, %v", v, ok)
+	}
+	if !l.Remove("costly") || l.Contains("costly") {
+		t.Fatalf("bad remove")
+	}
+	l.Purge()
+	if l.Len() != 0 || l.Size() != 0 || len(evicted) != 5 {
+		t.Fatalf("bad purge: %v, %v, %v", l.Len(), l.Size(), evicted)
+	}
+}
+
+func TestGDSF_UpdateSize(t *testing.T) {
+	var evicted []string
+	l, err := NewGDSF[string, int](100, func(k string, v int) {
+		evicted = append(evicted, k)
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add("a", 1, 10, 1)
+	l.Add("b", 2, 40, 1)
+	l.Add("c", 3, 30, 1)
+
+	if ok, ev, err := l.UpdateSize("a", 20); !ok || ev || err != nil {
+		t.Fatalf("bad update: %v, %v, %v", ok, ev, err)
+	}
+	if l.Size() != 90 {
+		t.Fatalf("bad size: %v", l.Size())
+	}
+	// growing past the capacity evicts others, never the entry itself
+	if ok, ev, err := l.UpdateSize("a", 50); !ok || !ev || err != nil {
+		t.Fatalf("bad update: %v, %v, %v", ok, ev, err)
+	}
+	if len(evicted) != 1 || evicted[0] != "b" || l.Size() != 80 || !l.Contains("a") {
+		t.Fatalf("bad eviction: %v, size %v", evicted, l.Size())
+	}
+	// a keeps the inflation it was added at, 0, rather than taking the
+	// 1/40 left by evicting b, so it now ranks below c
+	if ev, _ := l.Add("d", 4, 30, 1); !ev || len(evicted) != 2 || evicted[1] != "a" {
+		t.Fatalf("bad eviction: %v", evicted)
+	}
+	l.Add("a", 1, 50, 1)
+	if _, _, err := l.UpdateSize("a", 101); err != ErrEntryTooLarge {
+		t.Fatalf("bad error: %v", err)
+	}
+	if _, _, err := l.UpdateSize("a", 0); err == nil {
+		t.Fatalf("expected error for zero size")
+	}
+	if ok, _, _ := l.UpdateSize("missing", 1); ok {
+		t.Fatalf("missing key updated")
+	}
+	if l.Size() != 80 {
+		t.Fatalf("bad size: %v", l.Size())
+	}
+}
+
+func TestGDSF_RemoveIf(t *testing.T) {
+	var evicted []string
+	l, err := NewGDSF[string, int](100, func(k string, v int) {
+		evicted = append(evicted, k)
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add("a", 1, 10, 1)
+	if l.RemoveIf("a", func(v int) bool { return v == 2 }) || !l.Contains("a") {
+		t.Fatalf("a removed although its value does not match")
+	}
+	if l.RemoveIf("missing", func(int) bool { return true }) {
+		t.Fatalf("missing key removed")
+	}
+	if !l.RemoveIf("a", func(v int) bool { return v == 1 }) || l.Contains("a") {
+		t.Fatalf("a not removed")
+	}
+	if len(evicted) != 1 || evicted[0] != "a" || l.Size() != 0 {
+		t.Fatalf("bad removal: %v, size %v", evicted, l.Size())
+	}
+}
+
+func TestGDSF_Resize(t *testing.T) {
+	l, err := NewGDSF[string, int](100, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add("a", 1, 40, 1)
+	l.Add("b", 2, 30, 1)
+	l.Add("c", 3, 20, 1)
+	if n := l.Resize(0); n != 0 || l.Capacity() != 100 {
+		t.Fatalf("bad resize to 0: %v, capacity %v", n, l.Capacity())
+	}
+	if n := l.Resize(25); n != 2 || l.Len() != 1 || l.Capacity() != 25 || !l.Contains("c") {
+		t.Fatalf("bad resize: %v, %v", n, l.Keys())
+	}
+	if _, err := l.Add("d", 4, 30, 1); err != ErrEntryTooLarge {
+		t.Fatalf("bad error: %v", err)
+	}
+}
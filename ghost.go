@@ -0,0 +1,80 @@
+package dailzLRU
+
+import (
+	"github.com/dailz1/dailzLRU/lru"
+	"sync"
+)
+
+// GhostCache is a thread-safe fixed size set of keys kept in LRU order. It
+// stores no values, which makes it a cheap way to remember keys that were
+// recently evicted from another cache.
+type GhostCache[K comparable] struct {
+	lru  *lru.LRU[K, struct{}]
+	lock sync.RWMutex
+}
+
+// NewGhost constructs a GhostCache that remembers up to size keys.
+func NewGhost[K comparable](size int) (*GhostCache[K], error) {
+	l, err := lru.NewLRU[K, struct{}](size, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &GhostCache[K]{lru: l}, nil
+}
+
+// Add records a key, or marks it as the most recent one if it is already
+// known. Returns true if the oldest key was forgotten to make room.
+func (c *GhostCache[K]) Add(key K) (evicted bool) {
+	c.lock.Lock()
+	evicted = c.lru.Add(key, struct{}{})
+	c.lock.Unlock()
+	return
+}
+
+// Contains checks if a key is known, without updating its recent-ness.
+func (c *GhostCache[K]) Contains(key K) (ok bool) {
+	c.lock.RLock()
+	ok = c.lru.Contains(key)
+	c.lock.RUnlock()
+	return
+}
+
+// Remove forgets a key, returning true if it was known.
+func (c *GhostCache[K]) Remove(key K) (present bool) {
+	c.lock.Lock()
+	present = c.lru.Remove(key)
+	c.lock.Unlock()
+	return
+}
+
+// Keys returns the known keys, from oldest to newest.
+func (c *GhostCache[K]) Keys() []K {
+	c.lock.RLock()
+	keys := c.lru.Keys()
+	c.lock.RUnlock()
+	return keys
+}
+
+// Len returns the number of known keys.
+func (c *GhostCache[K]) Len() int {
+	c.lock.RLock()
+	length := c.lru.Len()
+	c.lock.RUnlock()
+	return length
+}
+
+// Resize changes the number of keys remembered, returning how many were
+// forgotten.
+func (c *GhostCache[K]) Resize(size int) (evicted int) {
+	c.lock.Lock()
+	evicted = c.lru.Resize(size)
+	c.lock.Unlock()
+	return
+}
+
+// Purge forgets every key.
+func (c *GhostCache[K]) Purge() {
+	c.lock.Lock()
+	c.lru.Purge()
+	c.lock.Unlock()
+}
@@ -0,0 +1,37 @@
+package dailzLRU
+
+import "testing"
+
+func TestGhostCache(t *testing.T) {
+	ghost, err := NewGhost[int](3)
+	if err != nil {
+		t.Fatalf("GhostCache error: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if ghost.Add(i) {
+			t.Fatalf("GhostCache error: unexpected eviction adding %v", i)
+		}
+	}
+	ghost.Add(0)
+	if !ghost.Add(3) {
+		t.Fatalf("GhostCache error: adding past the size should evict")
+	}
+	if ghost.Contains(1) || !ghost.Contains(0) || ghost.Len() != 3 {
+		t.Fatalf("GhostCache error: bad keys: %v", ghost.Keys())
+	}
+
+	if !ghost.Remove(0) || ghost.Remove(0) {
+		t.Fatalf("GhostCache error: bad remove")
+	}
+	if evicted := ghost.Resize(1); evicted != 1 || !ghost.Contains(3) {
+		t.Fatalf("GhostCache error: bad resize, keys: %v", ghost.Keys())
+	}
+	ghost.Purge()
+	if ghost.Len() != 0 {
+		t.Fatalf("GhostCache error: bad len after purge: %v", ghost.Len())
+	}
+
+	if _, err := NewGhost[int](0); err == nil {
+		t.Fatalf("GhostCache error: a zero size should be rejected")
+	}
+}
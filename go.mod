@@ -1,3 +1,3 @@
 module github.com/dailz1/dailzLRU
 
-go 1.19
+go 1.21
@@ -0,0 +1,108 @@
+package dailzLRU
+
+import (
+	"errors"
+	"time"
+)
+
+// gradualResize is a shrink in progress started by ResizeGradually
+type gradualResize struct {
+	stop chan struct{}
+	done chan struct{}
+}
+
+// cancelResize ends the gradual resize in progress, if any; the lock must be
+// held
+func (c *Cache[K, V]) cancelResize() {
+	if c.resizer != nil {
+		close(c.resizer.stop)
+		c.resizer = nil
+	}
+}
+
+// ResizeGradually changes the cache size like Resize, but lowers it by at
+// most step entries every interval, so shrinking a large cache does not
+// evict thousands of entries, and run as many eviction callbacks, at once.
+// The cache holds at most the current intermediate size meanwhile. Growing
+// takes effect immediately.
+//
+// The returned channel is closed once the size is reached, or when a call
+// to Resize or ResizeGradually supersedes this one. Steps are skipped while
+// the cache is frozen.
+func (c *Cache[K, V]) ResizeGradually(size, step int, interval time.Duration) (done <-chan struct{}, err error) {
+	if size <= 0 {
+		return nil, errors.New("must provide a positive size")
+	}
+	if step <= 0 {
+		return nil, errors.New("must provide a positive step")
+	}
+	if interval <= 0 {
+		return nil, errors.New("must provide a positive interval")
+	}
+	t := time.NewTicker(interval)
+	return c.resizeGradually(size, step, t.C, t.Stop), nil
+}
+
+// resizeGradually lowers the size by step each time tick fires, calling
+// stopTick once done
+func (c *Cache[K, V]) resizeGradually(size, step int, tick <-chan time.Time, stopTick func()) <-chan struct{} {
+	r := &gradualResize{stop: make(chan struct{}), done: make(chan struct{})}
+	c.lock.Lock()
+	c.cancelResize()
+	if size >= c.lru.Size() {
+		c.lock.Unlock()
+		stopTick()
+		c.Resize(size)
+		close(r.done)
+		return r.done
+	}
+	c.resizer = r
+	c.lock.Unlock()
+	go c.runResize(r, size, step, tick, stopTick)
+	return r.done
+}
+
+// runResize lowers the size by step each time tick fires until it reaches
+// size or r is cancelled
+func (c *Cache[K, V]) runResize(r *gradualResize, size, step int, tick <-chan time.Time, stopTick func()) {
+	defer close(r.done)
+	defer stopTick()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-tick:
+		}
+		var ks []K
+		var vs []V
+		c.lock.Lock()
+		if c.resizer != r {
+			c.lock.Unlock()
+			return
+		}
+		if c.frozen {
+			c.lock.Unlock()
+			continue
+		}
+		next := c.lru.Size() - step
+		if next < size {
+			next = size
+		}
+		if evicted := c.lru.Resize(next); c.onEvictedCB != nil && evicted > 0 {
+			ks = c.evictedKeys
+			vs = c.evictedVals
+			c.initEvictBuffers()
+		}
+		if next == size {
+			c.resizer = nil
+		}
+		c.lock.Unlock()
+		c.notifyAll(ks, vs)
+		if next == size {
+			if l := c.logger.Load(); l != nil {
+				l.Info("cache resized", "size", size)
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package dailzLRU
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCache_ResizeGradually(t *testing.T) {
+	evicted := make(chan int, 200)
+	c, err := NewWithEvict(100, func(k, v int) { evicted <- k })
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		c.Add(i, i)
+	}
+	if _, err := c.ResizeGradually(10, 0, time.Millisecond); err == nil {
+		t.Fatalf("expected error for zero step")
+	}
+	tick := make(chan time.Time)
+	done := c.resizeGradually(10, 30, tick, func() {})
+	if c.Len() != 100 {
+		t.Fatalf("shrink not gradual: %v entries", c.Len())
+	}
+	// each tick lowers the size by 30, to 70, 40 and 10
+	for want := 70; want >= 10; want -= 30 {
+		tick <- time.Now()
+		for i := 0; i < 30; i++ {
+			select {
+			case <-evicted:
+			case <-time.After(time.Second):
+				t.Fatalf("timed out waiting for evictions down to %v", want)
+			}
+		}
+		if c.Len() != want {
+			t.Fatalf("bad len: %v != %v", c.Len(), want)
+		}
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("resize did not finish")
+	}
+	if k := c.Keys(); len(k) != 10 || k[0] != 90 {
+		t.Fatalf("bad keys: %v", k)
+	}
+
+	// growing is immediate and a Resize supersedes a shrink in progress
+	done, err = c.ResizeGradually(50, 1, time.Hour)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	<-done
+	for i := 0; i < 50; i++ {
+		c.Add(i, i)
+	}
+	if c.Len() != 50 {
+		t.Fatalf("bad len: %v", c.Len())
+	}
+	done, _ = c.ResizeGradually(1, 1, time.Hour)
+	c.Resize(40)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("resize not cancelled")
+	}
+}
@@ -0,0 +1,244 @@
+// Package httpcache caches the responses of HTTP handlers in memory, in a
+// cache bounded by the total size of the responses it holds.
+package httpcache
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dailz1/dailzLRU"
+)
+
+// Options configures the caching of a handler's responses.
+type Options struct {
+	// TTL is how long a response is served from the cache. It must be
+	// positive.
+	TTL time.Duration
+	// MaxEntrySize is the largest response body that is cached. Zero means
+	// any response that fits in the cache.
+	MaxEntrySize int64
+	// Vary lists the request headers whose values select between cached
+	// responses, in addition to the method and the request URI.
+	Vary []string
+}
+
+// entry is a cached response
+type entry struct {
+	status  int
+	header  http.Header
+	body    []byte
+	expires time.Time
+}
+
+// Cache holds the responses of the handlers it wraps. Responses are weighed
+// by size and evicted with GreedyDual-Size-Frequency, so small and often
+// requested responses are kept over large and rarely requested ones.
+type Cache struct {
+	store *dailzLRU.GDSFCache[string, *entry]
+}
+
+// New constructs a Cache holding responses with a total size of up to
+// capacity bytes.
+func New(capacity int64) (*Cache, error) {
+	store, err := dailzLRU.NewGDSF[string, *entry](capacity, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Cache{store: store}, nil
+}
+
+// Handler returns a handler serving the GET and HEAD responses of h from the
+// cache. Only the handlers wrapped this way are cached, so caching is opted
+// into route by route. Responses are not cached if their status is not
+// cacheable by default, if they set cookies, if their Cache-Control
+// forbids storing them in a shared cache, or if they vary on a request
+// header not listed in opts.Vary. As required of shared caches, responses
+// to requests with an Authorization header are only cached if their
+// Cache-Control explicitly allows it with public, s-maxage or
+// must-revalidate. Responses of different handlers must not share request
+// URIs, as they share the cache.
+func (c *Cache) Handler(h http.Handler, opts Options) (http.Handler, error) {
+	if opts.TTL <= 0 {
+		return nil, errors.New("must provide a positive ttl")
+	}
+	vary := make([]string, len(opts.Vary))
+	for i, name := range opts.Vary {
+		vary[i] = http.CanonicalHeaderKey(name)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			h.ServeHTTP(w, r)
+			return
+		}
+		key := cacheKey(r, vary)
+		if e, ok := c.store.Get(key); ok {
+			if time.Now().Before(e.expires) {
+				serve(w, r, e)
+				return
+			}
+			c.store.Remove(key)
+		}
+		rec := &recorder{ResponseWriter: w, max: opts.MaxEntrySize}
+		h.ServeHTTP(rec, r)
+		if !rec.cacheable(r, vary) {
+			return
+		}
+		e := &entry{
+			status:  rec.status,
+			header:  w.Header().Clone(),
+			body:    rec.body.Bytes(),
+			expires: time.Now().Add(opts.TTL),
+		}
+		c.store.Add(key, e, e.size(key), 1)
+	}), nil
+}
+
+// Purge drops every cached response.
+func (c *Cache) Purge() {
+	c.store.Purge()
+}
+
+// Len returns the number of cached responses.
+func (c *Cache) Len() int {
+	return c.store.Len()
+}
+
+// cacheKey returns the key of the response to r
+func cacheKey(r *http.Request, vary []string) string {
+	var b strings.Builder
+	b.WriteString(r.Method)
+	b.WriteByte(' ')
+	b.WriteString(r.URL.RequestURI())
+	for _, name := range vary {
+		b.WriteByte(0)
+		b.WriteString(strings.Join(r.Header.Values(name), ","))
+	}
+	return b.String()
+}
+
+// size returns the number of bytes e weighs under key
+func (e *entry) size(key string) int64 {
+	n := int64(len(key) + len(e.body))
+	for name, values := range e.header {
+		n += int64(len(name))
+		for _, v := range values {
+			n += int64(len(v))
+		}
+	}
+	return n
+}
+
+// serve writes the cached response e
+func serve(w http.ResponseWriter, r *http.Request, e *entry) {
+	header := w.Header()
+	for name, values := range e.header {
+		header[name] = append([]string(nil), values...)
+	}
+	w.WriteHeader(e.status)
+	if r.Method != http.MethodHead {
+		w.Write(e.body)
+	}
+}
+
+// cacheableStatus holds the status codes cacheable by default
+var cacheableStatus = map[int]bool{
+	http.StatusOK:                   true,
+	http.StatusNonAuthoritativeInfo: true,
+	http.StatusNoContent:            true,
+	http.StatusMultipleChoices:      true,
+	http.StatusMovedPermanently:     true,
+	http.StatusPermanentRedirect:    true,
+	http.StatusNotFound:             true,
+	http.StatusMethodNotAllowed:     true,
+	http.StatusGone:                 true,
+	http.StatusRequestURITooLong:    true,
+	http.StatusNotImplemented:       true,
+}
+
+// recorder passes a response through while keeping a copy of it
+type recorder struct {
+	http.ResponseWriter
+	status   int
+	body     bytes.Buffer
+	max      int64
+	tooLarge bool
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	if !r.tooLarge {
+		if r.max > 0 && int64(r.body.Len()+len(p)) > r.max {
+			r.tooLarge = true
+			r.body = bytes.Buffer{}
+		} else {
+			r.body.Write(p)
+		}
+	}
+	return r.ResponseWriter.Write(p)
+}
+
+// cacheable reports whether the recorded response to req may be cached
+// under a key including the request headers vary
+func (r *recorder) cacheable(req *http.Request, vary []string) bool {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	if r.tooLarge || !cacheableStatus[r.status] {
+		return false
+	}
+	header := r.Header()
+	if len(header.Values("Set-Cookie")) > 0 {
+		return false
+	}
+	shared := false
+	for _, v := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(v, ",") {
+			directive = strings.ToLower(strings.TrimSpace(directive))
+			name, _, _ := strings.Cut(directive, "=")
+			switch name {
+			case "no-store", "private", "no-cache":
+				return false
+			case "public", "s-maxage", "must-revalidate":
+				shared = true
+			}
+		}
+	}
+	// RFC 9111 section 3.5
+	if req.Header.Get("Authorization") != "" && !shared {
+		return false
+	}
+	for _, v := range header.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			if name == "*" || !contains(vary, name) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// contains reports whether names holds name
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+package httpcache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// get requests target from h with the given headers
+func get(h http.Handler, target string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandler(t *testing.T) {
+	c, err := New(1 << 20)
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	calls := 0
+	origin := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "text/plain")
+		switch r.URL.Path {
+		case "/big":
+			w.Write([]byte(strings.Repeat("x", 100)))
+		case "/private":
+			w.Header().Set("Cache-Control", "private, max-age=60")
+			w.Write([]byte("mine"))
+		case "/error":
+			http.Error(w, "oops", http.StatusInternalServerError)
+		default:
+			w.Write([]byte(r.URL.Path + " " + r.Header.Get("Accept-Language") + " " + strconv.Itoa(calls)))
+		}
+	})
+	h, err := c.Handler(origin, Options{TTL: time.Minute, MaxEntrySize: 50, Vary: []string{"accept-language"}})
+	if err != nil {
+		t.Fatalf("Handler error: %v", err)
+	}
+
+	first := get(h, "/a", "Accept-Language", "en")
+	second := get(h, "/a", "Accept-Language", "en")
+	if calls != 1 || second.Body.String() != "/a en 1" || second.Header().Get("Content-Type") != "text/plain" {
+		t.Fatalf("response not served from the cache: %d calls, %q, %v", calls, second.Body.String(), second.Header())
+	}
+	if first.Body.String() != second.Body.String() {
+		t.Fatalf("cached response differs: %q != %q", first.Body.String(), second.Body.String())
+	}
+	if rec := get(h, "/a", "Accept-Language", "fr"); rec.Body.String() != "/a fr 2" {
+		t.Fatalf("vary header ignored: %q", rec.Body.String())
+	}
+	if rec := get(h, "/a?page=2", "Accept-Language", "en"); rec.Body.String() != "/a en 3" {
+		t.Fatalf("query ignored: %q", rec.Body.String())
+	}
+
+	// not cached: too large, private, errors and other methods
+	for _, path := range []string{"/big", "/private", "/error"} {
+		before := calls
+		get(h, path)
+		rec := get(h, path)
+		if calls != before+2 {
+			t.Fatalf("%s should not be cached", path)
+		}
+		if path == "/big" && rec.Body.Len() != 100 {
+			t.Fatalf("large response truncated: %d bytes", rec.Body.Len())
+		}
+	}
+	before := calls
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/a", nil))
+	if calls != before+1 || c.Len() != 3 {
+		t.Fatalf("POST should pass through uncached: %d calls, %d cached", calls-before, c.Len())
+	}
+
+	// expired responses are fetched again
+	expiring, err := c.Handler(origin, Options{TTL: time.Nanosecond})
+	if err != nil {
+		t.Fatalf("Handler error: %v", err)
+	}
+	get(expiring, "/b")
+	time.Sleep(time.Millisecond)
+	if rec := get(expiring, "/b"); calls != before+3 || !strings.HasSuffix(rec.Body.String(), strconv.Itoa(calls)) {
+		t.Fatalf("expired response served: %q", rec.Body.String())
+	}
+
+	c.Purge()
+	if c.Len() != 0 {
+		t.Fatalf("bad len after purge: %v", c.Len())
+	}
+	if _, err := c.Handler(origin, Options{}); err == nil {
+		t.Fatalf("should reject a zero ttl")
+	}
+}
+
+func TestHandler_Shared(t *testing.T) {
+	c, err := New(1 << 20)
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	calls := 0
+	origin := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch r.URL.Path {
+		case "/public":
+			w.Header().Set("Cache-Control", "public, max-age=60")
+		case "/shared":
+			w.Header().Set("Cache-Control", "s-maxage=60")
+		case "/encoding":
+			w.Header().Set("Vary", "Accept-Encoding")
+		case "/language":
+			w.Header().Set("Vary", "accept-language")
+		case "/star":
+			w.Header().Set("Vary", "*")
+		}
+		w.Write([]byte(r.URL.Path))
+	})
+	h, err := c.Handler(origin, Options{TTL: time.Minute, Vary: []string{"Accept-Language"}})
+	if err != nil {
+		t.Fatalf("Handler error: %v", err)
+	}
+
+	for _, tc := range []struct {
+		path   string
+		header []string
+		cached bool
+	}{
+		{"/plain", []string{"Authorization", "Bearer x"}, false},
+		{"/public", []string{"Authorization", "Bearer x"}, true},
+		{"/shared", []string{"Authorization", "Bearer x"}, true},
+		{"/encoding", nil, false},
+		{"/language", nil, true},
+		{"/star", nil, false},
+	} {
+		before := calls
+		get(h, tc.path, tc.header...)
+		get(h, tc.path, tc.header...)
+		if cached := calls == before+1; cached != tc.cached {
+			t.Fatalf("%s %v: cached %v, want %v", tc.path, tc.header, cached, tc.cached)
+		}
+	}
+}
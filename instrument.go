@@ -0,0 +1,63 @@
+package dailzLRU
+
+import "time"
+
+// Observer records a single observation. A Prometheus histogram or summary
+// satisfies it directly; other instruments, such as an OpenTelemetry
+// Float64Histogram, can be adapted with ObserverFunc.
+type Observer interface {
+	Observe(value float64)
+}
+
+// ObserverFunc adapts a function to the Observer interface.
+type ObserverFunc func(value float64)
+
+func (f ObserverFunc) Observe(value float64) {
+	f(value)
+}
+
+// Latencies holds the observers the Instrument middleware records the
+// latency of each operation into, in seconds. Operations whose observer is
+// nil are not timed.
+type Latencies struct {
+	Get    Observer
+	Add    Observer
+	Remove Observer
+}
+
+// Instrument returns a middleware that records the latency of Get, Add and
+// Remove into the observers of l. The latency includes the time spent
+// waiting for the cache's lock, so contention shows up in the recorded
+// distribution.
+func Instrument[K comparable, V any](l Latencies) Middleware[K, V] {
+	return func(next Interface[K, V]) Interface[K, V] {
+		return instrumented[K, V]{Wrapper: Wrapper[K, V]{Next: next}, latencies: l}
+	}
+}
+
+type instrumented[K comparable, V any] struct {
+	Wrapper[K, V]
+	latencies Latencies
+}
+
+// observeSince records the time elapsed since start into o, if it is not nil
+func observeSince(o Observer, start time.Time) {
+	if o != nil {
+		o.Observe(time.Since(start).Seconds())
+	}
+}
+
+func (c instrumented[K, V]) Get(key K) (value V, ok bool) {
+	defer observeSince(c.latencies.Get, time.Now())
+	return c.Next.Get(key)
+}
+
+func (c instrumented[K, V]) Add(key K, value V) (evicted bool) {
+	defer observeSince(c.latencies.Add, time.Now())
+	return c.Next.Add(key, value)
+}
+
+func (c instrumented[K, V]) Remove(key K) (present bool) {
+	defer observeSince(c.latencies.Remove, time.Now())
+	return c.Next.Remove(key)
+}
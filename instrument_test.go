@@ -0,0 +1,28 @@
+package dailzLRU
+
+import "testing"
+
+func TestInstrument(t *testing.T) {
+	base, err := New[int, int](8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var gets, adds []float64
+	c := Chain[int, int](base, Instrument[int, int](Latencies{
+		Get: ObserverFunc(func(v float64) { gets = append(gets, v) }),
+		Add: ObserverFunc(func(v float64) { adds = append(adds, v) }),
+	}))
+
+	c.Add(1, 1)
+	c.Get(1)
+	c.Get(2)
+	c.Remove(1)
+	if len(gets) != 2 || len(adds) != 1 {
+		t.Fatalf("bad observations: gets %v, adds %v", gets, adds)
+	}
+	for _, v := range append(gets, adds...) {
+		if v < 0 || v > 1 {
+			t.Fatalf("implausible latency: %v", v)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package dailzLRU
+
+import "log/slog"
+
+// SetLogger makes the cache log resizes and purges at info level, the work
+// of the EvictInBackground evictor at debug level, and panics raised by the
+// eviction callback at error level, to l. The panic is logged and then
+// propagated as before. A nil logger turns logging off, which is the
+// default.
+func (c *Cache[K, V]) SetLogger(l *slog.Logger) {
+	c.logger.Store(l)
+}
+
+// notifyEvicted invokes the eviction callback, logging it if it panics
+func (c *Cache[K, V]) notifyEvicted(k K, v V) {
+	if l := c.logger.Load(); l != nil {
+		defer func() {
+			if r := recover(); r != nil {
+				l.Error("eviction callback panicked", "key", k, "panic", r)
+				panic(r)
+			}
+		}()
+	}
+	c.onEvictedCB(k, v)
+}
+
+// SetLogger makes the snapshotter log every snapshot at debug level and
+// failed snapshots at error level to l. A nil logger turns logging off,
+// which is the default.
+func (s *Snapshotter) SetLogger(l *slog.Logger) {
+	s.logger.Store(l)
+}
+
+// SetLogger makes the cache log each sweep of expired entries at debug
+// level to l. A nil logger turns logging off, which is the default.
+func (c *ExpirableCache[K, V]) SetLogger(l *slog.Logger) {
+	c.logger.Store(l)
+}
+
+// SetLogger makes the map log each sweep of expired entries at debug level
+// to l. A nil logger turns logging off, which is the default.
+func (m *TTLMap[K, V]) SetLogger(l *slog.Logger) {
+	m.logger.Store(l)
+}
@@ -0,0 +1,104 @@
+package dailzLRU
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCache_SetLogger(t *testing.T) {
+	var buf bytes.Buffer
+	panicKey := -1
+	l, err := NewWithEvict[int, int](4, func(k, v int) {
+		if k == panicKey {
+			panic("boom")
+		}
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.SetLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+	for i := 0; i < 8; i++ {
+		l.Add(i, i)
+	}
+	l.Resize(2)
+	l.Purge()
+
+	out := buf.String()
+	if !strings.Contains(out, `msg="cache resized" size=2 evicted=2`) {
+		t.Fatalf("missing resize event: %s", out)
+	}
+	if !strings.Contains(out, `msg="cache purged" entries=2`) {
+		t.Fatalf("missing purge event: %s", out)
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Fatalf("callback panic not propagated: %v", r)
+			}
+		}()
+		panicKey = 3
+		l.Add(3, 3)
+		l.Remove(3)
+	}()
+	if !strings.Contains(buf.String(), `msg="eviction callback panicked" key=3 panic=boom`) {
+		t.Fatalf("missing panic event: %s", buf.String())
+	}
+}
+
+func TestCache_SetLoggerEvictor(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := New[int, int](2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.SetLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	stop, err := l.EvictInBackground(8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		l.Add(i, i)
+	}
+	deadline := time.Now().Add(time.Second)
+	for l.Len() > 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("background eviction did not run: len %d", l.Len())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	stop()
+	if !strings.Contains(buf.String(), `msg="evicted in background" evicted=`) {
+		t.Fatalf("missing background eviction event: %s", buf.String())
+	}
+}
+
+func TestExpirable_SetLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	l, err := NewExpirable[int, int](8, 20*time.Millisecond, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Close()
+	m, err := NewTTLMap[int, int](20*time.Millisecond, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	m.Close()
+	l.SetLogger(logger)
+	m.SetLogger(logger)
+	l.Add(1, 1)
+	l.Add(2, 2)
+	m.Set(1, 1)
+	time.Sleep(30 * time.Millisecond)
+	l.EvictExpired()
+	m.EvictExpired()
+	if got := strings.Count(buf.String(), `msg="expired entries swept"`); got != 2 ||
+		!strings.Contains(buf.String(), "removed=2") || !strings.Contains(buf.String(), "removed=1") {
+		t.Fatalf("missing sweep events: %s", buf.String())
+	}
+}
@@ -1,21 +1,45 @@
 package dailzLRU
 
 import (
-	"dailzLRU/lru"
+	"errors"
+	"github.com/dailz1/dailzLRU/lru"
+	"log/slog"
 	"sync"
+	"sync/atomic"
 )
 
 const (
 	// DefaultEvictedBufferSize defines the default buffer size to store evicted key/val
 	DefaultEvictedBufferSize = 16
+
+	// ResizeChunkSize is the most entries Resize evicts per acquisition of
+	// the lock
+	ResizeChunkSize = 1024
 )
 
+// ErrFrozen is returned by operations that cannot be applied to a frozen cache.
+var ErrFrozen = errors.New("cache is frozen")
+
 // Cache is a thread-safe fixed size LRU cache.
 type Cache[K comparable, V any] struct {
 	lru         *lru.LRU[K, V]
 	evictedKeys []K
 	evictedVals []V
 	onEvictedCB func(k K, v V)
+	victim      Interface[K, V]
+	admit       func(k K, v V) bool
+	frozen      bool
+	resumeThaw  bool // ResumeEviction was called while frozen
+	logger      atomic.Pointer[slog.Logger]
+	watchers    map[K][]*watcher[K, V]
+	deps        map[K][]K            // keys an entry depends on
+	dependents  map[K]map[K]struct{} // entries depending on a key
+	evictor     *evictor
+	resizer     *gradualResize
+	ages        *ageTracker[K]
+	bloom       atomic.Pointer[bloomFilter[K]]
+	uses        map[K]int // remaining uses of entries added by AddWithMaxUses
+	views       *viewTracker[K, V]
 	lock        sync.RWMutex
 }
 
@@ -24,14 +48,68 @@ func New[K comparable, V any](size int) (*Cache[K, V], error) {
 }
 
 func NewWithEvict[K comparable, V any](size int, onEvicted func(key K, value V)) (c *Cache[K, V], err error) {
+	return newWithPolicy(size, onEvicted, lru.NewLRU[K, V])
+}
+
+// NewMRU constructs a cache that evicts its most recently used entry to make
+// room for a new one. See lru.NewMRU.
+func NewMRU[K comparable, V any](size int) (*Cache[K, V], error) {
+	return NewMRUWithEvict[K, V](size, nil)
+}
+
+// NewMRUWithEvict constructs an MRU cache with an eviction callback.
+func NewMRUWithEvict[K comparable, V any](size int, onEvicted func(key K, value V)) (c *Cache[K, V], err error) {
+	return newWithPolicy(size, onEvicted, lru.NewMRU[K, V])
+}
+
+// NewFIFO constructs a cache that evicts entries in insertion order and does
+// not reorder them on lookups. Since lookups do not modify the cache, Get
+// only takes a read lock. See lru.NewFIFO.
+func NewFIFO[K comparable, V any](size int) (*Cache[K, V], error) {
+	return NewFIFOWithEvict[K, V](size, nil)
+}
+
+// NewFIFOWithEvict constructs a FIFO cache with an eviction callback.
+func NewFIFOWithEvict[K comparable, V any](size int, onEvicted func(key K, value V)) (c *Cache[K, V], err error) {
+	return newWithPolicy(size, onEvicted, lru.NewFIFO[K, V])
+}
+
+// newWithPolicy constructs a cache around the lru.LRU built by newLRU
+func newWithPolicy[K comparable, V any](size int, onEvicted func(key K, value V), newLRU func(int, lru.EvictCallback[K, V]) (*lru.LRU[K, V], error)) (c *Cache[K, V], err error) {
+	c = &Cache[K, V]{}
+	err = c.init(size, onEvicted, newLRU)
+	return
+}
+
+// init sets up the zero cache c in place around the lru.LRU built by newLRU
+func (c *Cache[K, V]) init(size int, onEvicted func(key K, value V), newLRU func(int, lru.EvictCallback[K, V]) (*lru.LRU[K, V], error)) (err error) {
+	c.onEvictedCB = onEvicted
+	if onEvicted != nil {
+		c.initEvictBuffers()
+	}
+	c.lru, err = newLRU(size, c.onEvicted)
+	return err
+}
+
+// NewWithVictim constructs a cache that hands entries evicted for space over
+// to victim, a second cache that gives them another chance to be reused.
+// Entries leaving through Remove, RemoveOldest or Purge are not handed over.
+// If admit is not nil, an entry is only handed over when admit returns true.
+// The handoff and admit run while the cache's lock is held, so victim must
+// not hand entries back to this cache, directly or through a chain.
+func NewWithVictim[K comparable, V any](size int, onEvicted func(key K, value V), victim Interface[K, V], admit func(key K, value V) bool) (c *Cache[K, V], err error) {
+	if victim == nil {
+		return nil, errors.New("must provide a victim cache")
+	}
 	c = &Cache[K, V]{
 		onEvictedCB: onEvicted,
+		victim:      victim,
+		admit:       admit,
 	}
 	if onEvicted != nil {
 		c.initEvictBuffers()
-		onEvicted = c.onEvicted
 	}
-	c.lru, err = lru.NewLRU(size, onEvicted)
+	c.lru, err = lru.NewLRU(size, c.onEvicted)
 	return
 }
 
@@ -40,17 +118,133 @@ func (c *Cache[K, V]) initEvictBuffers() {
 	c.evictedVals = make([]V, 0, DefaultEvictedBufferSize)
 }
 
+// takeEvicted empties the eviction buffers after an operation that removed
+// at least one entry. The first eviction is returned by value, so the common
+// case of a single eviction does not allocate; further ones, caused by
+// removals cascading to dependents, are handed off as slices.
+func (c *Cache[K, V]) takeEvicted() (k K, v V, ks []K, vs []V) {
+	k, v = c.evictedKeys[0], c.evictedVals[0]
+	if len(c.evictedKeys) > 1 {
+		ks, vs = c.evictedKeys[1:], c.evictedVals[1:]
+		c.initEvictBuffers()
+		return
+	}
+	c.evictedKeys = c.evictedKeys[:0]
+	c.evictedVals = c.evictedVals[:0]
+	return
+}
+
+// notifyAll invokes the eviction callback for each of the given entries
+func (c *Cache[K, V]) notifyAll(ks []K, vs []V) {
+	for i := 0; i < len(ks); i++ {
+		c.notifyEvicted(ks[i], vs[i])
+	}
+}
+
 // onEvicted save evicted key/val and sent in externally registered callback
 // outside of critical section
 func (c *Cache[K, V]) onEvicted(k K, v V) {
-	c.evictedKeys = append(c.evictedKeys, k)
-	c.evictedVals = append(c.evictedVals, v)
+	if c.victim != nil && c.lru.Evicting() && (c.admit == nil || c.admit(k, v)) {
+		c.victim.Add(k, v)
+	}
+	if len(c.watchers) > 0 {
+		kind := EventRemove
+		if c.lru.Evicting() {
+			kind = EventEvict
+		}
+		c.emit(kind, k, v)
+	}
+	if c.ages != nil {
+		c.ages.remove(k, c.lru.Evicting())
+	}
+	if c.onEvictedCB != nil {
+		c.evictedKeys = append(c.evictedKeys, k)
+		c.evictedVals = append(c.evictedVals, v)
+	}
+	if len(c.deps) > 0 || len(c.dependents) > 0 {
+		c.dropDeps(k)
+	}
+	if f := c.bloom.Load(); f != nil {
+		f.remove(k)
+	}
+	if c.uses != nil {
+		delete(c.uses, k)
+	}
+	if c.views != nil {
+		c.views.remove(k)
+	}
+}
+
+// beforeAdd is called with the lock held before key is added with value
+func (c *Cache[K, V]) beforeAdd(key K, value V) {
+	c.emitAdd(key, value)
+	if c.ages != nil {
+		c.ages.add(key)
+	}
+	if c.views != nil {
+		c.views.add(key, value, c.lru.Promotes())
+	}
+	if f := c.bloom.Load(); f != nil && !c.lru.Contains(key) {
+		f.add(key)
+	}
+	if c.evictor != nil && c.lru.Len() >= c.lru.Size() {
+		c.evictor.signal()
+	}
 }
 
 func (c *Cache[K, V]) Get(key K) (value V, ok bool) {
+	if !c.mayContain(key) {
+		return
+	}
+	if !c.lru.Promotes() {
+		c.lock.RLock()
+		if c.ages == nil && c.uses == nil {
+			value, ok = c.lru.Get(key)
+			c.lock.RUnlock()
+			return
+		}
+		c.lock.RUnlock()
+	}
+	var k K
+	var v V
+	var ks []K
+	var vs []V
+	removed := false
 	c.lock.Lock()
+	if c.uses != nil && c.exhausted(key) {
+		c.lock.Unlock()
+		return
+	}
 	value, ok = c.lru.Get(key)
+	if ok && c.ages != nil {
+		c.ages.access(key)
+	}
+	if ok && c.views != nil && c.lru.Promotes() {
+		c.views.touch(key, value)
+	}
+	if ok && c.uses != nil {
+		removed = c.use(key)
+		if c.onEvictedCB != nil && removed {
+			k, v, ks, vs = c.takeEvicted()
+		}
+	}
 	c.lock.Unlock()
+	if c.onEvictedCB != nil && removed {
+		c.notifyEvicted(k, v)
+		c.notifyAll(ks, vs)
+	}
+	return
+}
+
+// Peek returns the key value (or undefined if not found) without updating
+// the "recently used"-ness of the key.
+func (c *Cache[K, V]) Peek(key K) (value V, ok bool) {
+	if !c.mayContain(key) {
+		return
+	}
+	c.lock.RLock()
+	value, ok = c.lru.Peek(key)
+	c.lock.RUnlock()
 	return
 }
 
@@ -58,46 +252,86 @@ func (c *Cache[K, V]) Get(key K) (value V, ok bool) {
 func (c *Cache[K, V]) Add(key K, value V) (evicted bool) {
 	var k K
 	var v V
+	var ks []K
+	var vs []V
 	c.lock.Lock()
+	if c.frozen {
+		c.lock.Unlock()
+		return false
+	}
+	c.beforeAdd(key, value)
 	evicted = c.lru.Add(key, value)
 	if c.onEvictedCB != nil && evicted {
-		k = c.evictedKeys[0]
-		v = c.evictedVals[0]
-		c.evictedKeys = c.evictedKeys[:0]
-		c.evictedVals = c.evictedVals[:0]
+		k, v, ks, vs = c.takeEvicted()
 	}
 	c.lock.Unlock()
 	if c.onEvictedCB != nil && evicted {
-		c.onEvictedCB(k, v)
+		c.notifyEvicted(k, v)
+		c.notifyAll(ks, vs)
 	}
 	return
 }
 
 func (c *Cache[K, V]) Contains(key K) (containKey bool) {
+	if !c.mayContain(key) {
+		return false
+	}
 	c.lock.RLock()
 	containKey = c.lru.Contains(key)
 	c.lock.RUnlock()
 	return
 }
 
+// ContainsAll checks if every given key is in the cache, without updating
+// their recent-ness. All keys are checked under a single read lock.
+func (c *Cache[K, V]) ContainsAll(keys ...K) bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	for _, key := range keys {
+		if !c.lru.Contains(key) {
+			return false
+		}
+	}
+	return true
+}
+
+// ContainsAny checks if at least one of the given keys is in the cache,
+// without updating their recent-ness. All keys are checked under a single
+// read lock.
+func (c *Cache[K, V]) ContainsAny(keys ...K) bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	for _, key := range keys {
+		if c.lru.Contains(key) {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Cache[K, V]) ContainsOrAdd(key K, value V) (ok, evicted bool) {
 	var k K
 	var v V
+	var ks []K
+	var vs []V
 	c.lock.Lock()
 	if c.lru.Contains(key) {
 		c.lock.Unlock()
 		return true, false
 	}
+	if c.frozen {
+		c.lock.Unlock()
+		return false, false
+	}
+	c.beforeAdd(key, value)
 	evicted = c.lru.Add(key, value)
 	if c.onEvictedCB != nil && evicted {
-		k = c.evictedKeys[0]
-		v = c.evictedVals[0]
-		c.evictedKeys = c.evictedKeys[:0]
-		c.evictedVals = c.evictedVals[:0]
+		k, v, ks, vs = c.takeEvicted()
 	}
 	c.lock.Unlock()
 	if c.onEvictedCB != nil && evicted {
-		c.onEvictedCB(k, v)
+		c.notifyEvicted(k, v)
+		c.notifyAll(ks, vs)
 	}
 	return false, evicted
 }
@@ -105,22 +339,23 @@ func (c *Cache[K, V]) ContainsOrAdd(key K, value V) (ok, evicted bool) {
 func (c *Cache[K, V]) PeekOrAdd(key K, value V) (previous V, ok, evicted bool) {
 	var k K
 	var v V
+	var ks []K
+	var vs []V
 	c.lock.Lock()
 	previous, ok = c.lru.Peek(key)
-	if ok {
+	if ok || c.frozen {
 		c.lock.Unlock()
-		return previous, true, false
+		return previous, ok, false
 	}
+	c.beforeAdd(key, value)
 	evicted = c.lru.Add(key, value)
 	if c.onEvictedCB != nil && evicted {
-		k = c.evictedKeys[0]
-		v = c.evictedVals[0]
-		c.evictedKeys = c.evictedKeys[:0]
-		c.evictedVals = c.evictedVals[:0]
+		k, v, ks, vs = c.takeEvicted()
 	}
 	c.lock.Unlock()
 	if c.onEvictedCB != nil && evicted {
-		c.onEvictedCB(k, v)
+		c.notifyEvicted(k, v)
+		c.notifyAll(ks, vs)
 	}
 	return
 }
@@ -128,58 +363,175 @@ func (c *Cache[K, V]) PeekOrAdd(key K, value V) (previous V, ok, evicted bool) {
 func (c *Cache[K, V]) Remove(key K) (present bool) {
 	var k K
 	var v V
+	var ks []K
+	var vs []V
 	c.lock.Lock()
+	if c.frozen {
+		c.lock.Unlock()
+		return false
+	}
 	present = c.lru.Remove(key)
 	if c.onEvictedCB != nil && present {
-		k = c.evictedKeys[0]
-		v = c.evictedVals[0]
-		c.evictedKeys = c.evictedKeys[:0]
-		c.evictedVals = c.evictedVals[:0]
+		k, v, ks, vs = c.takeEvicted()
 	}
 	c.lock.Unlock()
 	if c.onEvictedCB != nil && present {
-		c.onEvictedCB(k, v)
+		c.notifyEvicted(k, v)
+		c.notifyAll(ks, vs)
 	}
 	return
 }
 
-func (c *Cache[K, V]) Resize(size int) (evicted int) {
+// Pop atomically looks up a key's value and removes it from the cache,
+// invoking the eviction callback for it. Of concurrent Pop calls for the
+// same key, only one gets the value.
+func (c *Cache[K, V]) Pop(key K) (value V, ok bool) {
 	var ks []K
 	var vs []V
 	c.lock.Lock()
-	evicted = c.lru.Resize(size)
-	if c.onEvictedCB != nil && evicted > 0 {
-		ks = c.evictedKeys
-		vs = c.evictedVals
-		c.initEvictBuffers()
+	if c.frozen {
+		c.lock.Unlock()
+		return value, false
 	}
-	c.lock.Unlock()
-	if c.onEvictedCB != nil && evicted > 0 {
-		for i := 0; i < len(ks); i++ {
-			c.onEvictedCB(ks[i], vs[i])
+	if value, ok = c.lru.Peek(key); ok {
+		c.lru.Remove(key)
+		if c.onEvictedCB != nil {
+			_, _, ks, vs = c.takeEvicted()
 		}
 	}
+	c.lock.Unlock()
+	if c.onEvictedCB != nil && ok {
+		c.notifyEvicted(key, value)
+		c.notifyAll(ks, vs)
+	}
+	return value, ok
+}
+
+// Resize changes the cache size, returning the number of entries evicted.
+// Shrinking by more than ResizeChunkSize entries evicts in chunks, so other
+// callers may observe and modify the cache while it shrinks. Any
+// ResizeGradually in progress is cancelled.
+func (c *Cache[K, V]) Resize(size int) (evicted int) {
+	c.lock.Lock()
+	if c.frozen {
+		c.lock.Unlock()
+		return 0
+	}
+	c.cancelResize()
+	if f := c.bloom.Load(); f != nil && size > f.capacity {
+		c.rebuildBloomFilter(size)
+	}
+	c.lock.Unlock()
+	evicted, ok := c.resize(size)
+	if !ok {
+		return evicted
+	}
+	if l := c.logger.Load(); l != nil {
+		l.Info("cache resized", "size", size, "evicted", evicted)
+	}
 	return evicted
 }
 
+// resize shrinks the cache towards size in steps of at most ResizeChunkSize
+// evictions, releasing the lock and running the eviction callbacks between
+// steps so a large shrink does not stall other callers. Returns false if the
+// cache was frozen before the size was reached.
+func (c *Cache[K, V]) resize(size int) (evicted int, ok bool) {
+	for {
+		var ks []K
+		var vs []V
+		c.lock.Lock()
+		if c.frozen {
+			c.lock.Unlock()
+			return evicted, false
+		}
+		step := size
+		if c.lru.Len()-size > ResizeChunkSize {
+			step = c.lru.Len() - ResizeChunkSize
+		}
+		n := c.lru.Resize(step)
+		if c.onEvictedCB != nil && n > 0 {
+			ks = c.evictedKeys
+			vs = c.evictedVals
+			c.initEvictBuffers()
+		}
+		c.lock.Unlock()
+		c.notifyAll(ks, vs)
+		evicted += n
+		if step == size {
+			return evicted, true
+		}
+	}
+}
+
 func (c *Cache[K, V]) RemoveOldest() (key K, value V, ok bool) {
 	var k K
 	var v V
+	var ks []K
+	var vs []V
 	c.lock.Lock()
+	if c.frozen {
+		c.lock.Unlock()
+		return
+	}
 	key, value, ok = c.lru.RemoveOldest()
 	if c.onEvictedCB != nil && ok {
-		k = c.evictedKeys[0]
-		v = c.evictedVals[0]
-		c.evictedKeys = c.evictedKeys[:0]
-		c.evictedVals = c.evictedVals[:0]
+		k, v, ks, vs = c.takeEvicted()
 	}
 	c.lock.Unlock()
 	if c.onEvictedCB != nil && ok {
-		c.onEvictedCB(k, v)
+		c.notifyEvicted(k, v)
+		c.notifyAll(ks, vs)
 	}
 	return
 }
 
+// Take atomically looks up a key's value and removes it from the cache
+// without invoking the eviction callback for it, handing the entry over to
+// the caller instead. Entries removed in turn because they depend on it
+// are still reported.
+func (c *Cache[K, V]) Take(key K) (value V, ok bool) {
+	var ks []K
+	var vs []V
+	c.lock.Lock()
+	if c.frozen {
+		c.lock.Unlock()
+		return value, false
+	}
+	if value, ok = c.lru.Peek(key); ok {
+		c.lru.Remove(key)
+		if c.onEvictedCB != nil {
+			_, _, ks, vs = c.takeEvicted()
+		}
+	}
+	c.lock.Unlock()
+	c.notifyAll(ks, vs)
+	return value, ok
+}
+
+// TakeOldestIf removes and returns the oldest entry for which fn returns
+// true, invoking the eviction callback for it. fn is called with the cache's
+// lock held, so it must not use the cache.
+func (c *Cache[K, V]) TakeOldestIf(fn func(key K, value V) bool) (key K, value V, ok bool) {
+	var ks []K
+	var vs []V
+	c.lock.Lock()
+	if c.frozen {
+		c.lock.Unlock()
+		return key, value, false
+	}
+	key, value, ok = c.lru.TakeOldestIf(fn)
+	if c.onEvictedCB != nil && ok {
+		_, _, ks, vs = c.takeEvicted()
+	}
+	c.lock.Unlock()
+	if c.onEvictedCB != nil && ok {
+		c.notifyEvicted(key, value)
+		c.notifyAll(ks, vs)
+	}
+	return key, value, ok
+}
+
 func (c *Cache[K, V]) GetOldest() (key K, value V, ok bool) {
 	c.lock.RLock()
 	key, value, ok = c.lru.GetOldest()
@@ -194,6 +546,33 @@ func (c *Cache[K, V]) Keys() []K {
 	return keys
 }
 
+// RecentKeys returns the n most recently used keys, from newest to oldest.
+func (c *Cache[K, V]) RecentKeys(n int) []K {
+	c.lock.RLock()
+	keys := c.lru.RecentKeys(n)
+	c.lock.RUnlock()
+	return keys
+}
+
+// OldestKeys returns the n least recently used keys, from oldest to newest.
+func (c *Cache[K, V]) OldestKeys(n int) []K {
+	c.lock.RLock()
+	keys := c.lru.OldestKeys(n)
+	c.lock.RUnlock()
+	return keys
+}
+
+// KeysPage returns up to limit keys, from oldest to newest, starting right
+// after cursor, along with the cursor of the next page. The lock is only held
+// while a single page is collected, so large caches can be listed without
+// copying every key at once. See lru.LRU.KeysPage for the consistency rules.
+func (c *Cache[K, V]) KeysPage(limit int, cursor lru.Cursor[K]) (keys []K, next lru.Cursor[K], err error) {
+	c.lock.RLock()
+	keys, next, err = c.lru.KeysPage(limit, cursor)
+	c.lock.RUnlock()
+	return
+}
+
 func (c *Cache[K, V]) Len() int {
 	c.lock.RLock()
 	length := c.lru.Len()
@@ -201,22 +580,127 @@ func (c *Cache[K, V]) Len() int {
 	return length
 }
 
-// Purge is used to completely clear the cache.
+// Purge is used to completely clear the cache. The entries are detached in
+// constant time under the lock; eviction callbacks for them are invoked
+// afterwards, oldest first, without holding the lock.
 func (c *Cache[K, V]) Purge() {
+	c.lock.Lock()
+	if c.frozen {
+		c.lock.Unlock()
+		return
+	}
+	old := c.lru.Detach()
+	c.deps, c.dependents = nil, nil
+	c.uses = nil
+	if c.views != nil {
+		c.views = &viewTracker[K, V]{seqs: make(map[K]uint64)}
+	}
+	if f := c.bloom.Load(); f != nil {
+		c.rebuildBloomFilter(f.capacity)
+	}
+	if c.ages != nil {
+		c.ages.times = make(map[K]entryTimes)
+	}
+	for k := range c.watchers {
+		if v, ok := old.Peek(k); ok {
+			c.emit(EventRemove, k, v)
+		}
+	}
+	c.lock.Unlock()
+	if l := c.logger.Load(); l != nil {
+		l.Info("cache purged", "entries", old.Len())
+	}
+
+	if c.onEvictedCB != nil {
+		for {
+			k, v, ok := old.RemoveOldest()
+			if !ok {
+				break
+			}
+			c.notifyEvicted(k, v)
+		}
+	}
+}
+
+// Freeze makes the cache read-only. Until Thaw is called, lookups keep
+// working while Add, Remove, Resize, RemoveOldest and Purge leave the cache
+// untouched and report that nothing was added, removed or evicted. Do and
+// ApplyBatch fail with ErrFrozen. ResumeEviction is deferred until Thaw.
+func (c *Cache[K, V]) Freeze() {
+	c.lock.Lock()
+	c.frozen = true
+	c.lock.Unlock()
+}
+
+// Thaw makes a frozen cache writable again, resuming eviction if
+// ResumeEviction was called while it was frozen and removing the entries
+// that ran out of uses meanwhile.
+func (c *Cache[K, V]) Thaw() {
 	var ks []K
 	var vs []V
 	c.lock.Lock()
-	c.lru.Purge()
+	c.frozen = false
+	if c.resumeThaw {
+		c.resumeThaw = false
+		c.lru.ResumeEviction()
+	}
+	if c.uses != nil {
+		c.removeExhausted()
+	}
 	if c.onEvictedCB != nil && len(c.evictedKeys) > 0 {
 		ks = c.evictedKeys
 		vs = c.evictedVals
 		c.initEvictBuffers()
 	}
+	if c.evictor != nil {
+		c.evictor.signal()
+	}
 	c.lock.Unlock()
+	c.notifyAll(ks, vs)
+}
 
-	if c.onEvictedCB != nil {
+// Frozen reports whether the cache is currently read-only.
+func (c *Cache[K, V]) Frozen() (frozen bool) {
+	c.lock.RLock()
+	frozen = c.frozen
+	c.lock.RUnlock()
+	return
+}
+
+// PauseEviction lets the cache grow past its size, up to ceiling entries,
+// until ResumeEviction is called. This keeps bulk loads from evicting the
+// entries they have just inserted.
+func (c *Cache[K, V]) PauseEviction(ceiling int) {
+	c.lock.Lock()
+	c.lru.PauseEviction(ceiling)
+	c.resumeThaw = false
+	c.lock.Unlock()
+}
+
+// ResumeEviction restores normal eviction and evicts the oldest entries until
+// the cache fits its size again, returning the number of evicted entries.
+// While the cache is frozen, eviction stays paused until Thaw and nothing
+// is evicted.
+func (c *Cache[K, V]) ResumeEviction() (evicted int) {
+	var ks []K
+	var vs []V
+	c.lock.Lock()
+	if c.frozen {
+		c.resumeThaw = true
+		c.lock.Unlock()
+		return 0
+	}
+	evicted = c.lru.ResumeEviction()
+	if c.onEvictedCB != nil && evicted > 0 {
+		ks = c.evictedKeys
+		vs = c.evictedVals
+		c.initEvictBuffers()
+	}
+	c.lock.Unlock()
+	if c.onEvictedCB != nil && evicted > 0 {
 		for i := 0; i < len(ks); i++ {
-			c.onEvictedCB(ks[i], vs[i])
+			c.notifyEvicted(ks[i], vs[i])
 		}
 	}
+	return evicted
 }
@@ -0,0 +1,44 @@
+package lru
+
+import "fmt"
+
+// CheckInvariants verifies the cache's internal bookkeeping: the map and the
+// recency list hold the same entries, the list is consistently linked, and
+// the cache does not exceed its capacity. It returns an error describing the
+// first violation found. Builds with the dailzlru_debug tag run it after
+// every mutation and panic on a violation.
+func (c *LRU[K, V]) CheckInvariants() error {
+	if len(c.items) != c.evictList.length() {
+		return fmt.Errorf("map holds %d entries but list holds %d", len(c.items), c.evictList.length())
+	}
+	if c.evictList.length() > c.capacity() {
+		return fmt.Errorf("%d entries exceed capacity %d", c.evictList.length(), c.capacity())
+	}
+	n := 0
+	root := &c.evictList.root
+	for e := root.next; e != root; e = e.next {
+		if n++; n > len(c.items) {
+			return fmt.Errorf("list is longer than its length %d", c.evictList.length())
+		}
+		if e.list != c.evictList {
+			return fmt.Errorf("entry %v belongs to another list", e.key)
+		}
+		if e.next.prev != e {
+			return fmt.Errorf("entry %v is not linked back from its successor", e.key)
+		}
+		if c.items[e.key] != e {
+			return fmt.Errorf("entry %v is orphaned from the map", e.key)
+		}
+	}
+	if n != len(c.items) {
+		return fmt.Errorf("list links %d entries but its length is %d", n, len(c.items))
+	}
+	return nil
+}
+
+// verify panics if an invariant is violated
+func (c *LRU[K, V]) verify() {
+	if err := c.CheckInvariants(); err != nil {
+		panic("lru: invariant violated: " + err.Error())
+	}
+}
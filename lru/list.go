@@ -8,6 +8,14 @@ type entry[K comparable, V any] struct {
 	value      V              // The LRU value of this element
 }
 
+// nextEntry returns the next lruList element or nil
+func (e *entry[K, V]) nextEntry() *entry[K, V] {
+	if n := e.next; e.list != nil && n != &e.list.root {
+		return n
+	}
+	return nil
+}
+
 // prevEntry returns lruList element or nil
 func (e *entry[K, V]) prevEntry() *entry[K, V] {
 	if p := e.prev; e.list != nil && p != &e.list.root {
@@ -38,6 +46,14 @@ func (l *lruList[K, V]) length() int {
 	return l.len
 }
 
+// front returns the first element of lruList or nil if the lruList is empty
+func (l *lruList[K, V]) front() *entry[K, V] {
+	if l.len == 0 {
+		return nil
+	}
+	return l.root.next
+}
+
 // back returns the last element of lruList or nil if the lruList is empty
 func (l *lruList[K, V]) back() *entry[K, V] {
 	if l.len == 0 {
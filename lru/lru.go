@@ -2,15 +2,35 @@ package lru
 
 import "errors"
 
+// ErrStaleCursor is returned by KeysPage when the key a Cursor points at is no
+// longer in the cache.
+var ErrStaleCursor = errors.New("cursor key is no longer in the cache")
+
+// A map whose peak number of entries reaches reclaimMinPeak is rebuilt once
+// its entries drop to a reclaimRatio-th of that peak, because Go maps never
+// release their buckets on their own.
+const (
+	reclaimMinPeak = 1024
+	reclaimRatio   = 4
+)
+
 // EvictCallback is used to get a callback when a cache entry is evicted
 type EvictCallback[K comparable, V any] func(key K, value V)
 
-// LRU implements a non-thread safe fixed size LRU cache
+// LRU implements a non-thread safe fixed size LRU cache. Its map is
+// rebuilt after Purge and once heavy removals leave it mostly empty, so the
+// memory of a cache that shrinks is returned.
 type LRU[K comparable, V any] struct {
 	size      int
 	evictList *lruList[K, V]
 	items     map[K]*entry[K, V]
+	peak      int // most entries items has held since it was made
 	onEvict   EvictCallback[K, V]
+	paused    bool
+	ceiling   int
+	evicting  bool
+	mru       bool
+	fifo      bool
 }
 
 // NewLRU constructs an LRU of the given size
@@ -28,39 +48,106 @@ func NewLRU[K comparable, V any](size int, onEvict EvictCallback[K, V]) (*LRU[K,
 	return c, nil
 }
 
+// NewMRU constructs a cache of the given size that evicts its most recently
+// used entry, instead of its least recently used one, to make room for a new
+// entry. This suits cyclic access patterns over a dataset slightly larger
+// than the cache, where LRU always evicts the entry needed next.
+func NewMRU[K comparable, V any](size int, onEvict EvictCallback[K, V]) (*LRU[K, V], error) {
+	c, err := NewLRU[K, V](size, onEvict)
+	if err != nil {
+		return nil, err
+	}
+	c.mru = true
+	return c, nil
+}
+
+// NewFIFO constructs a cache of the given size that keeps its entries in
+// insertion order: neither Get nor re-adding a key moves it, so the entry
+// evicted is always the one added first.
+func NewFIFO[K comparable, V any](size int, onEvict EvictCallback[K, V]) (*LRU[K, V], error) {
+	c, err := NewLRU[K, V](size, onEvict)
+	if err != nil {
+		return nil, err
+	}
+	c.fifo = true
+	return c, nil
+}
+
 // Purge is used to completely clear the cache.
 func (c *LRU[K, V]) Purge() {
-	for k, v := range c.items {
-		if c.onEvict != nil {
+	if debugInvariants {
+		defer c.verify()
+	}
+	// the callbacks see an empty cache
+	items := c.items
+	c.items = make(map[K]*entry[K, V])
+	c.peak = 0
+	c.evictList.init()
+	if c.onEvict != nil {
+		for k, v := range items {
 			c.onEvict(k, v.value)
 		}
-		delete(c.items, k)
 	}
-	c.evictList.init()
+}
+
+// Detach moves all entries into a new LRU in constant time and leaves c
+// empty. The returned LRU has the same size but no eviction callback, so the
+// caller decides what happens to the detached entries.
+func (c *LRU[K, V]) Detach() *LRU[K, V] {
+	old := &LRU[K, V]{
+		size:      c.size,
+		evictList: c.evictList,
+		items:     c.items,
+	}
+	c.evictList = newList[K, V]()
+	c.items = make(map[K]*entry[K, V])
+	c.peak = 0
+	return old
 }
 
 // Add adds a value to the cache.  Returns true if an eviction occurred.
-func (c LRU[K, V]) Add(key K, value V) bool {
+func (c *LRU[K, V]) Add(key K, value V) bool {
+	if debugInvariants {
+		defer c.verify()
+	}
 	if ent, ok := c.items[key]; ok {
-		c.evictList.moveToFront(ent)
+		if !c.fifo {
+			c.evictList.moveToFront(ent)
+		}
 		ent.value = value
 		return false
 	}
 
+	if c.mru && c.evictList.length() >= c.capacity() {
+		// evict before inserting, or the new entry would be the victim
+		c.removeVictim()
+		c.items[key] = c.evictList.pushFront(key, value)
+		return true
+	}
+
 	ent := c.evictList.pushFront(key, value)
 	c.items[key] = ent
+	if len(c.items) > c.peak {
+		c.peak = len(c.items)
+	}
 
-	evict := c.evictList.length() > c.size
+	evict := c.evictList.length() > c.capacity()
 	if evict {
-		c.removeOldest()
+		c.removeVictim()
 	}
 	return evict
 }
 
-// Get looks up a key's value from the cache.
-func (c LRU[K, V]) Get(key K) (value V, ok bool) {
+// Get looks up a key's value from the cache. Unless the cache is a FIFO, the
+// key becomes the most recently used one.
+func (c *LRU[K, V]) Get(key K) (value V, ok bool) {
+	if debugInvariants {
+		defer c.verify()
+	}
 	if ent, ok := c.items[key]; ok {
-		c.evictList.moveToFront(ent)
+		if !c.fifo {
+			c.evictList.moveToFront(ent)
+		}
 		return ent.value, true
 	}
 	return
@@ -82,11 +169,30 @@ func (c *LRU[K, V]) Peek(key K) (value V, ok bool) {
 	return
 }
 
+// Replace sets the value of key without updating its recent-ness or
+// invoking the eviction callback for the old value. Returns false, adding
+// nothing, if the key is not in the cache.
+func (c *LRU[K, V]) Replace(key K, value V) (ok bool) {
+	ent, ok := c.items[key]
+	if ok {
+		ent.value = value
+	}
+	return ok
+}
+
 // Remove removes the provided key from the cache, returning true if the
 // key was contained.
 func (c *LRU[K, V]) Remove(key K) (present bool) {
+	if debugInvariants {
+		defer c.verify()
+	}
 	if ent, ok := c.items[key]; ok {
+		// an eviction callback may remove other keys; those are not
+		// evictions
+		evicting := c.evicting
+		c.evicting = false
 		c.removeElement(ent)
+		c.evicting = evicting
 		return true
 	}
 	return false
@@ -94,7 +200,9 @@ func (c *LRU[K, V]) Remove(key K) (present bool) {
 
 // RemoveOldest removes the oldest item from the cache.
 func (c *LRU[K, V]) RemoveOldest() (key K, value V, ok bool) {
-
+	if debugInvariants {
+		defer c.verify()
+	}
 	if ent := c.evictList.back(); ent != nil {
 		c.removeElement(ent)
 		return ent.key, ent.value, true
@@ -102,6 +210,21 @@ func (c *LRU[K, V]) RemoveOldest() (key K, value V, ok bool) {
 	return
 }
 
+// TakeOldestIf removes and returns the oldest entry for which fn returns
+// true, scanning from the oldest entry towards the newest.
+func (c *LRU[K, V]) TakeOldestIf(fn func(key K, value V) bool) (key K, value V, ok bool) {
+	if debugInvariants {
+		defer c.verify()
+	}
+	for ent := c.evictList.back(); ent != nil; ent = ent.prevEntry() {
+		if fn(ent.key, ent.value) {
+			c.removeElement(ent)
+			return ent.key, ent.value, true
+		}
+	}
+	return
+}
+
 // GetOldest returns the oldest entry
 func (c *LRU[K, V]) GetOldest() (key K, value V, ok bool) {
 	if ent := c.evictList.back(); ent != nil {
@@ -121,36 +244,238 @@ func (c *LRU[K, V]) Keys() []K {
 	return keys
 }
 
+// RecentKeys returns up to n keys, from newest to oldest.
+func (c *LRU[K, V]) RecentKeys(n int) []K {
+	if l := c.evictList.length(); n > l {
+		n = l
+	}
+	if n <= 0 {
+		return nil
+	}
+	keys := make([]K, 0, n)
+	for ent := c.evictList.front(); ent != nil && len(keys) < n; ent = ent.nextEntry() {
+		keys = append(keys, ent.key)
+	}
+	return keys
+}
+
+// OldestKeys returns up to n keys, from oldest to newest.
+func (c *LRU[K, V]) OldestKeys(n int) []K {
+	if l := c.evictList.length(); n > l {
+		n = l
+	}
+	if n <= 0 {
+		return nil
+	}
+	keys := make([]K, 0, n)
+	for ent := c.evictList.back(); ent != nil && len(keys) < n; ent = ent.prevEntry() {
+		keys = append(keys, ent.key)
+	}
+	return keys
+}
+
+// Cursor marks where a paginated walk over the keys of an LRU stopped. The
+// zero Cursor starts the walk at the oldest key.
+type Cursor[K comparable] struct {
+	key     K
+	started bool
+	done    bool
+}
+
+// Done reports whether the walk has returned every key.
+func (c Cursor[K]) Done() bool {
+	return c.done
+}
+
+// KeysPage returns up to limit keys, from oldest to newest, starting right
+// after cursor, along with the cursor of the next page. Pages are not a
+// consistent snapshot: keys added or used between calls may be skipped or
+// returned twice. If the cursor's key has left the cache, ErrStaleCursor is
+// returned and the walk has to be restarted.
+func (c *LRU[K, V]) KeysPage(limit int, cursor Cursor[K]) (keys []K, next Cursor[K], err error) {
+	if cursor.done || limit <= 0 {
+		return nil, cursor, nil
+	}
+	ent := c.evictList.back()
+	if cursor.started {
+		at, ok := c.items[cursor.key]
+		if !ok {
+			return nil, cursor, ErrStaleCursor
+		}
+		ent = at.prevEntry()
+	}
+
+	if n := c.evictList.length(); limit > n {
+		limit = n
+	}
+	keys = make([]K, 0, limit)
+	for ; ent != nil && len(keys) < limit; ent = ent.prevEntry() {
+		keys = append(keys, ent.key)
+	}
+	if ent == nil {
+		return keys, Cursor[K]{done: true}, nil
+	}
+	return keys, Cursor[K]{key: keys[len(keys)-1], started: true}, nil
+}
+
+// Range calls fn for every entry, from oldest to newest, without updating
+// their recent-ness, until fn returns false. The cache must not be modified
+// from fn.
+func (c *LRU[K, V]) Range(fn func(key K, value V) bool) {
+	for ent := c.evictList.back(); ent != nil; ent = ent.prevEntry() {
+		if !fn(ent.key, ent.value) {
+			return
+		}
+	}
+}
+
 // Len returns the number of items in the cache.
 func (c *LRU[K, V]) Len() int {
 	return c.evictList.length()
 }
 
+// Size returns the number of entries the cache holds before it evicts, as
+// set at construction or by the last Resize.
+func (c *LRU[K, V]) Size() int {
+	return c.size
+}
+
+// Capacity returns the number of entries the cache may currently hold
+// before it evicts: its size, or the ceiling while eviction is paused.
+func (c *LRU[K, V]) Capacity() int {
+	return c.capacity()
+}
+
 // Resize changes the cache size.
 func (c *LRU[K, V]) Resize(size int) (evicted int) {
+	if debugInvariants {
+		defer c.verify()
+	}
 	diff := c.Len() - size
 	if diff < 0 {
 		diff = 0
 	}
 	for i := 0; i < diff; i++ {
-		c.removeOldest()
+		c.removeVictim()
 	}
 	c.size = size
 	return diff
 }
 
-// removeOldest removes the oldest item from the cache.
-func (c *LRU[K, V]) removeOldest() {
-	if ent := c.evictList.back(); ent != nil {
+// MakeRoom evicts entries, in the order the eviction policy picks victims
+// but skipping the keys for which keep returns true, until n more entries
+// fit without an eviction or only kept entries remain. Returns the number
+// of entries evicted.
+func (c *LRU[K, V]) MakeRoom(n int, keep func(key K) bool) (evicted int) {
+	if debugInvariants {
+		defer c.verify()
+	}
+	for c.evictList.length()+n > c.capacity() {
+		// the eviction callback may remove other entries, so the victims
+		// are picked by key before any of them is removed
+		need := c.evictList.length() + n - c.capacity()
+		victims := make([]K, 0, need)
+		for ent := c.victimEnd(); ent != nil && len(victims) < need; ent = c.victimNext(ent) {
+			if !keep(ent.key) {
+				victims = append(victims, ent.key)
+			}
+		}
+		if len(victims) == 0 {
+			break
+		}
+		for _, k := range victims {
+			if ent, ok := c.items[k]; ok {
+				c.evicting = true
+				c.removeElement(ent)
+				c.evicting = false
+				evicted++
+			}
+		}
+	}
+	return evicted
+}
+
+// PauseEviction lets the cache grow past its size, up to ceiling entries,
+// until ResumeEviction is called. A ceiling below the size is treated as the
+// size, so eviction still happens once the ceiling is reached.
+func (c *LRU[K, V]) PauseEviction(ceiling int) {
+	c.paused = true
+	c.ceiling = ceiling
+}
+
+// Promotes reports whether Get and Add move an existing key to the front,
+// which is the case for every cache but a FIFO. A cache that does not
+// promote is not modified by Get.
+func (c *LRU[K, V]) Promotes() bool {
+	return !c.fifo
+}
+
+// ResumeEviction restores normal eviction after PauseEviction and evicts the
+// oldest entries until the cache fits its size again.
+func (c *LRU[K, V]) ResumeEviction() (evicted int) {
+	c.paused = false
+	return c.Resize(c.size)
+}
+
+// Evicting reports whether the eviction callback currently running was
+// triggered by the cache exceeding its size, as opposed to an explicit
+// Remove, RemoveOldest or Purge.
+func (c *LRU[K, V]) Evicting() bool {
+	return c.evicting
+}
+
+// capacity returns the number of entries the cache may currently hold
+func (c *LRU[K, V]) capacity() int {
+	if c.paused && c.ceiling > c.size {
+		return c.ceiling
+	}
+	return c.size
+}
+
+// removeVictim removes the entry the eviction policy picks to make room:
+// the oldest one, or the newest one for an MRU cache.
+func (c *LRU[K, V]) removeVictim() {
+	if ent := c.victimEnd(); ent != nil {
+		c.evicting = true
 		c.removeElement(ent)
+		c.evicting = false
 	}
 }
 
+// victimEnd returns the entry the eviction policy picks first
+func (c *LRU[K, V]) victimEnd() *entry[K, V] {
+	if c.mru {
+		return c.evictList.front()
+	}
+	return c.evictList.back()
+}
+
+// victimNext returns the entry the eviction policy picks after ent
+func (c *LRU[K, V]) victimNext(ent *entry[K, V]) *entry[K, V] {
+	if c.mru {
+		return ent.nextEntry()
+	}
+	return ent.prevEntry()
+}
+
 // removeElement is used to remove a given list element from the cache
 func (c *LRU[K, V]) removeElement(e *entry[K, V]) {
 	c.evictList.remove(e)
 	delete(c.items, e.key)
+	if c.peak >= reclaimMinPeak && len(c.items)*reclaimRatio <= c.peak {
+		c.reclaim()
+	}
 	if c.onEvict != nil {
 		c.onEvict(e.key, e.value)
 	}
 }
+
+// reclaim replaces the map with one sized for its current entries
+func (c *LRU[K, V]) reclaim() {
+	items := make(map[K]*entry[K, V], len(c.items))
+	for k, ent := range c.items {
+		items[k] = ent
+	}
+	c.items = items
+	c.peak = len(items)
+}
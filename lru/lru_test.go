@@ -81,4 +81,346 @@ func TestLRU(t *testing.T) {
 	}
 }
 
+func TestLRU_PauseEviction(t *testing.T) {
+	evictCounter := 0
+	l, err := NewLRU(4, func(k int, v int) {
+		evictCounter++
+	})
+	if err != nil {
+		t.Fatalf("NewLRU error: %v", err)
+	}
+
+	l.PauseEviction(8)
+	for i := 0; i < 10; i++ {
+		l.Add(i, i)
+	}
+	if l.Len() != 8 || evictCounter != 2 {
+		t.Fatalf("LRU error: bad Len = %v, evict count = %v", l.Len(), evictCounter)
+	}
+
+	if evicted := l.ResumeEviction(); evicted != 4 {
+		t.Fatalf("LRU error: bad evicted = %v", evicted)
+	}
+	for i, k := range l.Keys() {
+		if k != i+6 {
+			t.Fatalf("LRU error: out of order key: %v", k)
+		}
+	}
+
+	l.Add(10, 10)
+	if l.Len() != 4 {
+		t.Fatalf("LRU error: bad Len = %v", l.Len())
+	}
+}
+
+func TestLRU_Detach(t *testing.T) {
+	evictCounter := 0
+	l, err := NewLRU(4, func(k int, v int) {
+		evictCounter++
+	})
+	if err != nil {
+		t.Fatalf("NewLRU error: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		l.Add(i, i)
+	}
+
+	old := l.Detach()
+	if l.Len() != 0 || old.Len() != 4 {
+		t.Fatalf("LRU error: bad Len = %v, detached Len = %v", l.Len(), old.Len())
+	}
+	for i, k := range old.Keys() {
+		if k != i {
+			t.Fatalf("LRU error: out of order detached key: %v", k)
+		}
+	}
+	old.Purge()
+	if evictCounter != 0 {
+		t.Fatalf("LRU error: detached entries should not fire callbacks")
+	}
+
+	for i := 10; i < 15; i++ {
+		l.Add(i, i)
+	}
+	if l.Len() != 4 || evictCounter != 1 {
+		t.Fatalf("LRU error: bad Len = %v, evict count = %v", l.Len(), evictCounter)
+	}
+}
+
+func TestLRU_KeysPage(t *testing.T) {
+	l, err := NewLRU[int, int](16, nil)
+	if err != nil {
+		t.Fatalf("NewLRU error: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		l.Add(i, i)
+	}
+
+	var all []int
+	var cursor Cursor[int]
+	for pages := 0; !cursor.Done(); pages++ {
+		if pages > 4 {
+			t.Fatalf("LRU error: too many pages")
+		}
+		var keys []int
+		keys, cursor, err = l.KeysPage(3, cursor)
+		if err != nil {
+			t.Fatalf("KeysPage error: %v", err)
+		}
+		all = append(all, keys...)
+	}
+	if len(all) != 10 {
+		t.Fatalf("LRU error: bad key count = %v", len(all))
+	}
+	for i, k := range all {
+		if k != i {
+			t.Fatalf("LRU error: out of order key: %v", k)
+		}
+	}
+
+	_, cursor, _ = l.KeysPage(2, Cursor[int]{})
+	l.Remove(1)
+	if _, _, err := l.KeysPage(2, cursor); err != ErrStaleCursor {
+		t.Fatalf("LRU error: expected ErrStaleCursor, got %v", err)
+	}
+}
+
+func TestLRU_RecentAndOldestKeys(t *testing.T) {
+	l, err := NewLRU[int, int](8, nil)
+	if err != nil {
+		t.Fatalf("NewLRU error: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		l.Add(i, i)
+	}
+	l.Get(0)
+
+	recent := l.RecentKeys(3)
+	if len(recent) != 3 || recent[0] != 0 || recent[1] != 4 || recent[2] != 3 {
+		t.Fatalf("LRU error: bad recent keys: %v", recent)
+	}
+	oldest := l.OldestKeys(2)
+	if len(oldest) != 2 || oldest[0] != 1 || oldest[1] != 2 {
+		t.Fatalf("LRU error: bad oldest keys: %v", oldest)
+	}
+	if keys := l.OldestKeys(100); len(keys) != 5 {
+		t.Fatalf("LRU error: bad oldest keys: %v", keys)
+	}
+	if keys := l.RecentKeys(0); len(keys) != 0 {
+		t.Fatalf("LRU error: bad recent keys: %v", keys)
+	}
+}
+
+func TestLRU_Range(t *testing.T) {
+	l, err := NewLRU[int, int](8, nil)
+	if err != nil {
+		t.Fatalf("NewLRU error: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		l.Add(i, i*10)
+	}
+
+	var keys []int
+	l.Range(func(k int, v int) bool {
+		if v != k*10 {
+			t.Fatalf("LRU error: bad value for %v: %v", k, v)
+		}
+		keys = append(keys, k)
+		return k < 2
+	})
+	if len(keys) != 3 || keys[0] != 0 || keys[2] != 2 {
+		t.Fatalf("LRU error: bad range keys: %v", keys)
+	}
+}
+
+func TestLRU_CheckInvariants(t *testing.T) {
+	l, err := NewLRU[int, int](8, nil)
+	if err != nil {
+		t.Fatalf("NewLRU error: %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		l.Add(i, i)
+		l.Get(i / 2)
+		if i%3 == 0 {
+			l.Remove(i - 1)
+		}
+	}
+	if err := l.CheckInvariants(); err != nil {
+		t.Fatalf("LRU error: %v", err)
+	}
+
+	// orphan an entry by dropping it from the map only
+	delete(l.items, l.evictList.back().key)
+	if err := l.CheckInvariants(); err == nil {
+		t.Fatalf("LRU error: orphaned entry not detected")
+	}
+}
+
+func TestMRU(t *testing.T) {
+	var evicted []int
+	l, err := NewMRU[int, int](4, func(k, v int) {
+		evicted = append(evicted, k)
+	})
+	if err != nil {
+		t.Fatalf("NewMRU error: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		l.Add(i, i)
+	}
+	l.Get(1)
+	if !l.Add(4, 4) {
+		t.Fatalf("MRU error: should have evicted")
+	}
+	if len(evicted) != 1 || evicted[0] != 1 {
+		t.Fatalf("MRU error: bad evicted keys: %v", evicted)
+	}
+	if !l.Contains(4) || l.Len() != 4 {
+		t.Fatalf("MRU error: new entry should be kept: %v", l.Keys())
+	}
+
+	// a loop over 5 keys never hits under LRU but mostly hits under MRU
+	hits := 0
+	for i := 0; i < 100; i++ {
+		k := i % 5
+		if _, ok := l.Get(k); ok {
+			hits++
+		} else {
+			l.Add(k, k)
+		}
+	}
+	if hits < 50 {
+		t.Fatalf("MRU error: too few hits on a loop: %v", hits)
+	}
+	if l.Resize(2) != 2 || l.Len() != 2 {
+		t.Fatalf("MRU error: bad resize: %v", l.Keys())
+	}
+}
+
+func TestFIFO(t *testing.T) {
+	l, err := NewFIFO[int, int](3, nil)
+	if err != nil {
+		t.Fatalf("NewFIFO error: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		l.Add(i, i)
+	}
+	l.Get(0)
+	l.Add(1, 10)
+	if keys := l.Keys(); keys[0] != 0 || keys[1] != 1 || keys[2] != 2 {
+		t.Fatalf("FIFO error: entries were reordered: %v", keys)
+	}
+	l.Add(3, 3)
+	if l.Contains(0) || !l.Contains(1) {
+		t.Fatalf("FIFO error: should evict in insertion order: %v", l.Keys())
+	}
+	if l.Promotes() {
+		t.Fatalf("FIFO error: should not promote")
+	}
+}
+
+func TestLRU_TakeOldestIf(t *testing.T) {
+	evictCounter := 0
+	l, err := NewLRU[int, int](8, func(k, v int) {
+		evictCounter++
+	})
+	if err != nil {
+		t.Fatalf("NewLRU error: %v", err)
+	}
+	for i := 0; i < 8; i++ {
+		l.Add(i, i)
+	}
+	odd := func(k, v int) bool { return v%2 == 1 }
+	if k, v, ok := l.TakeOldestIf(odd); !ok || k != 1 || v != 1 {
+		t.Fatalf("LRU error: bad entry: %v, %v, %v", k, v, ok)
+	}
+	if k, _, ok := l.TakeOldestIf(odd); !ok || k != 3 {
+		t.Fatalf("LRU error: bad entry: %v, %v", k, ok)
+	}
+	if _, _, ok := l.TakeOldestIf(func(k, v int) bool { return k > 100 }); ok {
+		t.Fatalf("LRU error: nothing should match")
+	}
+	if l.Len() != 6 || evictCounter != 2 {
+		t.Fatalf("LRU error: bad state: %v, %v", l.Len(), evictCounter)
+	}
+}
+
+func TestLRU_ReclaimMap(t *testing.T) {
+	l, err := NewLRU[int, int](4*reclaimMinPeak, nil)
+	if err != nil {
+		t.Fatalf("NewLRU error: %v", err)
+	}
+	for i := 0; i < 2*reclaimMinPeak; i++ {
+		l.Add(i, i)
+	}
+	if l.peak != 2*reclaimMinPeak {
+		t.Fatalf("bad peak: %v", l.peak)
+	}
+	for i := 0; i < 2*reclaimMinPeak-2*reclaimMinPeak/reclaimRatio; i++ {
+		l.Remove(i)
+	}
+	if l.peak != 2*reclaimMinPeak/reclaimRatio {
+		t.Fatalf("map not rebuilt: peak %v, %v entries", l.peak, len(l.items))
+	}
+	for i := 2*reclaimMinPeak - 2*reclaimMinPeak/reclaimRatio; i < 2*reclaimMinPeak; i++ {
+		if v, ok := l.Get(i); !ok || v != i {
+			t.Fatalf("entry %v lost: %v, %v", i, v, ok)
+		}
+	}
 
+	// small caches are left alone
+	l.Purge()
+	if l.peak != 0 || len(l.items) != 0 {
+		t.Fatalf("purge kept the map: peak %v", l.peak)
+	}
+	for i := 0; i < 100; i++ {
+		l.Add(i, i)
+	}
+	for i := 0; i < 99; i++ {
+		l.Remove(i)
+	}
+	if l.peak != 100 {
+		t.Fatalf("small map rebuilt: peak %v", l.peak)
+	}
+}
+
+func TestLRU_MakeRoom(t *testing.T) {
+	var evicted []int
+	l, err := NewLRU(4, func(k, v int) { evicted = append(evicted, k) })
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		l.Add(i, i)
+	}
+	keep := func(k int) bool { return k%2 == 0 }
+	if n := l.MakeRoom(1, keep); n != 1 || len(evicted) != 1 || evicted[0] != 1 {
+		t.Fatalf("bad eviction: %v, %v", n, evicted)
+	}
+	if n := l.MakeRoom(3, keep); n != 1 || l.Len() != 2 || evicted[1] != 3 {
+		t.Fatalf("kept entries evicted: %v, %v", n, l.Keys())
+	}
+	if l.Capacity() != 4 {
+		t.Fatalf("bad capacity: %v", l.Capacity())
+	}
+}
+
+func TestLRU_Replace(t *testing.T) {
+	evicted := 0
+	l, err := NewLRU(2, func(k, v int) { evicted++ })
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add(1, 1)
+	l.Add(2, 2)
+	if !l.Replace(1, 10) || l.Replace(3, 3) || l.Contains(3) {
+		t.Fatalf("bad replace")
+	}
+	if v, _ := l.Peek(1); v != 10 || evicted != 0 {
+		t.Fatalf("bad value: %v, %v evicted", v, evicted)
+	}
+	// 1 is still the oldest entry
+	l.Add(3, 3)
+	if l.Contains(1) || !l.Contains(2) {
+		t.Fatalf("bad keys: %v", l.Keys())
+	}
+}
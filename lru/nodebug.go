@@ -0,0 +1,7 @@
+//go:build !dailzlru_debug
+
+package lru
+
+// debugInvariants enables the invariant checks run after every mutation. It is
+// set by building with the dailzlru_debug tag.
+const debugInvariants = false
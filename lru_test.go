@@ -129,3 +129,309 @@ func getRand(tb testing.TB) int64 {
 	}
 	return out.Int64()
 }
+
+func TestCache_Freeze(t *testing.T) {
+	cache, err := New[int, int](2)
+	if err != nil {
+		t.Fatalf("LRU error: %v", err)
+	}
+	cache.Add(1, 1)
+	cache.Freeze()
+	if !cache.Frozen() {
+		t.Fatalf("cache should be frozen")
+	}
+
+	if cache.Add(2, 2) || cache.Contains(2) {
+		t.Fatalf("Add should be a no-op while frozen")
+	}
+	if ok, _ := cache.ContainsOrAdd(3, 3); ok || cache.Contains(3) {
+		t.Fatalf("ContainsOrAdd should be a no-op while frozen")
+	}
+	if cache.Remove(1) || !cache.Contains(1) {
+		t.Fatalf("Remove should be a no-op while frozen")
+	}
+	if _, _, ok := cache.RemoveOldest(); ok {
+		t.Fatalf("RemoveOldest should be a no-op while frozen")
+	}
+	cache.Purge()
+	if v, ok := cache.Get(1); !ok || v != 1 {
+		t.Fatalf("Get should work while frozen")
+	}
+	if err := cache.Do(func(tx Txn[int, int]) error { return nil }); err != ErrFrozen {
+		t.Fatalf("Do should fail with ErrFrozen, got %v", err)
+	}
+
+	cache.Thaw()
+	if cache.Add(2, 2); !cache.Contains(2) {
+		t.Fatalf("Add should work after Thaw")
+	}
+}
+
+func TestCache_FreezeEviction(t *testing.T) {
+	var evicted []int
+	cache, err := NewWithEvict(2, func(k int, v int) {
+		evicted = append(evicted, k)
+	})
+	if err != nil {
+		t.Fatalf("LRU error: %v", err)
+	}
+	cache.EnableBloomFilter()
+	cache.PauseEviction(4)
+	for i := 0; i < 4; i++ {
+		cache.Add(i, i)
+	}
+	cache.Freeze()
+	if n := cache.ResumeEviction(); n != 0 || cache.Len() != 4 || len(evicted) != 0 {
+		t.Fatalf("ResumeEviction should be deferred while frozen: %v, %v", n, cache.Len())
+	}
+	if n := cache.Resize(1); n != 0 || cache.Len() != 4 {
+		t.Fatalf("Resize should be a no-op while frozen: %v, %v", n, cache.Len())
+	}
+	if n := cache.Resize(1000); n != 0 || cache.bloom.Load().capacity >= 1000 {
+		t.Fatalf("Resize should not rebuild the bloom filter while frozen")
+	}
+
+	cache.Thaw()
+	if cache.Len() != 2 || len(evicted) != 2 || evicted[0] != 0 || evicted[1] != 1 {
+		t.Fatalf("eviction not resumed on Thaw: %v, %v", cache.Keys(), evicted)
+	}
+}
+
+func TestCache_Purge(t *testing.T) {
+	var evicted []int
+	cache, err := NewWithEvict(8, func(k int, v int) {
+		evicted = append(evicted, k)
+	})
+	if err != nil {
+		t.Fatalf("LRU error: %v", err)
+	}
+	for i := 0; i < 8; i++ {
+		cache.Add(i, i)
+	}
+
+	cache.Purge()
+	if cache.Len() != 0 {
+		t.Fatalf("LRU error: bad len: %v", cache.Len())
+	}
+	if len(evicted) != 8 {
+		t.Fatalf("LRU error: bad evict count: %v", len(evicted))
+	}
+	for i, k := range evicted {
+		if k != i {
+			t.Fatalf("LRU error: purge callbacks should run oldest first, got %v", evicted)
+		}
+	}
+
+	cache.Add(100, 100)
+	if v, ok := cache.Get(100); !ok || v != 100 {
+		t.Fatalf("LRU error: cache should be usable after Purge")
+	}
+}
+
+func TestCache_ContainsAllAny(t *testing.T) {
+	cache, err := New[int, int](8)
+	if err != nil {
+		t.Fatalf("LRU error: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		cache.Add(i, i)
+	}
+
+	if !cache.ContainsAll(0, 1, 2, 3) || cache.ContainsAll(0, 4) {
+		t.Fatalf("LRU error: bad ContainsAll")
+	}
+	if !cache.ContainsAny(9, 3) || cache.ContainsAny(4, 5) {
+		t.Fatalf("LRU error: bad ContainsAny")
+	}
+	if !cache.ContainsAll() || cache.ContainsAny() {
+		t.Fatalf("LRU error: bad result for empty key set")
+	}
+}
+
+func TestCache_Victim(t *testing.T) {
+	victim, err := New[int, int](4)
+	if err != nil {
+		t.Fatalf("LRU error: %v", err)
+	}
+	evictCounter := 0
+	cache, err := NewWithVictim(2, func(k int, v int) {
+		evictCounter++
+	}, Interface[int, int](victim), func(k int, v int) bool {
+		return k%2 == 0
+	})
+	if err != nil {
+		t.Fatalf("LRU error: %v", err)
+	}
+
+	for i := 0; i < 6; i++ {
+		cache.Add(i, i)
+	}
+	if evictCounter != 4 {
+		t.Fatalf("bad evict count: %v", evictCounter)
+	}
+	// odd keys are vetoed
+	if !victim.ContainsAll(0, 2) || victim.ContainsAny(1, 3) {
+		t.Fatalf("bad victim keys: %v", victim.Keys())
+	}
+
+	// explicit removals are not handed over
+	cache.Remove(4)
+	cache.Purge()
+	if victim.Len() != 2 {
+		t.Fatalf("removed entries should not reach the victim: %v", victim.Keys())
+	}
+	if evictCounter != 6 {
+		t.Fatalf("bad evict count: %v", evictCounter)
+	}
+
+	if _, err := NewWithVictim[int, int](2, nil, nil, nil); err == nil {
+		t.Fatalf("a nil victim should be rejected")
+	}
+}
+
+func TestCache_MRU(t *testing.T) {
+	var evicted []int
+	l, err := NewMRUWithEvict[int, int](2, func(k, v int) {
+		evicted = append(evicted, k)
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Add(3, 3)
+	if len(evicted) != 1 || evicted[0] != 2 {
+		t.Fatalf("bad evicted keys: %v", evicted)
+	}
+	if !l.Contains(1) || !l.Contains(3) {
+		t.Fatalf("bad keys: %v", l.Keys())
+	}
+}
+
+func TestCache_FIFO(t *testing.T) {
+	l, err := NewFIFO[int, int](2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add(1, 1)
+	l.Add(2, 2)
+	if v, ok := l.Get(1); !ok || v != 1 {
+		t.Fatalf("bad value: %v, %v", v, ok)
+	}
+	l.Add(3, 3)
+	if l.Contains(1) || !l.Contains(2) || !l.Contains(3) {
+		t.Fatalf("bad keys: %v", l.Keys())
+	}
+}
+
+func TestCache_Pop(t *testing.T) {
+	var evicted []int
+	l, err := NewWithEvict[int, int](8, func(k, v int) {
+		evicted = append(evicted, k)
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add(1, 10)
+	if v, ok := l.Pop(1); !ok || v != 10 {
+		t.Fatalf("bad pop: %v, %v", v, ok)
+	}
+	if _, ok := l.Pop(1); ok || l.Contains(1) {
+		t.Fatalf("key should be gone")
+	}
+	if len(evicted) != 1 || evicted[0] != 1 {
+		t.Fatalf("bad evicted keys: %v", evicted)
+	}
+
+	// only one of many concurrent pops gets the value
+	l.Add(2, 20)
+	got := make(chan bool)
+	for i := 0; i < 8; i++ {
+		go func() {
+			_, ok := l.Pop(2)
+			got <- ok
+		}()
+	}
+	n := 0
+	for i := 0; i < 8; i++ {
+		if <-got {
+			n++
+		}
+	}
+	if n != 1 {
+		t.Fatalf("value popped %v times", n)
+	}
+}
+
+func TestCache_TakeOldestIf(t *testing.T) {
+	var evicted []int
+	l, err := NewWithEvict[int, bool](8, func(k int, dirty bool) {
+		evicted = append(evicted, k)
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		l.Add(i, i >= 2)
+	}
+	k, dirty, ok := l.TakeOldestIf(func(k int, dirty bool) bool { return dirty })
+	if !ok || k != 2 || !dirty {
+		t.Fatalf("bad entry: %v, %v, %v", k, dirty, ok)
+	}
+	if len(evicted) != 1 || evicted[0] != 2 || l.Contains(2) {
+		t.Fatalf("bad evicted keys: %v", evicted)
+	}
+}
+
+func TestCache_ResizeChunked(t *testing.T) {
+	var l *Cache[int, int]
+	var lens []int
+	l, err := NewWithEvict(3*ResizeChunkSize, func(k, v int) {
+		// the lock is released between chunks
+		lens = append(lens, l.Len())
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 3*ResizeChunkSize; i++ {
+		l.Add(i, i)
+	}
+	if evicted := l.Resize(10); evicted != 3*ResizeChunkSize-10 {
+		t.Fatalf("bad evicted: %d", evicted)
+	}
+	if l.Len() != 10 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+	if len(lens) != 3*ResizeChunkSize-10 {
+		t.Fatalf("bad evictions: %d", len(lens))
+	}
+	if lens[0] != 2*ResizeChunkSize || lens[ResizeChunkSize] != ResizeChunkSize || lens[len(lens)-1] != 10 {
+		t.Fatalf("bad lens between chunks: %d %d %d", lens[0], lens[ResizeChunkSize], lens[len(lens)-1])
+	}
+	for i := 3*ResizeChunkSize - 10; i < 3*ResizeChunkSize; i++ {
+		if !l.Contains(i) {
+			t.Fatalf("newest key %d should be kept", i)
+		}
+	}
+}
+
+func TestCache_Take(t *testing.T) {
+	var evicted []int
+	l, err := NewWithEvict[int, int](4, func(k, v int) {
+		evicted = append(evicted, k)
+	})
+	if err != nil {
+		t.Fatalf("LRU error: %v", err)
+	}
+	l.Add(1, 1)
+	l.AddWithDeps(2, 2, 1)
+	if v, ok := l.Take(1); !ok || v != 1 || l.Contains(1) {
+		t.Fatalf("bad take: %v, %v", v, ok)
+	}
+	if _, ok := l.Take(1); ok {
+		t.Fatalf("taken twice")
+	}
+	if len(evicted) != 1 || evicted[0] != 2 {
+		t.Fatalf("bad callbacks: %v", evicted)
+	}
+}
@@ -0,0 +1,268 @@
+package dailzLRU
+
+import (
+	"container/heap"
+	"errors"
+	"github.com/dailz1/dailzLRU/lru"
+	"sync"
+)
+
+// lrukEntry is an entry of an LRUKCache
+type lrukEntry[K comparable, V any] struct {
+	key   K
+	value V
+	refs  []uint64 // reference times, most recent first, at most k of them
+	index int      // position in the heap
+}
+
+// lrukHeap orders entries by eviction order: entries referenced fewer than
+// k times first, from least to most recently referenced, then the others by
+// their k-th most recent reference
+type lrukHeap[K comparable, V any] struct {
+	k       int
+	entries []*lrukEntry[K, V]
+}
+
+func (h *lrukHeap[K, V]) Len() int { return len(h.entries) }
+
+func (h *lrukHeap[K, V]) Less(i, j int) bool {
+	a, b := h.entries[i], h.entries[j]
+	if af, bf := len(a.refs) == h.k, len(b.refs) == h.k; af != bf {
+		return bf
+	}
+	if len(a.refs) == h.k {
+		return a.refs[h.k-1] < b.refs[h.k-1]
+	}
+	return a.refs[0] < b.refs[0]
+}
+
+func (h *lrukHeap[K, V]) Swap(i, j int) {
+	h.entries[i], h.entries[j] = h.entries[j], h.entries[i]
+	h.entries[i].index = i
+	h.entries[j].index = j
+}
+
+func (h *lrukHeap[K, V]) Push(x any) {
+	e := x.(*lrukEntry[K, V])
+	e.index = len(h.entries)
+	h.entries = append(h.entries, e)
+}
+
+func (h *lrukHeap[K, V]) Pop() any {
+	old := h.entries
+	e := old[len(old)-1]
+	old[len(old)-1] = nil
+	h.entries = old[:len(old)-1]
+	return e
+}
+
+// LRUKCache is a thread-safe fixed size cache using LRU-K: it evicts the
+// entry whose k-th most recent reference is the oldest. Entries referenced
+// fewer than k times go first, least recently used first, so an entry must
+// prove itself with k references before it competes with established ones;
+// a one-off scan never displaces them. The reference times of evicted keys
+// are kept in a history of the same size as the cache, so a key that comes
+// back soon after being evicted keeps its earlier references.
+type LRUKCache[K comparable, V any] struct {
+	size        int
+	items       map[K]*lrukEntry[K, V]
+	heap        lrukHeap[K, V]
+	history     *lru.LRU[K, []uint64]
+	clock       uint64
+	evictedKeys []K
+	evictedVals []V
+	onEvictedCB func(k K, v V)
+	lock        sync.Mutex
+}
+
+// NewLRUK constructs an LRUKCache of the given size that ranks entries by
+// their k-th most recent reference. k is commonly 2; an LRUKCache with k of
+// 1 behaves like a plain LRU cache. onEvicted, if not nil, is called outside
+// of the cache's lock for entries that are evicted or removed.
+func NewLRUK[K comparable, V any](size, k int, onEvicted func(key K, value V)) (*LRUKCache[K, V], error) {
+	if size <= 0 {
+		return nil, errors.New("must provide a positive size")
+	}
+	if k <= 0 {
+		return nil, errors.New("must provide a positive k")
+	}
+	history, err := lru.NewLRU[K, []uint64](size, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &LRUKCache[K, V]{
+		size:        size,
+		items:       make(map[K]*lrukEntry[K, V]),
+		heap:        lrukHeap[K, V]{k: k},
+		history:     history,
+		onEvictedCB: onEvicted,
+	}, nil
+}
+
+// reference records a reference to e
+func (c *LRUKCache[K, V]) reference(e *lrukEntry[K, V]) {
+	c.clock++
+	if len(e.refs) < c.heap.k {
+		e.refs = append(e.refs, 0)
+	}
+	copy(e.refs[1:], e.refs)
+	e.refs[0] = c.clock
+}
+
+// removeEntry removes e, buffering the eviction callback
+func (c *LRUKCache[K, V]) removeEntry(e *lrukEntry[K, V]) {
+	heap.Remove(&c.heap, e.index)
+	delete(c.items, e.key)
+	if c.onEvictedCB != nil {
+		c.evictedKeys = append(c.evictedKeys, e.key)
+		c.evictedVals = append(c.evictedVals, e.value)
+	}
+}
+
+// evict removes the entry with the oldest k-th reference, remembering its
+// references in the history
+func (c *LRUKCache[K, V]) evict() {
+	e := c.heap.entries[0]
+	c.removeEntry(e)
+	c.history.Add(e.key, e.refs)
+}
+
+// takeEvicted hands back the buffered evictions and resets the buffers
+func (c *LRUKCache[K, V]) takeEvicted() (ks []K, vs []V) {
+	ks, vs = c.evictedKeys, c.evictedVals
+	c.evictedKeys, c.evictedVals = nil, nil
+	return ks, vs
+}
+
+// notify invokes the eviction callback for buffered evictions
+func (c *LRUKCache[K, V]) notify(ks []K, vs []V) {
+	for i := 0; i < len(ks); i++ {
+		c.onEvictedCB(ks[i], vs[i])
+	}
+}
+
+// Add adds a value to the cache, counting as a reference. Returns true if an
+// eviction occurred.
+func (c *LRUKCache[K, V]) Add(key K, value V) (evicted bool) {
+	c.lock.Lock()
+	if e, ok := c.items[key]; ok {
+		e.value = value
+		c.reference(e)
+		heap.Fix(&c.heap, e.index)
+		c.lock.Unlock()
+		return false
+	}
+	if len(c.items) >= c.size {
+		c.evict()
+		evicted = true
+	}
+	e := &lrukEntry[K, V]{key: key, value: value}
+	if refs, ok := c.history.Peek(key); ok {
+		c.history.Remove(key)
+		e.refs = refs
+	}
+	c.reference(e)
+	heap.Push(&c.heap, e)
+	c.items[key] = e
+	ks, vs := c.takeEvicted()
+	c.lock.Unlock()
+	c.notify(ks, vs)
+	return evicted
+}
+
+// Get looks up a key's value from the cache, recording a reference.
+func (c *LRUKCache[K, V]) Get(key K) (value V, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	e, ok := c.items[key]
+	if !ok {
+		return value, false
+	}
+	c.reference(e)
+	heap.Fix(&c.heap, e.index)
+	return e.value, true
+}
+
+// Peek returns the key value (or undefined if not found) without recording
+// a reference.
+func (c *LRUKCache[K, V]) Peek(key K) (value V, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if e, ok := c.items[key]; ok {
+		return e.value, true
+	}
+	return value, false
+}
+
+// Contains checks if a key is in the cache, without recording a reference.
+func (c *LRUKCache[K, V]) Contains(key K) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	_, ok := c.items[key]
+	return ok
+}
+
+// Remove removes the provided key from the cache, returning true if the key
+// was contained. Its references are forgotten.
+func (c *LRUKCache[K, V]) Remove(key K) (present bool) {
+	c.lock.Lock()
+	e, present := c.items[key]
+	if present {
+		c.removeEntry(e)
+	} else {
+		c.history.Remove(key)
+	}
+	ks, vs := c.takeEvicted()
+	c.lock.Unlock()
+	c.notify(ks, vs)
+	return present
+}
+
+// Keys returns a slice of the keys in the cache, in no particular order.
+func (c *LRUKCache[K, V]) Keys() []K {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	keys := make([]K, len(c.heap.entries))
+	for i, e := range c.heap.entries {
+		keys[i] = e.key
+	}
+	return keys
+}
+
+// Len returns the number of items in the cache.
+func (c *LRUKCache[K, V]) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.items)
+}
+
+// Resize changes the cache size, and that of its history, returning the
+// number of entries evicted.
+func (c *LRUKCache[K, V]) Resize(size int) (evicted int) {
+	if size <= 0 {
+		return 0
+	}
+	c.lock.Lock()
+	for len(c.items) > size {
+		c.evict()
+		evicted++
+	}
+	c.size = size
+	c.history.Resize(size)
+	ks, vs := c.takeEvicted()
+	c.lock.Unlock()
+	c.notify(ks, vs)
+	return evicted
+}
+
+// Purge is used to completely clear the cache, including its history.
+func (c *LRUKCache[K, V]) Purge() {
+	c.lock.Lock()
+	for len(c.heap.entries) > 0 {
+		c.removeEntry(c.heap.entries[len(c.heap.entries)-1])
+	}
+	c.history.Purge()
+	ks, vs := c.takeEvicted()
+	c.lock.Unlock()
+	c.notify(ks, vs)
+}
@@ -0,0 +1,72 @@
+package dailzLRU
+
+import "testing"
+
+func TestLRUK(t *testing.T) {
+	var evicted []int
+	l, err := NewLRUK(4, 2, func(k, v int) {
+		evicted = append(evicted, k)
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		l.Add(i, i)
+	}
+	// 1 and 2 are referenced twice, so 0 and then 3 go first
+	l.Get(1)
+	l.Get(2)
+	if !l.Add(4, 4) || !l.Add(5, 5) {
+		t.Fatalf("should have evicted")
+	}
+	if len(evicted) != 2 || evicted[0] != 0 || evicted[1] != 3 {
+		t.Fatalf("bad evictions: %v", evicted)
+	}
+	// a scan does not displace keys referenced twice
+	for i := 10; i < 20; i++ {
+		l.Add(i, i)
+	}
+	if !l.Contains(1) || !l.Contains(2) || l.Len() != 4 {
+		t.Fatalf("scan displaced the working set: %v", l.Keys())
+	}
+	// of the keys referenced twice, the oldest second reference goes first
+	l.Get(18)
+	l.Get(19)
+	l.Add(20, 20)
+	if l.Contains(1) || !l.Contains(2) || !l.Contains(18) || !l.Contains(19) {
+		t.Fatalf("bad eviction order: %v", l.Keys())
+	}
+
+	if !l.Remove(2) || l.Contains(2) || l.Remove(2) {
+		t.Fatalf("bad remove")
+	}
+	if n := l.Resize(1); n != 2 || l.Len() != 1 {
+		t.Fatalf("bad resize: %v, %v", n, l.Len())
+	}
+	l.Purge()
+	if l.Len() != 0 || l.history.Len() != 0 {
+		t.Fatalf("bad purge")
+	}
+	if _, err := NewLRUK[int, int](4, 0, nil); err == nil {
+		t.Fatalf("should reject k of 0")
+	}
+}
+
+// Test that an evicted key added back keeps the references it had
+func TestLRUK_History(t *testing.T) {
+	l, err := NewLRUK[int, int](2, 2, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Add(3, 3) // evicts 1 into the history
+	if l.Contains(1) {
+		t.Fatalf("1 should be evicted")
+	}
+	l.Add(1, 1) // second reference, evicts 2
+	l.Add(4, 4) // evicts 3, the only key referenced once
+	if !l.Contains(1) || !l.Contains(4) || l.Contains(3) {
+		t.Fatalf("history not used: %v", l.Keys())
+	}
+}
@@ -0,0 +1,263 @@
+package dailzLRU
+
+import (
+	"context"
+	"errors"
+	"hash/maphash"
+	"math"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultMemoizeErrorCacheSize is the number of errors cached by a memoized
+// function caching errors, unless MemoizeOptions sets another.
+const DefaultMemoizeErrorCacheSize = 1024
+
+// errMemoizePanic is returned to callers waiting on a memoized call that
+// panicked
+var errMemoizePanic = errors.New("memoized function panicked")
+
+// memoCall is a call of a memoized function in flight
+type memoCall[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// memoError is a cached error of a memoized function
+type memoError struct {
+	err     error
+	expires time.Time
+}
+
+// memoShard tracks the calls in flight of the keys hashing to it
+type memoShard[K comparable, V any] struct {
+	calls map[K]*memoCall[V]
+	lock  sync.Mutex
+}
+
+// expiryReporter is a cache reporting when its entries expire, such as
+// ExpirableCache
+type expiryReporter[K comparable, V any] interface {
+	GetWithExpiry(key K) (value V, expiresAt time.Time, ok bool)
+}
+
+// MemoizeOptions configures MemoizeWithOptions.
+type MemoizeOptions struct {
+	// EarlyRefresh enables probabilistic early expiration, the XFetch
+	// algorithm, if the cache reports when its entries expire, as
+	// ExpirableCache does. A call finding a cached result computes it again
+	// anyway, with a probability rising as the expiry nears and the longer
+	// the function takes, so a hot key is refreshed by one caller before it
+	// expires rather than by all of them once it has. Callers arriving
+	// during the refresh are served the cached result. EarlyRefresh is the
+	// beta of XFetch: 1 is the usual choice, larger values refresh earlier
+	// and 0 disables early refresh.
+	EarlyRefresh float64
+	// ErrorTTL is how long an error of the function is cached and returned
+	// to the callers of its key, so a failing upstream is not called again
+	// on every miss. Zero disables the caching of errors. Panics and the
+	// context errors of the caller making a call are never cached.
+	ErrorTTL time.Duration
+	// CacheError, if not nil, reports whether an error is to be cached, so
+	// that errors worth retrying at once are not. Nil caches every error.
+	CacheError func(err error) bool
+	// ErrorCacheSize is the number of errors cached, least recently used
+	// first out. Zero means DefaultMemoizeErrorCacheSize.
+	ErrorCacheSize int
+}
+
+// memoizer holds the state of a memoized function
+type memoizer[K comparable, V any] struct {
+	c      Interface[K, V]
+	expiry expiryReporter[K, V] // c, if it refreshes results early
+	fn     func(ctx context.Context, key K) (V, error)
+	beta   float64
+	delta  atomic.Int64 // moving average of the durations of fn, in nanoseconds
+	shards []memoShard[K, V]
+	seed   maphash.Seed
+	errs   *Cache[K, memoError] // nil unless errors are cached
+	opts   MemoizeOptions
+}
+
+// Memoize returns a function caching the results of fn in c. A call whose
+// key is in c returns the cached value; otherwise fn is called and a
+// successful result added to c. Concurrent calls for a key that is not
+// cached share a single call to fn, and a caller waiting on it returns early
+// if its own context is done. Errors are not cached, unless
+// MemoizeOptions.ErrorTTL says otherwise, but are returned to every caller
+// sharing the call, except for the context errors of the caller that made
+// it, which make the others try again. Calls in flight are tracked in
+// DefaultShardCount shards by key hash, so callers missing on different
+// keys rarely contend.
+//
+// Results stay cached until c evicts them. For results to expire, pass an
+// ExpirableCache as c: Memoize only uses Interface, so the cache's TTL
+// applies to the results as to any other entry.
+func Memoize[K comparable, V any](c Interface[K, V], fn func(ctx context.Context, key K) (V, error)) func(ctx context.Context, key K) (V, error) {
+	memoized, _ := MemoizeWithOptions[K, V](c, fn, MemoizeOptions{})
+	return memoized
+}
+
+// MemoizeWithOptions is like Memoize, configured by opts. It fails if
+// opts.ErrorCacheSize is negative.
+func MemoizeWithOptions[K comparable, V any](c Interface[K, V], fn func(ctx context.Context, key K) (V, error), opts MemoizeOptions) (func(ctx context.Context, key K) (V, error), error) {
+	m := &memoizer[K, V]{
+		c:      c,
+		fn:     fn,
+		beta:   opts.EarlyRefresh,
+		shards: make([]memoShard[K, V], DefaultShardCount()),
+		seed:   maphash.MakeSeed(),
+		opts:   opts,
+	}
+	if opts.ErrorTTL > 0 {
+		size := opts.ErrorCacheSize
+		if size == 0 {
+			size = DefaultMemoizeErrorCacheSize
+		}
+		errs, err := New[K, memoError](size)
+		if err != nil {
+			return nil, err
+		}
+		m.errs = errs
+	}
+	for i := range m.shards {
+		m.shards[i].calls = make(map[K]*memoCall[V])
+	}
+	if m.beta > 0 {
+		m.expiry, _ = c.(expiryReporter[K, V])
+	}
+	return m.call, nil
+}
+
+// call returns the result for key, from the cache or from fn
+func (m *memoizer[K, V]) call(ctx context.Context, key K) (V, error) {
+	for {
+		v, fresh, ok := m.cached(key)
+		if fresh {
+			return v, nil
+		}
+		if err := m.cachedError(key); err != nil {
+			if ok {
+				return v, nil
+			}
+			return v, err
+		}
+		shard := m.shard(key)
+		shard.lock.Lock()
+		call, running := shard.calls[key]
+		if !running {
+			call = &memoCall[V]{done: make(chan struct{})}
+			shard.calls[key] = call
+		}
+		shard.lock.Unlock()
+		if !running {
+			m.run(ctx, shard, key, call)
+			if call.err != nil && ok {
+				// the early refresh failed, but the cached result is
+				// still valid
+				return v, nil
+			}
+			return call.value, call.err
+		}
+		if ok {
+			// another caller is refreshing the result early
+			return v, nil
+		}
+
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			var zero V
+			return zero, ctx.Err()
+		}
+		if (errors.Is(call.err, context.Canceled) || errors.Is(call.err, context.DeadlineExceeded)) && ctx.Err() == nil {
+			continue
+		}
+		return call.value, call.err
+	}
+}
+
+// shard returns the shard tracking the calls of key
+func (m *memoizer[K, V]) shard(key K) *memoShard[K, V] {
+	return &m.shards[hashKey(m.seed, key)%uint64(len(m.shards))]
+}
+
+// cached looks key up in the cache. fresh is false if the result was not
+// found, or, with ok true, if the caller is to refresh it early.
+func (m *memoizer[K, V]) cached(key K) (v V, fresh, ok bool) {
+	if m.expiry == nil {
+		v, ok = m.c.Get(key)
+		return v, ok, ok
+	}
+	v, expiresAt, ok := m.expiry.GetWithExpiry(key)
+	if !ok {
+		return v, false, false
+	}
+	// XFetch refreshes once now - delta * beta * ln(rand) reaches the expiry
+	gap := time.Duration(float64(m.delta.Load()) * m.beta * -math.Log(rand.Float64()))
+	return v, time.Now().Add(gap).Before(expiresAt), true
+}
+
+// cachedError returns the unexpired cached error of key, if any
+func (m *memoizer[K, V]) cachedError(key K) error {
+	if m.errs == nil {
+		return nil
+	}
+	e, ok := m.errs.Get(key)
+	if !ok {
+		return nil
+	}
+	if !time.Now().Before(e.expires) {
+		return nil
+	}
+	return e.err
+}
+
+// cacheError caches err, returned by the call of key, if it is to be
+// cached
+func (m *memoizer[K, V]) cacheError(key K, err error) {
+	if m.errs == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+	if m.opts.CacheError != nil && !m.opts.CacheError(err) {
+		return
+	}
+	m.errs.Add(key, memoError{err: err, expires: time.Now().Add(m.opts.ErrorTTL)})
+}
+
+// run makes a call of fn, caching its result, and releases the callers
+// waiting on it even if fn panics
+func (m *memoizer[K, V]) run(ctx context.Context, shard *memoShard[K, V], key K, call *memoCall[V]) {
+	call.err = errMemoizePanic
+	defer func() {
+		shard.lock.Lock()
+		delete(shard.calls, key)
+		shard.lock.Unlock()
+		close(call.done)
+	}()
+	start := time.Now()
+	call.value, call.err = m.fn(ctx, key)
+	if call.err != nil {
+		m.cacheError(key, call.err)
+		return
+	}
+	m.observe(time.Since(start))
+	m.c.Add(key, call.value)
+}
+
+// observe folds the duration of a successful call of fn into the moving
+// average early refreshes are scaled by
+func (m *memoizer[K, V]) observe(d time.Duration) {
+	if m.expiry == nil {
+		return
+	}
+	old := m.delta.Load()
+	if old == 0 {
+		m.delta.Store(int64(d))
+		return
+	}
+	m.delta.Store(old + (int64(d)-old)/8)
+}
@@ -0,0 +1,311 @@
+package dailzLRU
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoize(t *testing.T) {
+	c, err := New[int, int](8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var calls atomic.Int32
+	fail := errors.New("odd key")
+	square := Memoize[int, int](c, func(ctx context.Context, k int) (int, error) {
+		calls.Add(1)
+		if k%2 == 1 {
+			return 0, fail
+		}
+		return k * k, nil
+	})
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if v, err := square(ctx, 4); err != nil || v != 16 {
+			t.Fatalf("bad result: %v, %v", v, err)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("result not cached: %v calls", n)
+	}
+	if v, ok := c.Peek(4); !ok || v != 16 {
+		t.Fatalf("result not in the cache: %v, %v", v, ok)
+	}
+	square(ctx, 3)
+	if _, err := square(ctx, 3); err != fail {
+		t.Fatalf("bad error: %v", err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Fatalf("error cached: %v calls", n)
+	}
+}
+
+func TestMemoize_Concurrent(t *testing.T) {
+	c, err := New[string, string](8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var calls atomic.Int32
+	release := make(chan struct{})
+	slow := Memoize[string, string](c, func(ctx context.Context, k string) (string, error) {
+		calls.Add(1)
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+		return k + "!", nil
+	})
+
+	// the first caller gives up, and a waiter takes over the call
+	first, cancel := context.WithCancel(context.Background())
+	go slow(first, "a")
+	for calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := slow(context.Background(), "a"); err != nil || v != "a!" {
+				t.Errorf("bad result: %v, %v", v, err)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	for calls.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("concurrent calls not shared: %v calls", n)
+	}
+
+	// a waiter's context bounds its wait
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	started := make(chan struct{})
+	block := Memoize[string, string](c, func(ctx context.Context, k string) (string, error) {
+		close(started)
+		time.Sleep(50 * time.Millisecond)
+		return k, nil
+	})
+	go block(context.Background(), "b")
+	<-started
+	if _, err := block(ctx, "b"); err != context.DeadlineExceeded {
+		t.Fatalf("bad error: %v", err)
+	}
+}
+
+func TestMemoize_Expirable(t *testing.T) {
+	c, err := NewExpirable[string, int](8, 20*time.Millisecond, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer c.Close()
+	var calls atomic.Int32
+	length := Memoize[string, int](c, func(ctx context.Context, k string) (int, error) {
+		calls.Add(1)
+		return len(k), nil
+	})
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if v, err := length(ctx, "abc"); err != nil || v != 3 {
+			t.Fatalf("bad result: %v, %v", v, err)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("result not cached: %v calls", n)
+	}
+	// the result expires with its entry and is computed again
+	time.Sleep(30 * time.Millisecond)
+	if v, err := length(ctx, "abc"); err != nil || v != 3 || calls.Load() != 2 {
+		t.Fatalf("expired result served: %v, %v, %v calls", v, err, calls.Load())
+	}
+}
+
+func TestMemoize_EarlyRefresh(t *testing.T) {
+	c, err := NewExpirable[string, int](8, time.Minute, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer c.Close()
+	var calls atomic.Int32
+	release := make(chan struct{})
+	fail := errors.New("upstream down")
+	fn := func(ctx context.Context, k string) (int, error) {
+		switch n := calls.Add(1); n {
+		case 1:
+			time.Sleep(time.Millisecond)
+		case 2:
+			<-release
+		case 3:
+			return 0, fail
+		}
+		return int(calls.Load()), nil
+	}
+	// a beta this large makes every call refresh the result early
+	refreshing, err := MemoizeWithOptions[string, int](c, fn, MemoizeOptions{EarlyRefresh: 1e9})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	ctx := context.Background()
+	if v, err := refreshing(ctx, "a"); err != nil || v != 1 {
+		t.Fatalf("bad result: %v, %v", v, err)
+	}
+	// callers arriving during an early refresh get the cached result
+	done := make(chan int)
+	go func() {
+		v, _ := refreshing(ctx, "a")
+		done <- v
+	}()
+	for calls.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	for i := 0; i < 3; i++ {
+		if v, err := refreshing(ctx, "a"); err != nil || v != 1 {
+			t.Fatalf("bad result during the refresh: %v, %v", v, err)
+		}
+	}
+	close(release)
+	if v := <-done; v != 2 {
+		t.Fatalf("bad refreshed result: %v", v)
+	}
+	// a failed refresh serves the cached result
+	if v, err := refreshing(ctx, "a"); err != nil || v != 2 {
+		t.Fatalf("bad result after a failed refresh: %v, %v", v, err)
+	}
+	if v, ok := c.Peek("a"); !ok || v != 2 || calls.Load() != 3 {
+		t.Fatalf("bad cached result: %v, %v, %v calls", v, ok, calls.Load())
+	}
+
+	// without early refresh, the result is served until it expires
+	plain := Memoize[string, int](c, fn)
+	for i := 0; i < 3; i++ {
+		if v, err := plain(ctx, "a"); err != nil || v != 2 {
+			t.Fatalf("bad result: %v, %v", v, err)
+		}
+	}
+	if n := calls.Load(); n != 3 {
+		t.Fatalf("result refreshed early: %v calls", n)
+	}
+}
+
+func TestMemoize_PerKey(t *testing.T) {
+	c, err := New[int, int](1024)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var calls atomic.Int32
+	release := make(chan struct{})
+	double := Memoize[int, int](c, func(ctx context.Context, k int) (int, error) {
+		calls.Add(1)
+		if k == 0 {
+			<-release
+		}
+		return 2 * k, nil
+	})
+
+	// a slow call of one key does not hold up the others
+	ctx := context.Background()
+	done := make(chan struct{})
+	go func() {
+		double(ctx, 0)
+		close(done)
+	}()
+	for calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := 1; k <= 500; k++ {
+				if v, err := double(ctx, k); err != nil || v != 2*k {
+					t.Errorf("bad result for %v: %v, %v", k, v, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(release)
+	<-done
+	if v, ok := c.Peek(0); !ok || v != 0 || c.Len() != 501 {
+		t.Fatalf("results not cached: %v, %v, %v", v, ok, c.Len())
+	}
+}
+
+func TestMemoize_ErrorTTL(t *testing.T) {
+	c, err := New[string, int](8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var calls atomic.Int32
+	permanent := errors.New("not found")
+	retryable := errors.New("try again")
+	lookup, err := MemoizeWithOptions[string, int](c, func(ctx context.Context, k string) (int, error) {
+		calls.Add(1)
+		switch k {
+		case "missing":
+			return 0, permanent
+		case "flaky":
+			return 0, retryable
+		case "slow":
+			<-ctx.Done()
+			return 0, ctx.Err()
+		}
+		return len(k), nil
+	}, MemoizeOptions{
+		ErrorTTL:   50 * time.Millisecond,
+		CacheError: func(err error) bool { return err != retryable },
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if _, err := lookup(ctx, "missing"); err != permanent {
+			t.Fatalf("bad error: %v", err)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("error not cached: %v calls", n)
+	}
+	lookup(ctx, "flaky")
+	lookup(ctx, "flaky")
+	if n := calls.Load(); n != 3 {
+		t.Fatalf("retryable error cached: %v calls", n)
+	}
+	short, cancel := context.WithTimeout(ctx, time.Millisecond)
+	lookup(short, "slow")
+	cancel()
+	short, cancel = context.WithTimeout(ctx, time.Millisecond)
+	defer cancel()
+	if _, err := lookup(short, "slow"); err != context.DeadlineExceeded {
+		t.Fatalf("bad error: %v", err)
+	}
+	if n := calls.Load(); n != 5 {
+		t.Fatalf("context error cached: %v calls", n)
+	}
+
+	// the error expires and the function is called again
+	time.Sleep(100 * time.Millisecond)
+	if _, err := lookup(ctx, "missing"); err != permanent || calls.Load() != 6 {
+		t.Fatalf("expired error served: %v, %v calls", err, calls.Load())
+	}
+
+	if _, err := MemoizeWithOptions[string, int](c, nil, MemoizeOptions{ErrorTTL: time.Second, ErrorCacheSize: -1}); err == nil {
+		t.Fatalf("expected error for a negative error cache size")
+	}
+}
@@ -0,0 +1,166 @@
+package dailzLRU
+
+import "sync/atomic"
+
+// Interface is the set of operations shared by the caches of this package.
+// *Cache, *TwoQueueCache and *Tiered implement it, and so does every cache
+// returned by Chain, so wrappers adding orthogonal features can be stacked.
+type Interface[K comparable, V any] interface {
+	Get(key K) (value V, ok bool)
+	Peek(key K) (value V, ok bool)
+	Contains(key K) bool
+	Add(key K, value V) (evicted bool)
+	Remove(key K) (present bool)
+	Keys() []K
+	Len() int
+	Purge()
+}
+
+// Middleware wraps a cache to add a feature to it.
+type Middleware[K comparable, V any] func(next Interface[K, V]) Interface[K, V]
+
+// Chain wraps base in the given middlewares. The first middleware is the
+// outermost one, so it sees every call before the others do.
+func Chain[K comparable, V any](base Interface[K, V], middlewares ...Middleware[K, V]) Interface[K, V] {
+	c := base
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		c = middlewares[i](c)
+	}
+	return c
+}
+
+// Wrapper forwards every call to Next. Embedding it lets a middleware
+// override only the operations it cares about.
+type Wrapper[K comparable, V any] struct {
+	Next Interface[K, V]
+}
+
+func (w Wrapper[K, V]) Get(key K) (value V, ok bool) {
+	return w.Next.Get(key)
+}
+
+func (w Wrapper[K, V]) Peek(key K) (value V, ok bool) {
+	return w.Next.Peek(key)
+}
+
+func (w Wrapper[K, V]) Contains(key K) bool {
+	return w.Next.Contains(key)
+}
+
+func (w Wrapper[K, V]) Add(key K, value V) (evicted bool) {
+	return w.Next.Add(key, value)
+}
+
+func (w Wrapper[K, V]) Remove(key K) (present bool) {
+	return w.Next.Remove(key)
+}
+
+func (w Wrapper[K, V]) Keys() []K {
+	return w.Next.Keys()
+}
+
+func (w Wrapper[K, V]) Len() int {
+	return w.Next.Len()
+}
+
+func (w Wrapper[K, V]) Purge() {
+	w.Next.Purge()
+}
+
+// EvictionAges forwards to Next if it records eviction ages, such as a
+// *Cache, and returns empty histograms otherwise.
+func (w Wrapper[K, V]) EvictionAges() EvictionAges {
+	if r, ok := w.Next.(ageReporter); ok {
+		return r.EvictionAges()
+	}
+	return EvictionAges{}
+}
+
+// ageReporter is implemented by caches that record EvictionAges
+type ageReporter interface {
+	EvictionAges() EvictionAges
+}
+
+// CloneOnRead returns a middleware that passes values returned by Get and
+// Peek through clone, so callers can modify them without corrupting the
+// cached copy.
+func CloneOnRead[K comparable, V any](clone func(V) V) Middleware[K, V] {
+	return func(next Interface[K, V]) Interface[K, V] {
+		return cloneOnRead[K, V]{Wrapper: Wrapper[K, V]{Next: next}, clone: clone}
+	}
+}
+
+type cloneOnRead[K comparable, V any] struct {
+	Wrapper[K, V]
+	clone func(V) V
+}
+
+func (c cloneOnRead[K, V]) Get(key K) (value V, ok bool) {
+	if value, ok = c.Next.Get(key); ok {
+		value = c.clone(value)
+	}
+	return value, ok
+}
+
+func (c cloneOnRead[K, V]) Peek(key K) (value V, ok bool) {
+	if value, ok = c.Next.Peek(key); ok {
+		value = c.clone(value)
+	}
+	return value, ok
+}
+
+// Stats holds the counters maintained by the CountStats middleware.
+type Stats struct {
+	Hits      atomic.Uint64
+	Misses    atomic.Uint64
+	Evictions atomic.Uint64
+	ages      atomic.Pointer[ageSource]
+}
+
+// ageSource holds the cache Stats reads EvictionAges from
+type ageSource struct {
+	r ageReporter
+}
+
+// EvictionAges returns the eviction ages recorded by the cache wrapped by
+// CountStats, as Cache.EvictionAges does, or empty histograms if it does not
+// record them. Recording is started with TrackEvictionAges on the cache.
+func (s *Stats) EvictionAges() EvictionAges {
+	if src := s.ages.Load(); src != nil {
+		return src.r.EvictionAges()
+	}
+	return EvictionAges{}
+}
+
+// CountStats returns a middleware that counts the hits and misses of Get and
+// the evictions reported by Add into s. If the wrapped cache records
+// eviction ages, s reports them too.
+func CountStats[K comparable, V any](s *Stats) Middleware[K, V] {
+	return func(next Interface[K, V]) Interface[K, V] {
+		if r, ok := next.(ageReporter); ok {
+			s.ages.Store(&ageSource{r: r})
+		}
+		return countStats[K, V]{Wrapper: Wrapper[K, V]{Next: next}, stats: s}
+	}
+}
+
+type countStats[K comparable, V any] struct {
+	Wrapper[K, V]
+	stats *Stats
+}
+
+func (c countStats[K, V]) Get(key K) (value V, ok bool) {
+	if value, ok = c.Next.Get(key); ok {
+		c.stats.Hits.Add(1)
+	} else {
+		c.stats.Misses.Add(1)
+	}
+	return value, ok
+}
+
+func (c countStats[K, V]) Add(key K, value V) (evicted bool) {
+	if evicted = c.Next.Add(key, value); evicted {
+		c.stats.Evictions.Add(1)
+	}
+	return evicted
+}
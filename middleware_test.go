@@ -0,0 +1,74 @@
+package dailzLRU
+
+import (
+	"testing"
+	"time"
+)
+
+var (
+	_ Interface[int, int] = (*Cache[int, int])(nil)
+	_ Interface[int, int] = (*TwoQueueCache[int, int])(nil)
+	_ Interface[int, int] = (*Tiered[int, int])(nil)
+	_ Interface[int, int] = (*ExpirableCache[int, int])(nil)
+	_ Interface[int, int] = (*SampledCache[int, int])(nil)
+	_ Interface[int, int] = (*RandomCache[int, int])(nil)
+	_ Interface[int, int] = (*PriorityCache[int, int])(nil)
+	_ Interface[int, int] = (*Sharded[int, int])(nil)
+	_ Interface[int, int] = (*ClockProCache[int, int])(nil)
+	_ Interface[int, int] = (*LRUKCache[int, int])(nil)
+	_ Interface[int, int] = (*MQCache[int, int])(nil)
+)
+
+func TestChain(t *testing.T) {
+	base, err := New[int, []int](2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var stats Stats
+	c := Chain[int, []int](base,
+		CountStats[int, []int](&stats),
+		CloneOnRead[int, []int](func(v []int) []int { return append([]int(nil), v...) }),
+	)
+
+	c.Add(1, []int{1})
+	v, ok := c.Get(1)
+	if !ok || v[0] != 1 {
+		t.Fatalf("bad value: %v, %v", v, ok)
+	}
+	v[0] = 100
+	if v, _ := c.Peek(1); v[0] != 1 {
+		t.Fatalf("cached value was modified through a read: %v", v)
+	}
+	c.Get(2)
+	c.Add(2, []int{2})
+	c.Add(3, []int{3})
+
+	if stats.Hits.Load() != 1 || stats.Misses.Load() != 1 || stats.Evictions.Load() != 1 {
+		t.Fatalf("bad stats: hits %v, misses %v, evictions %v", stats.Hits.Load(), stats.Misses.Load(), stats.Evictions.Load())
+	}
+	if c.Len() != 2 || base.Contains(1) {
+		t.Fatalf("calls were not forwarded: %v", c.Keys())
+	}
+}
+
+func TestCountStats_EvictionAges(t *testing.T) {
+	base, err := New[int, int](1)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	base.TrackEvictionAges(time.Hour)
+	var stats Stats
+	if ages := stats.EvictionAges(); ages.SinceAdd.Total() != 0 {
+		t.Fatalf("ages reported without a cache: %+v", ages)
+	}
+	// the ages are found through the middlewares in between
+	c := Chain[int, int](base,
+		CountStats[int, int](&stats),
+		CloneOnRead[int, int](func(v int) int { return v }),
+	)
+	c.Add(1, 1)
+	c.Add(2, 2)
+	if ages := stats.EvictionAges(); ages.SinceAdd.Total() != 1 || ages.SinceAccess.Counts[0] != 1 {
+		t.Fatalf("bad ages: %+v", ages)
+	}
+}
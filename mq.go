@@ -0,0 +1,306 @@
+package dailzLRU
+
+import (
+	"errors"
+	"github.com/dailz1/dailzLRU/lru"
+	"math/bits"
+	"sync"
+)
+
+// DefaultMQQueues is the number of queues used by NewMQ.
+const DefaultMQQueues = 8
+
+// mqEntry is a value of an MQCache along with its access metadata
+type mqEntry[V any] struct {
+	value  V
+	freq   uint64 // number of accesses, including those before an eviction
+	expire uint64 // time at which the entry is demoted a queue
+	queue  int
+}
+
+// MQCache is a thread-safe fixed size cache using the Multi-Queue policy,
+// designed for second-level caches that only see the misses of another
+// cache and thus little recency. Entries live in one of several LRU queues
+// by access frequency: queue i holds entries accessed at least 2^i times.
+// The least recently used entry of the lowest non-empty queue is evicted.
+// An entry not accessed within its lifetime is demoted a queue, so entries
+// that were popular once age out. The frequencies of evicted keys are kept
+// in a history of the same size as the cache, and restored if they come
+// back.
+type MQCache[K comparable, V any] struct {
+	size        int
+	lifetime    uint64
+	now         uint64
+	queues      []*lru.LRU[K, *mqEntry[V]]
+	history     *lru.LRU[K, uint64]
+	evictedKeys []K
+	evictedVals []V
+	onEvictedCB func(k K, v V)
+	lock        sync.Mutex
+}
+
+// NewMQ constructs an MQCache of the given size with DefaultMQQueues queues
+// and a lifetime of size accesses. onEvicted, if not nil, is called outside
+// of the cache's lock for entries that are evicted or removed.
+func NewMQ[K comparable, V any](size int, onEvicted func(key K, value V)) (*MQCache[K, V], error) {
+	return NewMQWithQueues[K, V](size, DefaultMQQueues, uint64(size), onEvicted)
+}
+
+// NewMQWithQueues constructs an MQCache with the given number of queues and
+// lifetime, counted in accesses to the cache. The lifetime should be about
+// the number of accesses between two uses of an entry worth keeping.
+func NewMQWithQueues[K comparable, V any](size, queues int, lifetime uint64, onEvicted func(key K, value V)) (*MQCache[K, V], error) {
+	if size <= 0 {
+		return nil, errors.New("must provide a positive size")
+	}
+	if queues <= 0 {
+		return nil, errors.New("must provide a positive queue count")
+	}
+	if lifetime == 0 {
+		return nil, errors.New("must provide a positive lifetime")
+	}
+	c := &MQCache[K, V]{
+		size:        size,
+		lifetime:    lifetime,
+		queues:      make([]*lru.LRU[K, *mqEntry[V]], queues),
+		onEvictedCB: onEvicted,
+	}
+	for i := range c.queues {
+		// a queue never holds more than the whole cache, so the queues
+		// themselves never evict
+		q, err := lru.NewLRU[K, *mqEntry[V]](size, nil)
+		if err != nil {
+			return nil, err
+		}
+		c.queues[i] = q
+	}
+	history, err := lru.NewLRU[K, uint64](size, nil)
+	if err != nil {
+		return nil, err
+	}
+	c.history = history
+	return c, nil
+}
+
+// queueFor returns the queue of entries accessed freq times
+func (c *MQCache[K, V]) queueFor(freq uint64) int {
+	q := bits.Len64(freq) - 1
+	if q >= len(c.queues) {
+		q = len(c.queues) - 1
+	}
+	return q
+}
+
+// lookup returns the entry of key
+func (c *MQCache[K, V]) lookup(key K) (e *mqEntry[V], ok bool) {
+	for _, q := range c.queues {
+		if e, ok := q.Peek(key); ok {
+			return e, true
+		}
+	}
+	return nil, false
+}
+
+// access counts an access to the entry of key, moving it to the most
+// recently used end of the queue for its new frequency
+func (c *MQCache[K, V]) access(key K, e *mqEntry[V]) {
+	c.now++
+	e.freq++
+	e.expire = c.now + c.lifetime
+	if q := c.queueFor(e.freq); q != e.queue {
+		c.queues[e.queue].Remove(key)
+		e.queue = q
+		c.queues[q].Add(key, e)
+	} else {
+		c.queues[q].Get(key)
+	}
+	c.demote()
+}
+
+// demote moves the least recently used entry of each queue down a queue if
+// its lifetime has passed
+func (c *MQCache[K, V]) demote() {
+	for i := 1; i < len(c.queues); i++ {
+		if k, e, ok := c.queues[i].GetOldest(); ok && e.expire < c.now {
+			c.queues[i].Remove(k)
+			e.queue = i - 1
+			e.expire = c.now + c.lifetime
+			c.queues[i-1].Add(k, e)
+		}
+	}
+}
+
+// len returns the number of entries in all queues
+func (c *MQCache[K, V]) len() (n int) {
+	for _, q := range c.queues {
+		n += q.Len()
+	}
+	return n
+}
+
+// evict removes the least recently used entry of the lowest non-empty queue,
+// remembering its frequency in the history
+func (c *MQCache[K, V]) evict() {
+	for _, q := range c.queues {
+		if k, e, ok := q.RemoveOldest(); ok {
+			c.history.Add(k, e.freq)
+			c.buffer(k, e.value)
+			return
+		}
+	}
+}
+
+// buffer queues the eviction callback of an entry leaving the cache
+func (c *MQCache[K, V]) buffer(k K, v V) {
+	if c.onEvictedCB != nil {
+		c.evictedKeys = append(c.evictedKeys, k)
+		c.evictedVals = append(c.evictedVals, v)
+	}
+}
+
+// takeEvicted hands back the buffered evictions and resets the buffers
+func (c *MQCache[K, V]) takeEvicted() (ks []K, vs []V) {
+	ks, vs = c.evictedKeys, c.evictedVals
+	c.evictedKeys, c.evictedVals = nil, nil
+	return ks, vs
+}
+
+// notify invokes the eviction callback for buffered evictions
+func (c *MQCache[K, V]) notify(ks []K, vs []V) {
+	for i := 0; i < len(ks); i++ {
+		c.onEvictedCB(ks[i], vs[i])
+	}
+}
+
+// Add adds a value to the cache, counting as an access. Returns true if an
+// eviction occurred.
+func (c *MQCache[K, V]) Add(key K, value V) (evicted bool) {
+	c.lock.Lock()
+	if e, ok := c.lookup(key); ok {
+		e.value = value
+		c.access(key, e)
+		c.lock.Unlock()
+		return false
+	}
+	e := &mqEntry[V]{value: value}
+	if freq, ok := c.history.Peek(key); ok {
+		c.history.Remove(key)
+		e.freq = freq
+	}
+	if c.len() >= c.size {
+		c.evict()
+		evicted = true
+	}
+	e.queue = c.queueFor(e.freq + 1)
+	c.queues[e.queue].Add(key, e)
+	c.access(key, e)
+	ks, vs := c.takeEvicted()
+	c.lock.Unlock()
+	c.notify(ks, vs)
+	return evicted
+}
+
+// Get looks up a key's value from the cache, counting as an access.
+func (c *MQCache[K, V]) Get(key K) (value V, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	e, ok := c.lookup(key)
+	if !ok {
+		return value, false
+	}
+	c.access(key, e)
+	return e.value, true
+}
+
+// Peek returns the key value (or undefined if not found) without counting
+// an access.
+func (c *MQCache[K, V]) Peek(key K) (value V, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if e, ok := c.lookup(key); ok {
+		return e.value, true
+	}
+	return value, false
+}
+
+// Contains checks if a key is in the cache, without counting an access.
+func (c *MQCache[K, V]) Contains(key K) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	_, ok := c.lookup(key)
+	return ok
+}
+
+// Remove removes the provided key from the cache, returning true if the key
+// was contained. Its frequency is forgotten.
+func (c *MQCache[K, V]) Remove(key K) (present bool) {
+	c.lock.Lock()
+	if e, ok := c.lookup(key); ok {
+		c.queues[e.queue].Remove(key)
+		c.buffer(key, e.value)
+		present = true
+	} else {
+		c.history.Remove(key)
+	}
+	ks, vs := c.takeEvicted()
+	c.lock.Unlock()
+	c.notify(ks, vs)
+	return present
+}
+
+// Keys returns a slice of the keys in the cache, from the lowest to the
+// highest queue and from oldest to newest within a queue.
+func (c *MQCache[K, V]) Keys() []K {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	keys := make([]K, 0, c.len())
+	for _, q := range c.queues {
+		keys = append(keys, q.Keys()...)
+	}
+	return keys
+}
+
+// Len returns the number of items in the cache.
+func (c *MQCache[K, V]) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.len()
+}
+
+// Resize changes the cache size, and that of its history, returning the
+// number of entries evicted.
+func (c *MQCache[K, V]) Resize(size int) (evicted int) {
+	if size <= 0 {
+		return 0
+	}
+	c.lock.Lock()
+	for c.len() > size {
+		c.evict()
+		evicted++
+	}
+	c.size = size
+	for _, q := range c.queues {
+		q.Resize(size)
+	}
+	c.history.Resize(size)
+	ks, vs := c.takeEvicted()
+	c.lock.Unlock()
+	c.notify(ks, vs)
+	return evicted
+}
+
+// Purge is used to completely clear the cache, including its history.
+func (c *MQCache[K, V]) Purge() {
+	c.lock.Lock()
+	for _, q := range c.queues {
+		q.Range(func(k K, e *mqEntry[V]) bool {
+			c.buffer(k, e.value)
+			return true
+		})
+		q.Purge()
+	}
+	c.history.Purge()
+	ks, vs := c.takeEvicted()
+	c.lock.Unlock()
+	c.notify(ks, vs)
+}
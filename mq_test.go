@@ -0,0 +1,86 @@
+package dailzLRU
+
+import "testing"
+
+func TestMQ(t *testing.T) {
+	evictCounter := 0
+	l, err := NewMQ(4, func(k, v int) {
+		if k != v {
+			t.Fatalf("Evict values not equal (%v!=%v)", k, v)
+		}
+		evictCounter++
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		l.Add(i, i)
+	}
+	// 0 is accessed four times and moves to queue 2
+	for i := 0; i < 3; i++ {
+		l.Get(0)
+	}
+	if e, _ := l.lookup(0); e.queue != 2 {
+		t.Fatalf("bad queue: %v", e.queue)
+	}
+	if !l.Add(4, 4) || l.Contains(1) || !l.Contains(0) {
+		t.Fatalf("bad eviction: %v", l.Keys())
+	}
+	if l.Len() != 4 || evictCounter != 1 {
+		t.Fatalf("bad len: %v, %v", l.Len(), evictCounter)
+	}
+	if keys := l.Keys(); keys[len(keys)-1] != 0 {
+		t.Fatalf("frequent key should be in the highest queue: %v", keys)
+	}
+
+	if !l.Remove(0) || l.Contains(0) || l.Remove(0) {
+		t.Fatalf("bad remove")
+	}
+	if n := l.Resize(2); n != 1 || l.Len() != 2 {
+		t.Fatalf("bad resize: %v, %v", n, l.Len())
+	}
+	l.Purge()
+	if l.Len() != 0 || evictCounter != 5 {
+		t.Fatalf("bad purge: %v, %v", l.Len(), evictCounter)
+	}
+}
+
+// Test that an entry not accessed within its lifetime is demoted and
+// eventually evicted
+func TestMQ_Lifetime(t *testing.T) {
+	l, err := NewMQWithQueues[int, int](4, 4, 3, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add(0, 0)
+	for i := 0; i < 7; i++ {
+		l.Get(0)
+	}
+	if e, _ := l.lookup(0); e.queue != 3 {
+		t.Fatalf("bad queue: %v", e.queue)
+	}
+	// a scan of keys accessed once goes through queue 0 while 0 ages
+	for i := 1; i < 100; i++ {
+		l.Add(i, i)
+	}
+	if l.Contains(0) {
+		t.Fatalf("expired entry should have been demoted and evicted")
+	}
+}
+
+// Test that an evicted key added back keeps its frequency
+func TestMQ_History(t *testing.T) {
+	l, err := NewMQWithQueues[int, int](2, 4, 100, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add(1, 1)
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Add(3, 3) // evicts 2
+	l.Add(4, 4) // evicts 3
+	l.Add(2, 2) // evicts 4, restores frequency 1 and moves 2 to queue 1
+	if e, ok := l.lookup(2); !ok || e.queue != 1 {
+		t.Fatalf("history not used: %v", l.Keys())
+	}
+}
@@ -0,0 +1,234 @@
+package dailzLRU
+
+import (
+	"errors"
+	"hash/maphash"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// mrcModulus is the range key hashes are reduced to when sampling
+const mrcModulus = 1 << 24
+
+// MissRatioPoint is a point of a miss ratio curve: the fraction of accesses
+// an LRU cache of Size entries would miss.
+type MissRatioPoint struct {
+	Size      int
+	MissRatio float64
+}
+
+// MissRatioEstimator estimates the miss ratio an LRU cache would have at
+// every size on the access stream it records, using SHARDS: only keys whose
+// hash falls in a fixed fraction of the hash space are tracked, and the
+// reuse distances measured among them (the number of distinct keys accessed
+// since the key's last access) are scaled up by the inverse of that
+// fraction. An access hits in an LRU cache exactly when its reuse distance
+// is below the cache size, so one pass over the stream yields the whole
+// curve. Memory is proportional to the number of distinct sampled keys.
+//
+// A few very popular keys being sampled or not skews the estimate; as in
+// SHARDS-adj, the difference between the number of sampled accesses and the
+// expected one is credited to the smallest distance to compensate.
+type MissRatioEstimator[K comparable] struct {
+	rate      float64
+	threshold uint64 // keys with a reduced hash below it are sampled
+	seed      maphash.Seed
+	last      map[K]int // time of each sampled key's last access
+	tree      []int     // Fenwick tree over times, 1 at each key's last access
+	clock     int
+	hist      []uint64 // hist[d] counts sampled accesses at reuse distance d
+	cold      uint64   // first accesses to sampled keys
+	accesses  atomic.Uint64
+	lock      sync.Mutex
+}
+
+// NewMissRatioEstimator constructs a MissRatioEstimator sampling the given
+// fraction of keys, in (0, 1]. A rate of 0.01 is usually accurate enough
+// for streams of millions of accesses; smaller streams need larger rates.
+func NewMissRatioEstimator[K comparable](rate float64) (*MissRatioEstimator[K], error) {
+	if !(rate > 0 && rate <= 1) {
+		return nil, errors.New("must provide a sampling rate in (0, 1]")
+	}
+	return &MissRatioEstimator[K]{
+		rate:      rate,
+		threshold: uint64(math.Ceil(rate * mrcModulus)),
+		seed:      maphash.MakeSeed(),
+		last:      make(map[K]int),
+		tree:      make([]int, 1024),
+	}, nil
+}
+
+// Record records an access to key.
+func (e *MissRatioEstimator[K]) Record(key K) {
+	e.accesses.Add(1)
+	if hashKey(e.seed, key)%mrcModulus >= e.threshold {
+		return
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.clock++
+	if e.clock >= len(e.tree) {
+		e.compact()
+	}
+	if t, ok := e.last[key]; ok {
+		d := e.prefix(e.clock-1) - e.prefix(t)
+		for len(e.hist) <= d {
+			e.hist = append(e.hist, 0)
+		}
+		e.hist[d]++
+		e.update(t, -1)
+	} else {
+		e.cold++
+	}
+	e.update(e.clock, 1)
+	e.last[key] = e.clock
+}
+
+// update adds delta at time t of the Fenwick tree
+func (e *MissRatioEstimator[K]) update(t, delta int) {
+	for ; t < len(e.tree); t += t & -t {
+		e.tree[t] += delta
+	}
+}
+
+// prefix returns the number of keys last accessed at or before time t
+func (e *MissRatioEstimator[K]) prefix(t int) (n int) {
+	for ; t > 0; t -= t & -t {
+		n += e.tree[t]
+	}
+	return n
+}
+
+// compact renumbers the last access times of the tracked keys to 1..n,
+// keeping their order, and makes room for as many accesses again
+func (e *MissRatioEstimator[K]) compact() {
+	keys := make([]K, 0, len(e.last))
+	for k := range e.last {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return e.last[keys[i]] < e.last[keys[j]] })
+	size := 2 * (len(keys) + 1)
+	if size < 1024 {
+		size = 1024
+	}
+	e.tree = make([]int, size)
+	for i, k := range keys {
+		e.last[k] = i + 1
+		e.update(i+1, 1)
+	}
+	e.clock = len(keys) + 1
+}
+
+// totals returns the number of sampled accesses to expect from the
+// accesses recorded so far and the excess of that over the sampled accesses
+// seen; the lock must be held
+func (e *MissRatioEstimator[K]) totals() (expected, excess float64) {
+	seen := e.cold
+	for _, n := range e.hist {
+		seen += n
+	}
+	expected = float64(e.accesses.Load()) * e.rate
+	return expected, expected - float64(seen)
+}
+
+// missRatio returns the estimated miss ratio at size; the lock must be held
+func (e *MissRatioEstimator[K]) missRatio(size int) float64 {
+	expected, excess := e.totals()
+	if expected == 0 || size <= 0 {
+		return 1
+	}
+	hits := excess
+	limit := float64(size) * e.rate
+	for d, n := range e.hist {
+		if float64(d) < limit {
+			hits += float64(n)
+		}
+	}
+	return clampRatio(1 - hits/expected)
+}
+
+// clampRatio limits r to [0, 1], which the adjusted counts can leave
+func clampRatio(r float64) float64 {
+	return math.Max(0, math.Min(1, r))
+}
+
+// MissRatio returns the estimated miss ratio of an LRU cache of the given
+// size on the accesses recorded so far.
+func (e *MissRatioEstimator[K]) MissRatio(size int) float64 {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.missRatio(size)
+}
+
+// MissRatioCurve returns the estimated miss ratio curve of the accesses
+// recorded so far, by increasing size. There is a point wherever the miss
+// ratio changes; beyond the last one only first accesses miss.
+func (e *MissRatioEstimator[K]) MissRatioCurve() []MissRatioPoint {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	expected, excess := e.totals()
+	if expected == 0 {
+		return nil
+	}
+	var curve []MissRatioPoint
+	misses := expected - excess
+	for d, n := range e.hist {
+		if n == 0 && (d > 0 || excess == 0) {
+			continue
+		}
+		// accesses at a scaled distance of d/rate hit in any larger cache
+		misses -= float64(n)
+		curve = append(curve, MissRatioPoint{
+			Size:      int(float64(d)/e.rate) + 1,
+			MissRatio: clampRatio(misses / expected),
+		})
+	}
+	return curve
+}
+
+// RecommendSize returns the smallest LRU cache size projected to reach the
+// given hit ratio on the accesses recorded so far, or -1 if no size does,
+// for example because too many accesses are first accesses.
+func (e *MissRatioEstimator[K]) RecommendSize(targetHitRatio float64) int {
+	if targetHitRatio <= 0 {
+		return 0
+	}
+	for _, p := range e.MissRatioCurve() {
+		if 1-p.MissRatio >= targetHitRatio {
+			return p.Size
+		}
+	}
+	return -1
+}
+
+// Reset forgets every recorded access.
+func (e *MissRatioEstimator[K]) Reset() {
+	e.lock.Lock()
+	e.last = make(map[K]int)
+	e.tree = make([]int, 1024)
+	e.clock = 0
+	e.hist = nil
+	e.cold = 0
+	e.accesses.Store(0)
+	e.lock.Unlock()
+}
+
+// EstimateMissRatios returns a middleware recording the key of every Get
+// into e.
+func EstimateMissRatios[K comparable, V any](e *MissRatioEstimator[K]) Middleware[K, V] {
+	return func(next Interface[K, V]) Interface[K, V] {
+		return estimateMissRatios[K, V]{Wrapper: Wrapper[K, V]{Next: next}, estimator: e}
+	}
+}
+
+type estimateMissRatios[K comparable, V any] struct {
+	Wrapper[K, V]
+	estimator *MissRatioEstimator[K]
+}
+
+func (c estimateMissRatios[K, V]) Get(key K) (value V, ok bool) {
+	c.estimator.Record(key)
+	return c.Next.Get(key)
+}
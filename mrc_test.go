@@ -0,0 +1,88 @@
+package dailzLRU
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestMissRatioEstimator(t *testing.T) {
+	e, err := NewMissRatioEstimator[int](1)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	// a loop over 100 keys misses everywhere in a cache of 99 and only on
+	// the first pass in a cache of 100
+	for i := 0; i < 1000; i++ {
+		e.Record(i % 100)
+	}
+	if r := e.MissRatio(99); r != 1 {
+		t.Fatalf("bad miss ratio below the loop size: %v", r)
+	}
+	if r := e.MissRatio(100); math.Abs(r-0.1) > 1e-9 {
+		t.Fatalf("bad miss ratio at the loop size: %v", r)
+	}
+	curve := e.MissRatioCurve()
+	if len(curve) != 1 || curve[0].Size != 100 || math.Abs(curve[0].MissRatio-0.1) > 1e-9 {
+		t.Fatalf("bad curve: %v", curve)
+	}
+	if n := e.RecommendSize(0.85); n != 100 {
+		t.Fatalf("bad recommendation: %v", n)
+	}
+	if n := e.RecommendSize(0.95); n != -1 {
+		t.Fatalf("unreachable hit ratio should not be recommended: %v", n)
+	}
+	e.Reset()
+	if e.MissRatioCurve() != nil || e.MissRatio(10) != 1 {
+		t.Fatalf("bad reset")
+	}
+
+	if _, err := NewMissRatioEstimator[int](0); err == nil {
+		t.Fatalf("should reject a zero rate")
+	}
+}
+
+// Test that a sampled estimate tracks the miss ratio of a real cache
+func TestMissRatioEstimator_Sampled(t *testing.T) {
+	e, err := NewMissRatioEstimator[int](0.1)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	r := rand.New(rand.NewSource(1))
+	zipf := rand.NewZipf(r, 1.1, 1, 100000)
+	trace := make([]int, 200000)
+	for i := range trace {
+		trace[i] = int(zipf.Uint64())
+	}
+	for _, size := range []int{100, 1000, 10000} {
+		c, err := New[int, struct{}](size)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		l := Chain[int, struct{}](c, EstimateMissRatios[int, struct{}](e))
+		misses := 0
+		for _, k := range trace {
+			if _, ok := l.Get(k); !ok {
+				misses++
+				l.Add(k, struct{}{})
+			}
+		}
+		actual := float64(misses) / float64(len(trace))
+		// the estimator saw the trace once per size, which only adds
+		// reuses at large distances
+		if est := e.MissRatio(size); math.Abs(est-actual) > 0.07 {
+			t.Fatalf("bad estimate at %d: %v, actual %v", size, est, actual)
+		}
+		e.Reset()
+	}
+
+	for i, k := range trace {
+		if i%2 == 0 {
+			e.Record(k)
+		}
+	}
+	size := e.RecommendSize(0.75)
+	if size <= 0 || e.MissRatio(size) > 0.25 || e.MissRatio(size-1) <= 0.25 {
+		t.Fatalf("bad recommendation: %v", size)
+	}
+}
@@ -0,0 +1,122 @@
+// Package orderedmap provides a map that remembers the order of its keys,
+// either the order they were inserted in or the order they were last
+// accessed in, optionally bounded by evicting the first key.
+package orderedmap
+
+import (
+	"errors"
+	"github.com/dailz1/dailzLRU/lru"
+	"math"
+)
+
+// Order selects how a Map orders its keys.
+type Order int
+
+const (
+	// InsertionOrder keeps keys in the order they were first set.
+	InsertionOrder Order = iota
+	// AccessOrder moves a key to the back whenever it is set or read with
+	// Get, like an LRU cache.
+	AccessOrder
+)
+
+// Map is a map whose keys are ordered from first to last. It is not safe
+// for concurrent use.
+type Map[K comparable, V any] struct {
+	l *lru.LRU[K, V]
+}
+
+// New constructs an empty Map with the given order. A positive capacity
+// bounds the map: setting a new key in a full map removes the first key. A
+// capacity of 0 leaves the map unbounded.
+func New[K comparable, V any](order Order, capacity int) (*Map[K, V], error) {
+	if capacity < 0 {
+		return nil, errors.New("must provide a non-negative capacity")
+	}
+	if capacity == 0 {
+		capacity = math.MaxInt
+	}
+	var l *lru.LRU[K, V]
+	var err error
+	switch order {
+	case InsertionOrder:
+		l, err = lru.NewFIFO[K, V](capacity, nil)
+	case AccessOrder:
+		l, err = lru.NewLRU[K, V](capacity, nil)
+	default:
+		return nil, errors.New("unknown order")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Map[K, V]{l: l}, nil
+}
+
+// Get returns the value for key. In access order the key moves to the back.
+func (m *Map[K, V]) Get(key K) (value V, ok bool) {
+	return m.l.Get(key)
+}
+
+// Peek returns the value for key without changing the order.
+func (m *Map[K, V]) Peek(key K) (value V, ok bool) {
+	return m.l.Peek(key)
+}
+
+// Has reports whether key is in the map, without changing the order.
+func (m *Map[K, V]) Has(key K) bool {
+	return m.l.Contains(key)
+}
+
+// Set sets the value for key. A new key goes to the back; an existing key
+// keeps its position in insertion order and moves to the back in access
+// order. Returns true if the first key was removed to stay within the
+// capacity.
+func (m *Map[K, V]) Set(key K, value V) (evicted bool) {
+	return m.l.Add(key, value)
+}
+
+// Delete removes key, returning true if it was present.
+func (m *Map[K, V]) Delete(key K) bool {
+	return m.l.Remove(key)
+}
+
+// First returns the first entry without removing it.
+func (m *Map[K, V]) First() (key K, value V, ok bool) {
+	return m.l.GetOldest()
+}
+
+// Last returns the last entry without removing it.
+func (m *Map[K, V]) Last() (key K, value V, ok bool) {
+	keys := m.l.RecentKeys(1)
+	if len(keys) == 0 {
+		return key, value, false
+	}
+	value, ok = m.l.Peek(keys[0])
+	return keys[0], value, ok
+}
+
+// PopFirst removes and returns the first entry.
+func (m *Map[K, V]) PopFirst() (key K, value V, ok bool) {
+	return m.l.RemoveOldest()
+}
+
+// Keys returns the keys from first to last.
+func (m *Map[K, V]) Keys() []K {
+	return m.l.Keys()
+}
+
+// Range calls fn for every entry from first to last, stopping when fn
+// returns false. fn must not modify the map.
+func (m *Map[K, V]) Range(fn func(key K, value V) bool) {
+	m.l.Range(fn)
+}
+
+// Len returns the number of entries.
+func (m *Map[K, V]) Len() int {
+	return m.l.Len()
+}
+
+// Clear removes every entry.
+func (m *Map[K, V]) Clear() {
+	m.l.Purge()
+}
@@ -0,0 +1,56 @@
+package orderedmap
+
+import "testing"
+
+func TestInsertionOrder(t *testing.T) {
+	m, err := New[string, int](InsertionOrder, 0)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	m.Set("b", 1)
+	m.Set("a", 2)
+	m.Set("c", 3)
+	m.Get("b")
+	m.Set("b", 4)
+	if keys := m.Keys(); keys[0] != "b" || keys[1] != "a" || keys[2] != "c" {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	if k, v, ok := m.Last(); !ok || k != "c" || v != 3 {
+		t.Fatalf("bad last: %v, %v, %v", k, v, ok)
+	}
+	if k, v, ok := m.PopFirst(); !ok || k != "b" || v != 4 {
+		t.Fatalf("bad first: %v, %v, %v", k, v, ok)
+	}
+	if !m.Delete("c") || m.Len() != 1 || !m.Has("a") {
+		t.Fatalf("bad delete: %v", m.Keys())
+	}
+}
+
+func TestAccessOrder(t *testing.T) {
+	m, err := New[int, int](AccessOrder, 3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		m.Set(i, i)
+	}
+	m.Get(0)
+	if !m.Set(3, 3) {
+		t.Fatalf("should have evicted")
+	}
+	var keys []int
+	m.Range(func(k, v int) bool {
+		keys = append(keys, k)
+		return true
+	})
+	if len(keys) != 3 || keys[0] != 2 || keys[1] != 0 || keys[2] != 3 {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	if k, _, _ := m.First(); k != 2 {
+		t.Fatalf("bad first: %v", k)
+	}
+	m.Clear()
+	if _, _, ok := m.Last(); ok || m.Len() != 0 {
+		t.Fatalf("map should be empty")
+	}
+}
@@ -0,0 +1,422 @@
+// Package persistent provides an LRU cache for []byte values whose entries
+// live in a memory-mapped file, so the cache survives restarts and the OS can
+// page cold entries out.
+package persistent
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"github.com/dailz1/dailzLRU/lru"
+	"os"
+	"sort"
+	"sync"
+)
+
+const (
+	fileMagic   = "DLRUMMAP"
+	fileVersion = 1
+	maxSlotSize = 1 << 30
+
+	// file header layout
+	headerSize      = 64
+	offHeaderMagic  = 0
+	offHeaderFormat = 8
+	offHeaderSlots  = 12
+	offHeaderSize   = 16
+
+	// slot header layout, followed by the key and then the value
+	slotHeaderSize = 24
+	offFlags       = 0
+	offKeyLen      = 4
+	offValLen      = 8
+	offSeq         = 16
+
+	slotFlagUsed = 1
+)
+
+var (
+	// ErrEntryTooLarge is returned by Add when a key and value do not fit in
+	// a single slot.
+	ErrEntryTooLarge = errors.New("entry is larger than a slot")
+	// ErrGeometryMismatch is returned by Open when an existing file was
+	// created with a different slot count or slot size.
+	ErrGeometryMismatch = errors.New("cache file has a different geometry")
+	// ErrCorruptFile is returned by Open when a file is not a cache file.
+	ErrCorruptFile = errors.New("not a cache file")
+	// ErrClosed is returned by operations on a closed cache.
+	ErrClosed = errors.New("cache is closed")
+)
+
+// Cache is a thread-safe fixed size LRU cache backed by a memory-mapped
+// file. The file is split into equally sized slots, each holding one entry
+// together with a header that doubles as the on-disk index: the header
+// records whether the slot is used and a sequence number that is bumped on
+// every access, from which the recency order is rebuilt when the file is
+// opened again.
+//
+// Writes reach the file through the page cache; call Sync to force them to
+// disk.
+type Cache struct {
+	file     *os.File
+	data     []byte
+	slots    int
+	slotSize int
+	lru      *lru.LRU[string, int]
+	free     []int
+	seq      uint64
+	wal      *wal
+	walErr   error
+	lock     sync.Mutex
+}
+
+// Open opens the cache file at path, creating it with the given number of
+// slots of slotSize bytes each if it does not exist. An existing file must
+// have been created with the same geometry.
+func Open(path string, slots, slotSize int) (*Cache, error) {
+	if slots <= 0 {
+		return nil, errors.New("must provide a positive slot count")
+	}
+	if slotSize <= slotHeaderSize || slotSize > maxSlotSize {
+		return nil, errors.New("invalid slot size")
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	c, err := open(f, slots, slotSize)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func open(f *os.File, slots, slotSize int) (*Cache, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := headerSize + slots*slotSize
+	fresh := info.Size() == 0
+	if fresh {
+		if err := f.Truncate(int64(size)); err != nil {
+			return nil, err
+		}
+	} else if info.Size() != int64(size) {
+		return nil, ErrGeometryMismatch
+	}
+
+	data, err := mmap(f, size)
+	if err != nil {
+		return nil, err
+	}
+	c := &Cache{
+		file:     f,
+		data:     data,
+		slots:    slots,
+		slotSize: slotSize,
+	}
+	if c.lru, err = lru.NewLRU[string, int](slots, c.freeSlot); err != nil {
+		munmap(data)
+		return nil, err
+	}
+
+	if fresh {
+		c.writeHeader()
+	} else if err := c.checkHeader(); err != nil {
+		munmap(data)
+		return nil, err
+	}
+	c.load()
+	return c, nil
+}
+
+// writeHeader writes the file header of a new cache file
+func (c *Cache) writeHeader() {
+	copy(c.data[offHeaderMagic:], fileMagic)
+	binary.LittleEndian.PutUint32(c.data[offHeaderFormat:], fileVersion)
+	binary.LittleEndian.PutUint32(c.data[offHeaderSlots:], uint32(c.slots))
+	binary.LittleEndian.PutUint32(c.data[offHeaderSize:], uint32(c.slotSize))
+}
+
+// checkHeader validates the file header of an existing cache file
+func (c *Cache) checkHeader() error {
+	if !bytes.Equal(c.data[offHeaderMagic:offHeaderMagic+len(fileMagic)], []byte(fileMagic)) ||
+		binary.LittleEndian.Uint32(c.data[offHeaderFormat:]) != fileVersion {
+		return ErrCorruptFile
+	}
+	if int(binary.LittleEndian.Uint32(c.data[offHeaderSlots:])) != c.slots ||
+		int(binary.LittleEndian.Uint32(c.data[offHeaderSize:])) != c.slotSize {
+		return ErrGeometryMismatch
+	}
+	return nil
+}
+
+// load rebuilds the index and recency order from the slot headers
+func (c *Cache) load() {
+	type used struct {
+		slot int
+		seq  uint64
+	}
+	var entries []used
+	for i := 0; i < c.slots; i++ {
+		s := c.slot(i)
+		keyLen := int(binary.LittleEndian.Uint32(s[offKeyLen:]))
+		valLen := int(binary.LittleEndian.Uint32(s[offValLen:]))
+		if binary.LittleEndian.Uint32(s[offFlags:]) != slotFlagUsed || keyLen+valLen > c.slotSize-slotHeaderSize {
+			binary.LittleEndian.PutUint32(s[offFlags:], 0)
+			c.free = append(c.free, i)
+			continue
+		}
+		entries = append(entries, used{slot: i, seq: binary.LittleEndian.Uint64(s[offSeq:])})
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].seq < entries[j].seq })
+	for _, e := range entries {
+		key := string(c.slotKey(e.slot))
+		// an interrupted update can leave an older copy of a key behind;
+		// the newer one wins and frees the older slot
+		if old, ok := c.lru.Peek(key); ok {
+			c.freeSlot(key, old)
+		}
+		c.lru.Add(key, e.slot)
+		c.seq = e.seq
+	}
+}
+
+// slot returns the bytes of slot i
+func (c *Cache) slot(i int) []byte {
+	off := headerSize + i*c.slotSize
+	return c.data[off : off+c.slotSize]
+}
+
+// slotKey returns the key stored in slot i
+func (c *Cache) slotKey(i int) []byte {
+	s := c.slot(i)
+	keyLen := binary.LittleEndian.Uint32(s[offKeyLen:])
+	return s[slotHeaderSize : slotHeaderSize+keyLen]
+}
+
+// slotValue returns the value stored in slot i
+func (c *Cache) slotValue(i int) []byte {
+	s := c.slot(i)
+	keyLen := binary.LittleEndian.Uint32(s[offKeyLen:])
+	valLen := binary.LittleEndian.Uint32(s[offValLen:])
+	return s[slotHeaderSize+keyLen : slotHeaderSize+keyLen+valLen]
+}
+
+// writeSlot stores an entry in slot i. The slot is marked unused while it is
+// being written so a torn write is discarded on the next Open.
+func (c *Cache) writeSlot(i int, key string, value []byte) {
+	s := c.slot(i)
+	binary.LittleEndian.PutUint32(s[offFlags:], 0)
+	binary.LittleEndian.PutUint32(s[offKeyLen:], uint32(len(key)))
+	binary.LittleEndian.PutUint32(s[offValLen:], uint32(len(value)))
+	n := copy(s[slotHeaderSize:], key)
+	copy(s[slotHeaderSize+n:], value)
+	c.touchSlot(i)
+	binary.LittleEndian.PutUint32(s[offFlags:], slotFlagUsed)
+}
+
+// touchSlot records an access to slot i
+func (c *Cache) touchSlot(i int) {
+	c.seq++
+	binary.LittleEndian.PutUint64(c.slot(i)[offSeq:], c.seq)
+}
+
+// freeSlot is the eviction callback of the index; it releases the slot of
+// an entry leaving the cache
+func (c *Cache) freeSlot(key string, i int) {
+	binary.LittleEndian.PutUint32(c.slot(i)[offFlags:], 0)
+	c.free = append(c.free, i)
+}
+
+// Add adds a value to the cache. Returns true if an eviction occurred.
+func (c *Cache) Add(key string, value []byte) (evicted bool, err error) {
+	if len(key)+len(value) > c.slotSize-slotHeaderSize {
+		return false, ErrEntryTooLarge
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.data == nil {
+		return false, ErrClosed
+	}
+	if err := c.log(walOpAdd, key, value); err != nil {
+		return false, err
+	}
+	return c.add(key, value), nil
+}
+
+// add stores an entry, evicting the oldest one if every slot is used
+func (c *Cache) add(key string, value []byte) (evicted bool) {
+	if i, ok := c.lru.Get(key); ok {
+		c.writeSlot(i, key, value)
+		return false
+	}
+	if len(c.free) == 0 {
+		c.lru.RemoveOldest()
+		evicted = true
+	}
+	i := c.free[len(c.free)-1]
+	c.free = c.free[:len(c.free)-1]
+	c.writeSlot(i, key, value)
+	c.lru.Add(key, i)
+	return evicted
+}
+
+// log writes a mutation to the write-ahead log, if there is one, rotating
+// the log first when its current segment is full
+func (c *Cache) log(op byte, key string, value []byte) error {
+	if c.wal == nil {
+		return nil
+	}
+	if c.wal.full() {
+		if err := c.checkpoint(); err != nil {
+			return err
+		}
+	}
+	return c.wal.append(op, key, value)
+}
+
+// checkpoint syncs the cache file and starts a new log segment, dropping the
+// segments whose records are now part of the file
+func (c *Cache) checkpoint() error {
+	if err := msync(c.data); err != nil {
+		return err
+	}
+	return c.wal.rotate()
+}
+
+// Get looks up a key's value from the cache, returning a copy of it.
+func (c *Cache) Get(key string) (value []byte, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.data == nil {
+		return nil, false
+	}
+	i, ok := c.lru.Get(key)
+	if !ok {
+		return nil, false
+	}
+	c.touchSlot(i)
+	return append([]byte(nil), c.slotValue(i)...), true
+}
+
+// Peek returns a copy of the key's value without updating the "recently
+// used"-ness of the key.
+func (c *Cache) Peek(key string) (value []byte, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.data == nil {
+		return nil, false
+	}
+	i, ok := c.lru.Peek(key)
+	if !ok {
+		return nil, false
+	}
+	return append([]byte(nil), c.slotValue(i)...), true
+}
+
+// Contains checks if a key is in the cache, without updating its recent-ness.
+func (c *Cache) Contains(key string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.data != nil && c.lru.Contains(key)
+}
+
+// Remove removes the provided key from the cache, returning true if the key
+// was contained.
+func (c *Cache) Remove(key string) (present bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.data == nil || !c.lru.Contains(key) {
+		return false
+	}
+	if err := c.log(walOpRemove, key, nil); err != nil && c.walErr == nil {
+		c.walErr = err
+	}
+	return c.lru.Remove(key)
+}
+
+// Keys returns a slice of the keys in the cache, from oldest to newest.
+func (c *Cache) Keys() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.data == nil {
+		return nil
+	}
+	return c.lru.Keys()
+}
+
+// Len returns the number of items in the cache.
+func (c *Cache) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.data == nil {
+		return 0
+	}
+	return c.lru.Len()
+}
+
+// Purge is used to completely clear the cache.
+func (c *Cache) Purge() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.data == nil {
+		return
+	}
+	if err := c.log(walOpPurge, "", nil); err != nil && c.walErr == nil {
+		c.walErr = err
+	}
+	c.lru.Purge()
+}
+
+// Sync flushes all changes to the file. With a write-ahead log, it also
+// compacts the log and reports any earlier failure to log a Remove or Purge.
+func (c *Cache) Sync() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.data == nil {
+		return ErrClosed
+	}
+	if err := c.walErr; err != nil {
+		c.walErr = nil
+		return err
+	}
+	if c.wal != nil {
+		return c.checkpoint()
+	}
+	return msync(c.data)
+}
+
+// Close flushes all changes and releases the file. The cache must not be
+// used afterwards.
+func (c *Cache) Close() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.data == nil {
+		return ErrClosed
+	}
+	var err error
+	if c.wal != nil {
+		err = c.checkpoint()
+		if werr := c.wal.close(); err == nil {
+			err = werr
+		}
+		if err == nil {
+			err = c.walErr
+		}
+	} else {
+		err = msync(c.data)
+	}
+	if uerr := munmap(c.data); err == nil {
+		err = uerr
+	}
+	if cerr := c.file.Close(); err == nil {
+		err = cerr
+	}
+	c.data = nil
+	return err
+}
@@ -0,0 +1,57 @@
+//go:build linux
+
+package persistent
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache")
+	c, err := Open(path, 4, 64)
+	if err != nil {
+		t.Fatalf("Open error: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := c.Add(fmt.Sprint(i), []byte(fmt.Sprintf("value-%d", i))); err != nil {
+			t.Fatalf("Add error: %v", err)
+		}
+	}
+	if c.Contains("0") || c.Len() != 4 {
+		t.Fatalf("bad keys: %v", c.Keys())
+	}
+	c.Get("1")
+	c.Remove("3")
+	if _, err := c.Add("big", make([]byte, 64)); err != ErrEntryTooLarge {
+		t.Fatalf("expected ErrEntryTooLarge, got %v", err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+
+	// entries and their recency survive a reopen
+	c, err = Open(path, 4, 64)
+	if err != nil {
+		t.Fatalf("reopen error: %v", err)
+	}
+	defer c.Close()
+	keys := c.Keys()
+	if len(keys) != 3 || keys[0] != "2" || keys[1] != "4" || keys[2] != "1" {
+		t.Fatalf("bad keys after reopen: %v", keys)
+	}
+	if v, ok := c.Get("4"); !ok || string(v) != "value-4" {
+		t.Fatalf("bad value after reopen: %q", v)
+	}
+	for i := 5; i < 7; i++ {
+		c.Add(fmt.Sprint(i), []byte("x"))
+	}
+	if c.Contains("2") || c.Len() != 4 {
+		t.Fatalf("bad eviction after reopen: %v", c.Keys())
+	}
+
+	if _, err := Open(path, 8, 64); err != ErrGeometryMismatch {
+		t.Fatalf("expected ErrGeometryMismatch, got %v", err)
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !linux
+
+package persistent
+
+import (
+	"errors"
+	"os"
+)
+
+var errUnsupported = errors.New("memory-mapped caches are only supported on linux")
+
+func mmap(f *os.File, size int) ([]byte, error) {
+	return nil, errUnsupported
+}
+
+func munmap(data []byte) error {
+	return errUnsupported
+}
+
+func msync(data []byte) error {
+	return errUnsupported
+}
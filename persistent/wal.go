@@ -0,0 +1,249 @@
+package persistent
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	// DefaultSegmentSize is the size at which a WAL segment is rotated.
+	DefaultSegmentSize = 64 << 20
+
+	walSuffix       = ".wal"
+	walHeaderSize   = 8
+	walOpAdd        = 1
+	walOpRemove     = 2
+	walOpPurge      = 3
+	maxRecordLength = maxSlotSize + 16
+)
+
+// WALOptions configures the write-ahead log of a cache opened with
+// OpenWithWAL.
+type WALOptions struct {
+	// SegmentSize is the size at which the current segment is rotated. On
+	// rotation the cache file is synced and every older segment deleted.
+	// Defaults to DefaultSegmentSize.
+	SegmentSize int64
+	// NoSync skips the fsync after every record. Records then survive a
+	// process crash but not an OS crash or power loss.
+	NoSync bool
+}
+
+// wal is an append-only log of cache mutations split into numbered segments
+type wal struct {
+	dir         string
+	segmentSize int64
+	noSync      bool
+	file        *os.File
+	seq         uint64
+	size        int64
+}
+
+// OpenWithWAL opens a cache like Open and additionally logs every Add,
+// Remove and Purge to a write-ahead log in dir before applying it. Records
+// left by a previous run are replayed on open, so mutations made since the
+// cache file was last synced survive a crash that lost the file's dirty
+// pages. Lookups are not logged, so the entries evicted while replaying can
+// differ from those evicted in the original run. Once replayed, the cache
+// file is synced and the log compacted.
+//
+// When logging a Remove or Purge fails the cache keeps working; the error
+// is reported by the next call to Sync or Close.
+func OpenWithWAL(path string, slots, slotSize int, dir string, opts WALOptions) (*Cache, error) {
+	if opts.SegmentSize <= 0 {
+		opts.SegmentSize = DefaultSegmentSize
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	c, err := Open(path, slots, slotSize)
+	if err != nil {
+		return nil, err
+	}
+	w := &wal{dir: dir, segmentSize: opts.SegmentSize, noSync: opts.NoSync}
+	if err := w.replay(c); err != nil {
+		c.Close()
+		return nil, err
+	}
+	c.wal = w
+	if err := c.checkpoint(); err != nil {
+		c.wal = nil
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// segments returns the sequence numbers of the segments in dir, in order
+func (w *wal) segments() ([]uint64, error) {
+	names, err := filepath.Glob(filepath.Join(w.dir, "*"+walSuffix))
+	if err != nil {
+		return nil, err
+	}
+	var seqs []uint64
+	for _, name := range names {
+		var seq uint64
+		if _, err := fmt.Sscanf(filepath.Base(name), "%016x"+walSuffix, &seq); err == nil {
+			seqs = append(seqs, seq)
+		}
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+	return seqs, nil
+}
+
+// segmentPath returns the file name of segment seq
+func (w *wal) segmentPath(seq uint64) string {
+	return filepath.Join(w.dir, fmt.Sprintf("%016x%s", seq, walSuffix))
+}
+
+// replay applies every intact record of every segment to c. Replay stops at
+// the first torn or corrupt record, which can only be the tail of the last
+// segment written before a crash.
+func (w *wal) replay(c *Cache) error {
+	seqs, err := w.segments()
+	if err != nil {
+		return err
+	}
+	for _, seq := range seqs {
+		w.seq = seq
+		f, err := os.Open(w.segmentPath(seq))
+		if err != nil {
+			return err
+		}
+		intact, err := replaySegment(bufio.NewReader(f), c)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		if !intact {
+			break
+		}
+	}
+	return nil
+}
+
+// replaySegment applies the records read from r to c, returning false if it
+// stopped at a torn or corrupt record
+func replaySegment(r io.Reader, c *Cache) (intact bool, err error) {
+	var header [walHeaderSize]byte
+	for {
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return err == io.EOF, nil
+		}
+		n := binary.LittleEndian.Uint32(header[0:])
+		if n < 5 || n > maxRecordLength {
+			return false, nil
+		}
+		payload := make([]byte, n)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return false, nil
+		}
+		if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(header[4:]) {
+			return false, nil
+		}
+
+		keyLen := binary.LittleEndian.Uint32(payload[1:])
+		if uint64(keyLen) > uint64(n-5) {
+			return false, nil
+		}
+		key := string(payload[5 : 5+keyLen])
+		switch payload[0] {
+		case walOpAdd:
+			if len(payload)-5 > c.slotSize-slotHeaderSize {
+				return false, ErrEntryTooLarge
+			}
+			c.add(key, payload[5+keyLen:])
+		case walOpRemove:
+			c.lru.Remove(key)
+		case walOpPurge:
+			c.lru.Purge()
+		default:
+			return false, nil
+		}
+	}
+}
+
+// append writes a record to the current segment
+func (w *wal) append(op byte, key string, value []byte) error {
+	payload := make([]byte, walHeaderSize+5+len(key)+len(value))
+	binary.LittleEndian.PutUint32(payload[0:], uint32(len(payload)-walHeaderSize))
+	payload[walHeaderSize] = op
+	binary.LittleEndian.PutUint32(payload[walHeaderSize+1:], uint32(len(key)))
+	copy(payload[walHeaderSize+5:], key)
+	copy(payload[walHeaderSize+5+len(key):], value)
+	binary.LittleEndian.PutUint32(payload[4:], crc32.ChecksumIEEE(payload[walHeaderSize:]))
+
+	if _, err := w.file.Write(payload); err != nil {
+		return err
+	}
+	w.size += int64(len(payload))
+	if w.noSync {
+		return nil
+	}
+	return w.file.Sync()
+}
+
+// full reports whether the current segment should be rotated
+func (w *wal) full() bool {
+	return w.file == nil || w.size >= w.segmentSize
+}
+
+// rotate starts a new segment and deletes every older one. The caller must
+// have synced the cache file so the older segments are no longer needed.
+func (w *wal) rotate() error {
+	if w.file != nil {
+		if err := w.file.Close(); err != nil {
+			return err
+		}
+		w.file = nil
+	}
+	old, err := w.segments()
+	if err != nil {
+		return err
+	}
+	if len(old) > 0 {
+		w.seq = old[len(old)-1]
+	}
+	w.seq++
+	f, err := os.OpenFile(w.segmentPath(w.seq), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	w.file = f
+	w.size = 0
+	for _, seq := range old {
+		if err := os.Remove(w.segmentPath(seq)); err != nil {
+			return err
+		}
+	}
+	return syncDir(w.dir)
+}
+
+// close closes the current segment
+func (w *wal) close() error {
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// syncDir makes file creations and removals in dir durable
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = d.Sync()
+	if cerr := d.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,92 @@
+//go:build linux
+
+package persistent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// crash drops a cache without syncing or compacting its log and wipes the
+// slots of its file, as if none of its dirty pages had been written back
+func crash(t *testing.T, c *Cache, path string) {
+	t.Helper()
+	c.wal.close()
+	munmap(c.data)
+	c.file.Close()
+	if err := os.Truncate(path, headerSize); err != nil {
+		t.Fatalf("truncate error: %v", err)
+	}
+	if err := os.Truncate(path, int64(headerSize+c.slots*c.slotSize)); err != nil {
+		t.Fatalf("truncate error: %v", err)
+	}
+}
+
+func TestCache_WAL(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cache")
+	walDir := filepath.Join(dir, "wal")
+
+	c, err := OpenWithWAL(path, 8, 64, walDir, WALOptions{})
+	if err != nil {
+		t.Fatalf("OpenWithWAL error: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		c.Add(fmt.Sprint(i), []byte(fmt.Sprintf("value-%d", i)))
+	}
+	c.Remove("1")
+	crash(t, c, path)
+
+	// a torn record at the tail of the log is ignored
+	segs, _ := filepath.Glob(filepath.Join(walDir, "*"+walSuffix))
+	if len(segs) != 1 {
+		t.Fatalf("expected a single segment, got %v", segs)
+	}
+	f, err := os.OpenFile(segs[0], os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatalf("open segment error: %v", err)
+	}
+	f.Write([]byte{42, 0, 0, 0, 1, 2})
+	f.Close()
+
+	c, err = OpenWithWAL(path, 8, 64, walDir, WALOptions{})
+	if err != nil {
+		t.Fatalf("reopen error: %v", err)
+	}
+	keys := c.Keys()
+	if len(keys) != 3 || keys[0] != "0" || keys[1] != "2" || keys[2] != "3" {
+		t.Fatalf("bad keys after replay: %v", keys)
+	}
+	if v, ok := c.Get("2"); !ok || string(v) != "value-2" {
+		t.Fatalf("bad value after replay: %q", v)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+}
+
+func TestCache_WALRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cache")
+	walDir := filepath.Join(dir, "wal")
+
+	c, err := OpenWithWAL(path, 4, 64, walDir, WALOptions{SegmentSize: 64, NoSync: true})
+	if err != nil {
+		t.Fatalf("OpenWithWAL error: %v", err)
+	}
+	defer c.Close()
+	for i := 0; i < 32; i++ {
+		if _, err := c.Add(fmt.Sprint(i), []byte("value")); err != nil {
+			t.Fatalf("Add error: %v", err)
+		}
+	}
+	segs, _ := filepath.Glob(filepath.Join(walDir, "*"+walSuffix))
+	if len(segs) != 1 {
+		t.Fatalf("rotation should compact older segments, got %v", segs)
+	}
+	if info, err := os.Stat(segs[0]); err != nil || info.Size() > 64+32 {
+		t.Fatalf("bad segment size: %v, %v", info.Size(), err)
+	}
+}
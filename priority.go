@@ -0,0 +1,284 @@
+package dailzLRU
+
+import (
+	"errors"
+	"github.com/dailz1/dailzLRU/lru"
+	"sync"
+)
+
+// Priority is the eviction class of an entry in a PriorityCache.
+type Priority int
+
+const (
+	// PriorityLow entries are evicted before any other entry.
+	PriorityLow Priority = iota
+	// PriorityNormal is the priority of entries added with Add.
+	PriorityNormal
+	// PriorityHigh entries are evicted only when no other entry is left.
+	PriorityHigh
+
+	numPriorities = 3
+)
+
+// PriorityCache is a thread-safe fixed size cache whose entries belong to a
+// priority class. To make room it evicts the least recently used entry of
+// the lowest priority class that is not empty, so higher priority entries
+// are only evicted once every lower priority entry is gone. A low priority
+// entry added to a cache full of higher priority entries is evicted right
+// away.
+type PriorityCache[K comparable, V any] struct {
+	size        int
+	classes     [numPriorities]*lru.LRU[K, V]
+	quotas      [numPriorities]int
+	evictedKeys []K
+	evictedVals []V
+	onEvictedCB func(k K, v V)
+	dropping    bool // set while an entry moves between classes
+	lock        sync.Mutex
+}
+
+// NewPriority constructs a PriorityCache of the given size. onEvicted, if not
+// nil, is called outside of the cache's lock for entries that are evicted or
+// removed.
+func NewPriority[K comparable, V any](size int, onEvicted func(key K, value V)) (*PriorityCache[K, V], error) {
+	if size <= 0 {
+		return nil, errors.New("must provide a positive size")
+	}
+	c := &PriorityCache[K, V]{size: size, onEvictedCB: onEvicted}
+	for i := range c.classes {
+		// a class never holds more than the whole cache, so the classes
+		// themselves only evict when asked to
+		l, err := lru.NewLRU[K, V](size, c.onEvicted)
+		if err != nil {
+			return nil, err
+		}
+		c.classes[i] = l
+	}
+	return c, nil
+}
+
+// onEvicted buffers the eviction callback of an entry leaving a class
+func (c *PriorityCache[K, V]) onEvicted(k K, v V) {
+	if c.onEvictedCB != nil && !c.dropping {
+		c.evictedKeys = append(c.evictedKeys, k)
+		c.evictedVals = append(c.evictedVals, v)
+	}
+}
+
+// takeEvicted hands back the buffered evictions and resets the buffers
+func (c *PriorityCache[K, V]) takeEvicted() (ks []K, vs []V) {
+	ks, vs = c.evictedKeys, c.evictedVals
+	c.evictedKeys, c.evictedVals = nil, nil
+	return ks, vs
+}
+
+// notify invokes the eviction callback for buffered evictions
+func (c *PriorityCache[K, V]) notify(ks []K, vs []V) {
+	for i := 0; i < len(ks); i++ {
+		c.onEvictedCB(ks[i], vs[i])
+	}
+}
+
+// len returns the number of entries in all classes
+func (c *PriorityCache[K, V]) len() (n int) {
+	for _, l := range c.classes {
+		n += l.Len()
+	}
+	return n
+}
+
+// class returns the class holding key, or nil
+func (c *PriorityCache[K, V]) class(key K) *lru.LRU[K, V] {
+	for _, l := range c.classes {
+		if l.Contains(key) {
+			return l
+		}
+	}
+	return nil
+}
+
+// evictLowest evicts the oldest entry of the lowest non-empty class
+func (c *PriorityCache[K, V]) evictLowest() {
+	for _, l := range c.classes {
+		if _, _, ok := l.RemoveOldest(); ok {
+			return
+		}
+	}
+}
+
+// Add adds a value with PriorityNormal to the cache. Returns true if an
+// eviction occurred.
+func (c *PriorityCache[K, V]) Add(key K, value V) (evicted bool) {
+	return c.AddWithPriority(key, value, PriorityNormal)
+}
+
+// AddWithPriority adds a value with the given priority to the cache, moving
+// the key to that priority if it was already present. Returns true if an
+// eviction occurred.
+func (c *PriorityCache[K, V]) AddWithPriority(key K, value V, p Priority) (evicted bool) {
+	if p < PriorityLow {
+		p = PriorityLow
+	} else if p > PriorityHigh {
+		p = PriorityHigh
+	}
+	c.lock.Lock()
+	if l := c.class(key); l != nil && l != c.classes[p] {
+		c.dropping = true
+		l.Remove(key)
+		c.dropping = false
+	}
+	c.classes[p].Add(key, value)
+	if c.enforceQuota(p) > 0 {
+		evicted = true
+	}
+	for c.len() > c.size {
+		c.evictLowest()
+		evicted = true
+	}
+	ks, vs := c.takeEvicted()
+	c.lock.Unlock()
+	c.notify(ks, vs)
+	return evicted
+}
+
+// SetQuota caps the number of entries of priority p. Once the class is at its
+// quota, adding another entry of that priority evicts the class's least
+// recently used entry, whatever the other classes hold. Capping the lower
+// classes thus guarantees higher priority entries a share of the cache, and
+// capping the higher classes keeps lower priority entries from being starved
+// out. A quota of 0 removes the cap. Entries over a new quota are evicted
+// right away; returns the number of entries evicted.
+func (c *PriorityCache[K, V]) SetQuota(p Priority, quota int) (evicted int) {
+	if p < PriorityLow || p > PriorityHigh {
+		return 0
+	}
+	if quota < 0 {
+		quota = 0
+	}
+	c.lock.Lock()
+	c.quotas[p] = quota
+	evicted = c.enforceQuota(p)
+	ks, vs := c.takeEvicted()
+	c.lock.Unlock()
+	c.notify(ks, vs)
+	return evicted
+}
+
+// enforceQuota evicts the oldest entries of class p while it exceeds its
+// quota, returning the number of entries evicted
+func (c *PriorityCache[K, V]) enforceQuota(p Priority) (evicted int) {
+	q := c.quotas[p]
+	if q == 0 {
+		return 0
+	}
+	for c.classes[p].Len() > q {
+		c.classes[p].RemoveOldest()
+		evicted++
+	}
+	return evicted
+}
+
+// Get looks up a key's value from the cache, updating its recent-ness within
+// its priority class.
+func (c *PriorityCache[K, V]) Get(key K) (value V, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if l := c.class(key); l != nil {
+		return l.Get(key)
+	}
+	return value, false
+}
+
+// Peek returns the key value (or undefined if not found) without updating
+// the "recently used"-ness of the key.
+func (c *PriorityCache[K, V]) Peek(key K) (value V, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if l := c.class(key); l != nil {
+		return l.Peek(key)
+	}
+	return value, false
+}
+
+// PriorityOf returns the priority of key, if it is in the cache.
+func (c *PriorityCache[K, V]) PriorityOf(key K) (p Priority, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for i, l := range c.classes {
+		if l.Contains(key) {
+			return Priority(i), true
+		}
+	}
+	return 0, false
+}
+
+// Contains checks if a key is in the cache, without updating its
+// recent-ness.
+func (c *PriorityCache[K, V]) Contains(key K) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.class(key) != nil
+}
+
+// Remove removes the provided key from the cache, returning true if the key
+// was contained.
+func (c *PriorityCache[K, V]) Remove(key K) (present bool) {
+	c.lock.Lock()
+	if l := c.class(key); l != nil {
+		present = l.Remove(key)
+	}
+	ks, vs := c.takeEvicted()
+	c.lock.Unlock()
+	c.notify(ks, vs)
+	return present
+}
+
+// Keys returns a slice of the keys in the cache, from lowest to highest
+// priority and from oldest to newest within a priority.
+func (c *PriorityCache[K, V]) Keys() []K {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	keys := make([]K, 0, c.len())
+	for _, l := range c.classes {
+		keys = append(keys, l.Keys()...)
+	}
+	return keys
+}
+
+// Len returns the number of items in the cache.
+func (c *PriorityCache[K, V]) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.len()
+}
+
+// Resize changes the cache size, returning the number of entries evicted.
+func (c *PriorityCache[K, V]) Resize(size int) (evicted int) {
+	if size <= 0 {
+		return 0
+	}
+	c.lock.Lock()
+	for c.len() > size {
+		c.evictLowest()
+		evicted++
+	}
+	c.size = size
+	for _, l := range c.classes {
+		l.Resize(size)
+	}
+	ks, vs := c.takeEvicted()
+	c.lock.Unlock()
+	c.notify(ks, vs)
+	return evicted
+}
+
+// Purge is used to completely clear the cache.
+func (c *PriorityCache[K, V]) Purge() {
+	c.lock.Lock()
+	for _, l := range c.classes {
+		l.Purge()
+	}
+	ks, vs := c.takeEvicted()
+	c.lock.Unlock()
+	c.notify(ks, vs)
+}
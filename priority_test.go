@@ -0,0 +1,76 @@
+package dailzLRU
+
+import "testing"
+
+func TestPriority(t *testing.T) {
+	var evicted []int
+	l, err := NewPriority[int, int](4, func(k, v int) {
+		evicted = append(evicted, k)
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.AddWithPriority(1, 1, PriorityHigh)
+	l.AddWithPriority(2, 2, PriorityHigh)
+	l.Add(3, 3)
+	l.AddWithPriority(4, 4, PriorityLow)
+
+	// the low priority entry goes first even though it is the newest
+	if !l.Add(5, 5) {
+		t.Fatalf("should have evicted")
+	}
+	if len(evicted) != 1 || evicted[0] != 4 {
+		t.Fatalf("bad evicted keys: %v", evicted)
+	}
+	// then the least recently used normal priority entry
+	l.Get(3)
+	l.Add(6, 6)
+	if len(evicted) != 2 || evicted[1] != 5 {
+		t.Fatalf("bad evicted keys: %v", evicted)
+	}
+
+	// changing the priority of a key moves it without an eviction
+	l.AddWithPriority(1, 10, PriorityLow)
+	if p, ok := l.PriorityOf(1); !ok || p != PriorityLow {
+		t.Fatalf("bad priority: %v, %v", p, ok)
+	}
+	if v, ok := l.Peek(1); !ok || v != 10 || len(evicted) != 2 {
+		t.Fatalf("bad value: %v, %v, %v", v, ok, evicted)
+	}
+	if keys := l.Keys(); len(keys) != 4 || keys[0] != 1 || keys[3] != 2 {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	if n := l.Resize(2); n != 2 || l.Contains(1) || !l.Contains(2) {
+		t.Fatalf("bad resize: %v, %v", n, l.Keys())
+	}
+	l.Purge()
+	if l.Len() != 0 || len(evicted) != 6 {
+		t.Fatalf("bad purge: %v, %v", l.Len(), evicted)
+	}
+}
+
+func TestPriority_SetQuota(t *testing.T) {
+	l, err := NewPriority[int, int](10, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		l.AddWithPriority(i, i, PriorityLow)
+	}
+	if n := l.SetQuota(PriorityLow, 2); n != 2 || l.Contains(1) || !l.Contains(2) {
+		t.Fatalf("bad quota eviction: %v, %v", n, l.Keys())
+	}
+
+	// low priority churn stays within its quota and leaves room for others
+	for i := 100; i < 200; i++ {
+		l.AddWithPriority(i, i, PriorityLow)
+	}
+	for i := 0; i < 8; i++ {
+		if l.AddWithPriority(1000+i, i, PriorityHigh) {
+			t.Fatalf("high priority entry %v should fit", i)
+		}
+	}
+	if l.Len() != 10 || !l.Contains(198) || !l.Contains(199) {
+		t.Fatalf("bad keys: %v", l.Keys())
+	}
+}
@@ -0,0 +1,22 @@
+package dailzLRU
+
+// RandomCache is a thread-safe fixed size cache that evicts a uniformly
+// random entry to make room. It tracks no recency at all, which makes it a
+// cheap baseline, and it is adequate for workloads without much locality.
+type RandomCache[K comparable, V any] struct {
+	*SampledCache[K, V]
+}
+
+// randomScore rates every entry alike, so the sampled entry is evicted
+func randomScore[K comparable, V any](e *sampledEntry[K, V], now uint64) float64 {
+	return 0
+}
+
+// NewRandom constructs a RandomCache of the given size.
+func NewRandom[K comparable, V any](size int, onEvicted func(key K, value V)) (*RandomCache[K, V], error) {
+	c, err := newSampled[K, V](size, 1, randomScore[K, V], onEvicted)
+	if err != nil {
+		return nil, err
+	}
+	return &RandomCache[K, V]{c}, nil
+}
@@ -0,0 +1,32 @@
+package dailzLRU
+
+import "testing"
+
+func TestRandom(t *testing.T) {
+	var evicted []int
+	l, err := NewRandom[int, int](100, func(k, v int) {
+		evicted = append(evicted, k)
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 200; i++ {
+		if l.Add(i, i) != (i >= 100) {
+			t.Fatalf("bad eviction for %v", i)
+		}
+	}
+	if l.Len() != 100 || len(evicted) != 100 {
+		t.Fatalf("bad len: %v, %v", l.Len(), len(evicted))
+	}
+
+	// LRU would only have evicted the first 100 keys
+	recent := 0
+	for _, k := range evicted {
+		if k >= 100 {
+			recent++
+		}
+	}
+	if recent == 0 {
+		t.Fatalf("evictions are not random: %v", evicted)
+	}
+}
@@ -0,0 +1,273 @@
+package dailzLRU
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// DefaultSamples is the number of entries NewSampled compares to pick an
+// eviction victim.
+const DefaultSamples = 5
+
+// sampledEntry is an entry of a SampledCache along with the access metadata
+// its eviction score is computed from
+type sampledEntry[K comparable, V any] struct {
+	key        K
+	value      V
+	added      uint64 // clock at which the entry was added
+	lastAccess uint64 // clock at which the entry was last added or read
+	hits       uint64 // number of reads since the entry was added
+}
+
+// sampledScore rates an entry at the given clock; of the sampled entries, the
+// one with the lowest score is evicted
+type sampledScore[K comparable, V any] func(e *sampledEntry[K, V], now uint64) float64
+
+// lruScore approximates LRU: the least recently accessed entry goes first
+func lruScore[K comparable, V any](e *sampledEntry[K, V], now uint64) float64 {
+	return float64(e.lastAccess)
+}
+
+// hyperbolicScore rates an entry by its access rate over its time in the
+// cache, counting the access that added it
+func hyperbolicScore[K comparable, V any](e *sampledEntry[K, V], now uint64) float64 {
+	return float64(e.hits+1) / float64(now-e.added+1)
+}
+
+// SampledCache is a thread-safe fixed size cache with approximate LRU
+// eviction. It keeps no recency list: every entry records the logical time
+// of its last access, and when the cache is full a few entries are picked at
+// random and the least recently used of them is evicted. This saves the two
+// list pointers per entry and the list maintenance on every read, at the
+// cost of sometimes evicting an entry that is not the oldest. NewHyperbolic
+// builds one that ranks the sampled entries by hit rate instead.
+type SampledCache[K comparable, V any] struct {
+	size        int
+	samples     int
+	score       sampledScore[K, V]
+	items       map[K]int
+	entries     []sampledEntry[K, V]
+	clock       uint64
+	rand        *rand.Rand
+	evictedKeys []K
+	evictedVals []V
+	onEvictedCB func(k K, v V)
+	lock        sync.Mutex
+}
+
+// NewSampled constructs a SampledCache of the given size that compares
+// DefaultSamples entries per eviction.
+func NewSampled[K comparable, V any](size int, onEvicted func(key K, value V)) (*SampledCache[K, V], error) {
+	return NewSampledWithSamples[K, V](size, DefaultSamples, onEvicted)
+}
+
+// NewSampledWithSamples constructs a SampledCache that compares the given
+// number of entries per eviction. More samples approximate LRU more closely
+// but make evictions slower.
+func NewSampledWithSamples[K comparable, V any](size, samples int, onEvicted func(key K, value V)) (*SampledCache[K, V], error) {
+	return newSampled[K, V](size, samples, lruScore[K, V], onEvicted)
+}
+
+// NewPowerOfTwoChoices constructs a SampledCache that evicts the least
+// recently used of two random entries. Comparing just two entries already
+// avoids evicting recently used entries most of the time; use
+// NewSampledWithSamples to compare more.
+func NewPowerOfTwoChoices[K comparable, V any](size int, onEvicted func(key K, value V)) (*SampledCache[K, V], error) {
+	return NewSampledWithSamples[K, V](size, 2, onEvicted)
+}
+
+// NewHyperbolic constructs a SampledCache using hyperbolic caching: of
+// DefaultSamples random entries it evicts the one with the fewest hits per
+// unit of time spent in the cache. Unlike LRU this accounts for frequency,
+// and unlike LFU an entry's past hits weigh less the longer it stays, so
+// entries that were popular once do not linger. A new entry starts with a
+// high rate that decays unless it is hit.
+func NewHyperbolic[K comparable, V any](size int, onEvicted func(key K, value V)) (*SampledCache[K, V], error) {
+	return newSampled[K, V](size, DefaultSamples, hyperbolicScore[K, V], onEvicted)
+}
+
+func newSampled[K comparable, V any](size, samples int, score sampledScore[K, V], onEvicted func(key K, value V)) (*SampledCache[K, V], error) {
+	if size <= 0 {
+		return nil, errors.New("must provide a positive size")
+	}
+	if samples <= 0 {
+		return nil, errors.New("must provide a positive sample count")
+	}
+	return &SampledCache[K, V]{
+		size:        size,
+		samples:     samples,
+		score:       score,
+		items:       make(map[K]int),
+		rand:        rand.New(rand.NewSource(time.Now().UnixNano())),
+		onEvictedCB: onEvicted,
+	}, nil
+}
+
+// tick advances the logical clock
+func (c *SampledCache[K, V]) tick() uint64 {
+	c.clock++
+	return c.clock
+}
+
+// removeAt removes the entry at index i by moving the last entry into its
+// place, buffering the eviction callback
+func (c *SampledCache[K, V]) removeAt(i int) {
+	e := c.entries[i]
+	last := len(c.entries) - 1
+	if i != last {
+		c.entries[i] = c.entries[last]
+		c.items[c.entries[i].key] = i
+	}
+	c.entries[last] = sampledEntry[K, V]{}
+	c.entries = c.entries[:last]
+	delete(c.items, e.key)
+	if c.onEvictedCB != nil {
+		c.evictedKeys = append(c.evictedKeys, e.key)
+		c.evictedVals = append(c.evictedVals, e.value)
+	}
+}
+
+// evict removes the lowest scoring of a random sample of entries
+func (c *SampledCache[K, V]) evict() {
+	victim := -1
+	var lowest float64
+	for n := 0; n < c.samples; n++ {
+		i := c.rand.Intn(len(c.entries))
+		if s := c.score(&c.entries[i], c.clock); victim < 0 || s < lowest {
+			victim, lowest = i, s
+		}
+	}
+	c.removeAt(victim)
+}
+
+// takeEvicted hands back the buffered evictions and resets the buffers
+func (c *SampledCache[K, V]) takeEvicted() (ks []K, vs []V) {
+	ks, vs = c.evictedKeys, c.evictedVals
+	c.evictedKeys, c.evictedVals = nil, nil
+	return ks, vs
+}
+
+// notify invokes the eviction callback for buffered evictions
+func (c *SampledCache[K, V]) notify(ks []K, vs []V) {
+	for i := 0; i < len(ks); i++ {
+		c.onEvictedCB(ks[i], vs[i])
+	}
+}
+
+// Add adds a value to the cache. Returns true if an eviction occurred.
+func (c *SampledCache[K, V]) Add(key K, value V) (evicted bool) {
+	c.lock.Lock()
+	now := c.tick()
+	if i, ok := c.items[key]; ok {
+		c.entries[i].value = value
+		c.entries[i].lastAccess = now
+		c.lock.Unlock()
+		return false
+	}
+	if len(c.entries) > 0 && len(c.entries) >= c.size {
+		c.evict()
+		evicted = true
+	}
+	c.items[key] = len(c.entries)
+	c.entries = append(c.entries, sampledEntry[K, V]{key: key, value: value, added: now, lastAccess: now})
+	ks, vs := c.takeEvicted()
+	c.lock.Unlock()
+	c.notify(ks, vs)
+	return evicted
+}
+
+// Get looks up a key's value from the cache, recording the access.
+func (c *SampledCache[K, V]) Get(key K) (value V, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	i, ok := c.items[key]
+	if !ok {
+		return value, false
+	}
+	e := &c.entries[i]
+	e.lastAccess = c.tick()
+	e.hits++
+	return e.value, true
+}
+
+// Peek returns the key value (or undefined if not found) without recording
+// an access.
+func (c *SampledCache[K, V]) Peek(key K) (value V, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if i, ok := c.items[key]; ok {
+		return c.entries[i].value, true
+	}
+	return value, false
+}
+
+// Contains checks if a key is in the cache, without recording an access.
+func (c *SampledCache[K, V]) Contains(key K) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	_, ok := c.items[key]
+	return ok
+}
+
+// Remove removes the provided key from the cache, returning true if the key
+// was contained.
+func (c *SampledCache[K, V]) Remove(key K) (present bool) {
+	c.lock.Lock()
+	i, present := c.items[key]
+	if present {
+		c.removeAt(i)
+	}
+	ks, vs := c.takeEvicted()
+	c.lock.Unlock()
+	c.notify(ks, vs)
+	return present
+}
+
+// Keys returns a slice of the keys in the cache, in no particular order.
+func (c *SampledCache[K, V]) Keys() []K {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	keys := make([]K, len(c.entries))
+	for i := range c.entries {
+		keys[i] = c.entries[i].key
+	}
+	return keys
+}
+
+// Len returns the number of items in the cache.
+func (c *SampledCache[K, V]) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.entries)
+}
+
+// Resize changes the cache size, returning the number of entries evicted.
+// A size that is not positive is ignored.
+func (c *SampledCache[K, V]) Resize(size int) (evicted int) {
+	if size <= 0 {
+		return 0
+	}
+	c.lock.Lock()
+	for len(c.entries) > size {
+		c.evict()
+		evicted++
+	}
+	c.size = size
+	ks, vs := c.takeEvicted()
+	c.lock.Unlock()
+	c.notify(ks, vs)
+	return evicted
+}
+
+// Purge is used to completely clear the cache.
+func (c *SampledCache[K, V]) Purge() {
+	c.lock.Lock()
+	for len(c.entries) > 0 {
+		c.removeAt(len(c.entries) - 1)
+	}
+	ks, vs := c.takeEvicted()
+	c.lock.Unlock()
+	c.notify(ks, vs)
+}
@@ -0,0 +1,113 @@
+package dailzLRU
+
+import "testing"
+
+func TestSampled(t *testing.T) {
+	var evicted []int
+	l, err := NewSampledWithSamples[int, int](128, 4096, func(k, v int) {
+		if k != v {
+			t.Fatalf("evict values not equal (%v!=%v)", k, v)
+		}
+		evicted = append(evicted, k)
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 128; i++ {
+		l.Add(i, i)
+	}
+	l.Get(0)
+
+	// with enough samples every entry is compared, so evictions are exact LRU
+	if !l.Add(128, 128) {
+		t.Fatalf("should have evicted")
+	}
+	if len(evicted) != 1 || evicted[0] != 1 {
+		t.Fatalf("bad evicted keys: %v", evicted)
+	}
+	if l.Len() != 128 || !l.Contains(0) || l.Contains(1) {
+		t.Fatalf("bad state: %v", l.Len())
+	}
+
+	if !l.Remove(0) || l.Remove(0) {
+		t.Fatalf("bad remove")
+	}
+	if v, ok := l.Peek(64); !ok || v != 64 {
+		t.Fatalf("bad value: %v, %v", v, ok)
+	}
+	for _, k := range l.Keys() {
+		if v, ok := l.Peek(k); !ok || v != k {
+			t.Fatalf("bad value for %v: %v, %v", k, v, ok)
+		}
+	}
+	if n := l.Resize(10); n != 117 || l.Len() != 10 {
+		t.Fatalf("bad resize: %v, %v", n, l.Len())
+	}
+	if n := l.Resize(0); n != 0 || l.Len() != 10 {
+		t.Fatalf("bad resize to 0: %v, %v", n, l.Len())
+	}
+	l.Add(200, 200)
+	if l.Len() != 10 {
+		t.Fatalf("bad len after resize to 0: %v", l.Len())
+	}
+	l.Purge()
+	if l.Len() != 0 || len(evicted) != 130 {
+		t.Fatalf("bad purge: %v, %v", l.Len(), len(evicted))
+	}
+}
+
+func TestPowerOfTwoChoices(t *testing.T) {
+	l, err := NewPowerOfTwoChoices[int, int](100, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	// keep a hot set in use while streaming other keys through
+	misses := 0
+	for i := 100; i < 1000; i++ {
+		for k := 0; k < 10; k++ {
+			if _, ok := l.Get(k); !ok {
+				misses++
+				l.Add(k, k)
+			}
+		}
+		l.Add(i, i)
+	}
+	// a hot entry is only evicted when both samples are hot entries, which
+	// happens for about 1% of the evictions; random eviction would pick
+	// one for 10% of them
+	if misses > 10+45 {
+		t.Fatalf("too many hot entries were evicted: %v", misses)
+	}
+}
+
+func TestHyperbolic(t *testing.T) {
+	l, err := NewHyperbolic[int, int](100, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	// 10 popular keys among 90 others hit once
+	for i := 0; i < 100; i++ {
+		l.Add(i, i)
+	}
+	for n := 0; n < 50; n++ {
+		for i := 0; i < 10; i++ {
+			l.Get(i)
+		}
+	}
+	for i := 1000; i < 1200; i++ {
+		l.Add(i, i)
+	}
+	if l.Len() != 100 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+	kept := 0
+	for i := 0; i < 10; i++ {
+		if l.Contains(i) {
+			kept++
+		}
+	}
+	// LRU would keep none of them
+	if kept < 8 {
+		t.Fatalf("popular keys were evicted: %d of 10 kept", kept)
+	}
+}
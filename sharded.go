@@ -0,0 +1,313 @@
+package dailzLRU
+
+import (
+	"encoding/binary"
+	"errors"
+	"github.com/dailz1/dailzLRU/lru"
+	"hash/maphash"
+	"math"
+	"reflect"
+	"runtime"
+)
+
+// cacheLineSize is the span of memory, in bytes, kept between the locks of
+// padded shards. It covers the 64-byte cache lines of common CPUs and the
+// pairs of lines they prefetch together.
+const cacheLineSize = 128
+
+// Sharded spreads keys over a number of independent caches by hash, so
+// callers working on different keys contend on different locks. Each shard
+// runs its own eviction policy on its share of the keys, which makes the
+// whole an approximation of that policy. Any Interface can be a shard, so a
+// TwoQueueCache or an ExpirableCache shards just like a Cache.
+type Sharded[K comparable, V any] struct {
+	shards []Interface[K, V]
+	hash   func(K) uint64
+}
+
+// NewSharded constructs a Sharded cache of n shards built by newShard;
+// DefaultShardCount is a good choice of n for a cache used by many
+// goroutines. Keys of string, integer or unsigned integer types are hashed
+// directly. Keys of other types are hashed by reflection, the way Go maps
+// compare them: pointers and channels by identity, floats by value, so 0.0
+// and -0.0 share a shard, and structs, arrays and interfaces field by
+// field. That is several times slower, so such keys on a hot path should
+// use NewShardedWithHash.
+func NewSharded[K comparable, V any](n int, newShard func() (Interface[K, V], error)) (*Sharded[K, V], error) {
+	seed := maphash.MakeSeed()
+	return NewShardedWithHash[K, V](n, func(key K) uint64 { return hashKey(seed, key) }, newShard)
+}
+
+// NewShardedWithHash is like NewSharded, spreading keys by the given hash
+// function.
+func NewShardedWithHash[K comparable, V any](n int, hash func(K) uint64, newShard func() (Interface[K, V], error)) (*Sharded[K, V], error) {
+	if n <= 0 {
+		return nil, errors.New("must provide a positive shard count")
+	}
+	if hash == nil {
+		return nil, errors.New("must provide a hash function")
+	}
+	s := &Sharded[K, V]{shards: make([]Interface[K, V], n), hash: hash}
+	for i := range s.shards {
+		c, err := newShard()
+		if err != nil {
+			return nil, err
+		}
+		s.shards[i] = c
+	}
+	return s, nil
+}
+
+// DefaultShardCount returns the number of shards used when none is given:
+// four per GOMAXPROCS, rounded up to a power of two, so that concurrent
+// callers rarely contend on the same shard.
+func DefaultShardCount() int {
+	n := 1
+	for n < 4*runtime.GOMAXPROCS(0) {
+		n <<= 1
+	}
+	return n
+}
+
+// ShardedOptions configures NewShardedLRU.
+type ShardedOptions[K comparable, V any] struct {
+	// Shards is the number of shards. Zero means DefaultShardCount.
+	Shards int
+	// Hash spreads keys over the shards. Nil hashes keys as NewSharded
+	// does.
+	Hash func(K) uint64
+	// OnEvicted, if not nil, is called for the entries evicted or removed
+	// from any shard.
+	OnEvicted func(key K, value V)
+	// Unpadded packs the shards together. By default each shard is padded
+	// to keep its lock on cache lines of its own, so that callers working
+	// on adjacent shards do not slow each other down through false
+	// sharing, at the cost of cacheLineSize bytes per shard.
+	Unpadded bool
+}
+
+// paddedCache is a Cache followed by enough padding that the lock of the
+// next Cache in a slice does not share a cache line with its own
+type paddedCache[K comparable, V any] struct {
+	Cache[K, V]
+	_ [cacheLineSize]byte
+}
+
+// NewShardedLRU constructs a Sharded cache of LRU Caches holding up to size
+// entries in total, split as evenly as possible between the shards. The
+// shard count must not exceed size; the default one is lowered to size if
+// it would. Unlike shards built by NewSharded, which are allocated wherever
+// newShard puts them, the shards are allocated together, padded unless
+// opts.Unpadded is set.
+func NewShardedLRU[K comparable, V any](size int, opts ShardedOptions[K, V]) (*Sharded[K, V], error) {
+	if size <= 0 {
+		return nil, errors.New("must provide a positive size")
+	}
+	n := opts.Shards
+	if n == 0 {
+		n = min(DefaultShardCount(), size)
+	}
+	if n < 0 {
+		return nil, errors.New("must provide a non-negative shard count")
+	}
+	if n > size {
+		return nil, errors.New("shard count must not exceed the size")
+	}
+	hash := opts.Hash
+	if hash == nil {
+		seed := maphash.MakeSeed()
+		hash = func(key K) uint64 { return hashKey(seed, key) }
+	}
+	// the first size%n shards hold one entry more than the others
+	shard := 0
+	var next func() *Cache[K, V]
+	if opts.Unpadded {
+		caches := make([]Cache[K, V], n)
+		next = func() *Cache[K, V] {
+			c := &caches[0]
+			caches = caches[1:]
+			return c
+		}
+	} else {
+		caches := make([]paddedCache[K, V], n)
+		next = func() *Cache[K, V] {
+			c := &caches[0].Cache
+			caches = caches[1:]
+			return c
+		}
+	}
+	return NewShardedWithHash[K, V](n, hash, func() (Interface[K, V], error) {
+		c := next()
+		perShard := size / n
+		if shard < size%n {
+			perShard++
+		}
+		shard++
+		if err := c.init(perShard, opts.OnEvicted, lru.NewLRU[K, V]); err != nil {
+			return nil, err
+		}
+		return c, nil
+	})
+}
+
+// hashKey hashes key with seed, so that keys equal under == hash alike
+func hashKey[K comparable](seed maphash.Seed, key K) uint64 {
+	switch k := any(key).(type) {
+	case string:
+		return maphash.String(seed, k)
+	case int:
+		return mix64(uint64(k))
+	case int8:
+		return mix64(uint64(k))
+	case int16:
+		return mix64(uint64(k))
+	case int32:
+		return mix64(uint64(k))
+	case int64:
+		return mix64(uint64(k))
+	case uint:
+		return mix64(uint64(k))
+	case uint8:
+		return mix64(uint64(k))
+	case uint16:
+		return mix64(uint64(k))
+	case uint32:
+		return mix64(uint64(k))
+	case uint64:
+		return mix64(k)
+	case uintptr:
+		return mix64(uint64(k))
+	}
+	var h maphash.Hash
+	h.SetSeed(seed)
+	hashValue(&h, reflect.ValueOf(&key).Elem())
+	return h.Sum64()
+}
+
+// hashValue writes v to h such that values equal under == write the same
+// bytes
+func hashValue(h *maphash.Hash, v reflect.Value) {
+	var buf [8]byte
+	writeUint := func(x uint64) {
+		binary.LittleEndian.PutUint64(buf[:], x)
+		h.Write(buf[:])
+	}
+	writeFloat := func(f float64) {
+		if f == 0 {
+			f = 0 // -0.0 == 0.0
+		}
+		writeUint(math.Float64bits(f))
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			writeUint(1)
+		} else {
+			writeUint(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		writeUint(uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		writeUint(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		writeFloat(v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		writeFloat(real(v.Complex()))
+		writeFloat(imag(v.Complex()))
+	case reflect.String:
+		h.WriteString(v.String())
+	case reflect.Pointer, reflect.Chan, reflect.UnsafePointer:
+		writeUint(uint64(v.Pointer()))
+	case reflect.Interface:
+		if v.IsNil() {
+			writeUint(0)
+			return
+		}
+		v = v.Elem()
+		h.WriteString(v.Type().String())
+		hashValue(h, v)
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			hashValue(h, v.Index(i))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			hashValue(h, v.Field(i))
+		}
+	}
+}
+
+// mix64 scrambles the bits of x so that close integers land in different
+// shards
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// Shard returns the shard holding key.
+func (s *Sharded[K, V]) Shard(key K) Interface[K, V] {
+	return s.shards[s.hash(key)%uint64(len(s.shards))]
+}
+
+// Shards returns the number of shards.
+func (s *Sharded[K, V]) Shards() int {
+	return len(s.shards)
+}
+
+// Get looks up a key's value from its shard.
+func (s *Sharded[K, V]) Get(key K) (value V, ok bool) {
+	return s.Shard(key).Get(key)
+}
+
+// Peek looks up a key's value from its shard without updating its
+// recent-ness.
+func (s *Sharded[K, V]) Peek(key K) (value V, ok bool) {
+	return s.Shard(key).Peek(key)
+}
+
+// Contains checks if a key is in its shard.
+func (s *Sharded[K, V]) Contains(key K) bool {
+	return s.Shard(key).Contains(key)
+}
+
+// Add adds a value to the key's shard. Returns true if the shard evicted an
+// entry.
+func (s *Sharded[K, V]) Add(key K, value V) (evicted bool) {
+	return s.Shard(key).Add(key, value)
+}
+
+// Remove removes the provided key from its shard, returning true if the key
+// was contained.
+func (s *Sharded[K, V]) Remove(key K) (present bool) {
+	return s.Shard(key).Remove(key)
+}
+
+// Keys returns the keys of every shard, shard by shard. Shards are read one
+// after the other, so the result is not a consistent snapshot while the
+// cache is being modified.
+func (s *Sharded[K, V]) Keys() []K {
+	var keys []K
+	for _, c := range s.shards {
+		keys = append(keys, c.Keys()...)
+	}
+	return keys
+}
+
+// Len returns the total number of items in the shards.
+func (s *Sharded[K, V]) Len() (n int) {
+	for _, c := range s.shards {
+		n += c.Len()
+	}
+	return n
+}
+
+// Purge clears every shard.
+func (s *Sharded[K, V]) Purge() {
+	for _, c := range s.shards {
+		c.Purge()
+	}
+}
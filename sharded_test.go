@@ -0,0 +1,174 @@
+package dailzLRU
+
+import (
+	"hash/maphash"
+	"math"
+	"runtime"
+	"strconv"
+	"sync"
+	"testing"
+	"unsafe"
+)
+
+func TestSharded(t *testing.T) {
+	s, err := NewSharded(4, func() (Interface[int, int], error) {
+		return New2Q[int, int](64)
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if s.Shards() != 4 {
+		t.Fatalf("bad shard count: %v", s.Shards())
+	}
+	for i := 0; i < 128; i++ {
+		s.Add(i, i)
+	}
+	if s.Len() != 128 || len(s.Keys()) != 128 {
+		t.Fatalf("bad len: %v, %v", s.Len(), len(s.Keys()))
+	}
+	for i := 0; i < 4; i++ {
+		if n := s.shards[i].Len(); n == 0 || n == 128 {
+			t.Fatalf("keys are not spread over the shards: shard %d holds %d", i, n)
+		}
+	}
+	for i := 0; i < 128; i++ {
+		if v, ok := s.Get(i); !ok || v != i {
+			t.Fatalf("bad value for %v: %v, %v", i, v, ok)
+		}
+		if !s.Shard(i).Contains(i) {
+			t.Fatalf("%v is not in its shard", i)
+		}
+	}
+	if !s.Remove(5) || s.Contains(5) || s.Remove(5) {
+		t.Fatalf("bad remove")
+	}
+	s.Purge()
+	if s.Len() != 0 {
+		t.Fatalf("bad len after purge: %v", s.Len())
+	}
+
+	if _, err := NewSharded(0, func() (Interface[int, int], error) { return New[int, int](1) }); err == nil {
+		t.Fatalf("should reject a zero shard count")
+	}
+}
+
+func TestSharded_Hash(t *testing.T) {
+	type point struct{ x, y int }
+	s, err := NewSharded(8, func() (Interface[point, string], error) {
+		return New[point, string](16)
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 32; i++ {
+		s.Add(point{i, -i}, strconv.Itoa(i))
+	}
+	for i := 0; i < 32; i++ {
+		if v, ok := s.Peek(point{i, -i}); !ok || v != strconv.Itoa(i) {
+			t.Fatalf("bad value for %v: %v, %v", i, v, ok)
+		}
+	}
+
+	// keys are hashed as they compare: pointers by address, whatever they
+	// point to, and 0.0 like -0.0
+	ptrs, err := NewSharded(8, func() (Interface[*int, int], error) {
+		return New[*int, int](16)
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	p := new(int)
+	ptrs.Add(p, 1)
+	*p = 42
+	if !ptrs.Contains(p) {
+		t.Fatalf("pointer key lost after its pointee changed")
+	}
+	floats, err := NewSharded(8, func() (Interface[float64, int], error) {
+		return New[float64, int](16)
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	negZero := math.Copysign(0, -1)
+	floats.Add(negZero, 1)
+	if !floats.Contains(0.0) {
+		t.Fatalf("0.0 not found under -0.0")
+	}
+	type nested struct {
+		v any
+		f [2]float32
+	}
+	seed := maphash.MakeSeed()
+	if hashKey(seed, nested{"a", [2]float32{float32(negZero), 1}}) != hashKey(seed, nested{"a", [2]float32{0, 1}}) {
+		t.Fatalf("equal keys hashed apart")
+	}
+
+	byLen, err := NewShardedWithHash(2, func(k string) uint64 { return uint64(len(k)) }, func() (Interface[string, int], error) {
+		return New[string, int](4)
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	byLen.Add("a", 1)
+	byLen.Add("bb", 2)
+	byLen.Add("ccc", 3)
+	if byLen.Shard("a") != byLen.Shard("ccc") || byLen.Shard("a") == byLen.Shard("bb") {
+		t.Fatalf("custom hash not used")
+	}
+}
+
+func TestShardedLRU(t *testing.T) {
+	var evicted []int
+	s, err := NewShardedLRU[int, int](64, ShardedOptions[int, int]{
+		OnEvicted: func(k, v int) { evicted = append(evicted, k) },
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if n := DefaultShardCount(); s.Shards() != min(n, 64) || n&(n-1) != 0 || n < 4*runtime.GOMAXPROCS(0) {
+		t.Fatalf("bad shard count: %v, default %v", s.Shards(), n)
+	}
+	for i := 0; i < 1000; i++ {
+		s.Add(i, i)
+	}
+	if s.Len() > 64 || len(evicted) != 1000-s.Len() {
+		t.Fatalf("bad len: %v, %v evicted", s.Len(), len(evicted))
+	}
+
+	// the size is split exactly, never rounded up
+	s, err = NewShardedLRU[int, int](10, ShardedOptions[int, int]{Shards: 4})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	total := 0
+	for i, c := range s.shards {
+		size := c.(*Cache[int, int]).lru.Size()
+		if want := []int{3, 3, 2, 2}[i]; size != want {
+			t.Fatalf("bad size of shard %d: %v", i, size)
+		}
+		total += size
+	}
+	if total != 10 {
+		t.Fatalf("bad total size: %v", total)
+	}
+	if _, err := NewShardedLRU[int, int](2, ShardedOptions[int, int]{Shards: 4}); err == nil {
+		t.Fatalf("should reject more shards than entries")
+	}
+
+	// a cache line separates the lock of a shard from the next shard
+	for _, unpadded := range []bool{false, true} {
+		s, err := NewShardedLRU[int, int](64, ShardedOptions[int, int]{Shards: 4, Unpadded: unpadded})
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		lockEnd := uintptr(unsafe.Pointer(&s.shards[0].(*Cache[int, int]).lock)) + unsafe.Sizeof(sync.RWMutex{})
+		next := uintptr(unsafe.Pointer(s.shards[1].(*Cache[int, int])))
+		if padded := next-lockEnd >= cacheLineSize; padded == unpadded {
+			t.Fatalf("bad padding: unpadded %v, %v bytes after the lock", unpadded, next-lockEnd)
+		}
+	}
+
+	if _, err := NewShardedLRU[int, int](0, ShardedOptions[int, int]{}); err == nil {
+		t.Fatalf("should reject a zero size")
+	}
+}
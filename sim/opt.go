@@ -0,0 +1,75 @@
+package sim
+
+import "container/heap"
+
+// OPT is the name under which Table replays a trace through Belady's
+// optimal policy. It is not a registered Policy: the policy needs to see the
+// future, so it can only run over a whole trace, with RunOPT.
+const OPT = "opt"
+
+// optEntry is a resident key along with the position of its next access
+type optEntry struct {
+	key  uint64
+	next int
+}
+
+// optHeap orders resident keys by next access, furthest first
+type optHeap []optEntry
+
+func (h optHeap) Len() int           { return len(h) }
+func (h optHeap) Less(i, j int) bool { return h[i].next > h[j].next }
+func (h optHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *optHeap) Push(x any)        { *h = append(*h, x.(optEntry)) }
+
+func (h *optHeap) Pop() any {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
+
+// RunOPT replays trace through Belady's clairvoyant policy with room for
+// size keys: on a miss with the cache full, it evicts the key whose next
+// access is furthest in the future. No policy can score more hits on the
+// trace, so the result is the ceiling real policies should be measured
+// against.
+func RunOPT(size int, trace []uint64) Result {
+	// next[i] is the position of the access following trace[i] to the same
+	// key, or len(trace) if there is none
+	next := make([]int, len(trace))
+	last := make(map[uint64]int)
+	for i := len(trace) - 1; i >= 0; i-- {
+		if j, ok := last[trace[i]]; ok {
+			next[i] = j
+		} else {
+			next[i] = len(trace)
+		}
+		last[trace[i]] = i
+	}
+
+	var r Result
+	if size <= 0 {
+		r.Misses = len(trace)
+		return r
+	}
+	// resident maps each key to its next access; the heap may hold stale
+	// entries for a key, which are skipped when they surface
+	resident := make(map[uint64]int, size)
+	h := make(optHeap, 0, size)
+	for i, key := range trace {
+		if _, ok := resident[key]; ok {
+			r.Hits++
+		} else {
+			r.Misses++
+			for len(resident) >= size {
+				e := heap.Pop(&h).(optEntry)
+				if resident[e.key] == e.next {
+					delete(resident, e.key)
+				}
+			}
+		}
+		resident[key] = next[i]
+		heap.Push(&h, optEntry{key: key, next: next[i]})
+	}
+	return r
+}
@@ -0,0 +1,26 @@
+package sim
+
+import "testing"
+
+func TestRunOPT(t *testing.T) {
+	// the textbook reference string, with 9 faults under OPT with 3 frames
+	trace := []uint64{7, 0, 1, 2, 0, 3, 0, 4, 2, 3, 0, 3, 2, 1, 2, 0, 1, 7, 0, 1}
+	if r := RunOPT(3, trace); r.Misses != 9 || r.Hits != 11 {
+		t.Fatalf("bad OPT result: %+v", r)
+	}
+	if r := RunOPT(0, trace); r.Misses != len(trace) {
+		t.Fatalf("bad OPT result for an empty cache: %+v", r)
+	}
+
+	trace = Take(Zipf(1, 1.1, 1000), 20000)
+	results, err := Table(Policies(), []int{50}, trace)
+	if err != nil {
+		t.Fatalf("Table error: %v", err)
+	}
+	opt := RunOPT(50, trace)
+	for i, name := range Policies() {
+		if results[i][0].Hits > opt.Hits {
+			t.Fatalf("%s beats OPT: %+v > %+v", name, results[i][0], opt)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package sim
+
+import (
+	"github.com/dailz1/dailzLRU"
+	"github.com/dailz1/dailzLRU/lru"
+)
+
+func init() {
+	Register("lru", func(size int) (Policy, error) {
+		l, err := lru.NewLRU[uint64, struct{}](size, nil)
+		if err != nil {
+			return nil, err
+		}
+		return lruPolicy{l}, nil
+	})
+	Register("mru", func(size int) (Policy, error) {
+		l, err := lru.NewMRU[uint64, struct{}](size, nil)
+		if err != nil {
+			return nil, err
+		}
+		return lruPolicy{l}, nil
+	})
+	Register("fifo", func(size int) (Policy, error) {
+		l, err := lru.NewFIFO[uint64, struct{}](size, nil)
+		if err != nil {
+			return nil, err
+		}
+		return lruPolicy{l}, nil
+	})
+	Register("2q", func(size int) (Policy, error) {
+		c, err := dailzLRU.New2Q[uint64, struct{}](size)
+		if err != nil {
+			return nil, err
+		}
+		return cachePolicy{c}, nil
+	})
+	Register("sampled", func(size int) (Policy, error) {
+		c, err := dailzLRU.NewSampled[uint64, struct{}](size, nil)
+		if err != nil {
+			return nil, err
+		}
+		return cachePolicy{c}, nil
+	})
+	Register("p2c", func(size int) (Policy, error) {
+		c, err := dailzLRU.NewPowerOfTwoChoices[uint64, struct{}](size, nil)
+		if err != nil {
+			return nil, err
+		}
+		return cachePolicy{c}, nil
+	})
+	Register("hyperbolic", func(size int) (Policy, error) {
+		c, err := dailzLRU.NewHyperbolic[uint64, struct{}](size, nil)
+		if err != nil {
+			return nil, err
+		}
+		return cachePolicy{c}, nil
+	})
+	Register("random", func(size int) (Policy, error) {
+		c, err := dailzLRU.NewRandom[uint64, struct{}](size, nil)
+		if err != nil {
+			return nil, err
+		}
+		return cachePolicy{c}, nil
+	})
+	Register("lru2", func(size int) (Policy, error) {
+		c, err := dailzLRU.NewLRUK[uint64, struct{}](size, 2, nil)
+		if err != nil {
+			return nil, err
+		}
+		return cachePolicy{c}, nil
+	})
+	Register("mq", func(size int) (Policy, error) {
+		c, err := dailzLRU.NewMQ[uint64, struct{}](size, nil)
+		if err != nil {
+			return nil, err
+		}
+		return cachePolicy{c}, nil
+	})
+	Register("clockpro", func(size int) (Policy, error) {
+		c, err := dailzLRU.NewClockPro[uint64, struct{}](size, nil)
+		if err != nil {
+			return nil, err
+		}
+		return cachePolicy{c}, nil
+	})
+}
+
+// lruPolicy simulates an lru.LRU in any of its modes
+type lruPolicy struct {
+	l *lru.LRU[uint64, struct{}]
+}
+
+func (p lruPolicy) Get(key uint64) bool {
+	_, ok := p.l.Get(key)
+	return ok
+}
+
+func (p lruPolicy) Add(key uint64) {
+	p.l.Add(key, struct{}{})
+}
+
+// cachePolicy simulates one of the caches of the dailzLRU package
+type cachePolicy struct {
+	c dailzLRU.Interface[uint64, struct{}]
+}
+
+func (p cachePolicy) Get(key uint64) bool {
+	_, ok := p.c.Get(key)
+	return ok
+}
+
+func (p cachePolicy) Add(key uint64) {
+	p.c.Add(key, struct{}{})
+}
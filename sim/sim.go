@@ -0,0 +1,143 @@
+// Package sim replays key access traces through cache policies and reports
+// their hit ratios, so policies and sizes can be compared on real workloads.
+package sim
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Policy is a cache under simulation. Only keys are tracked; values do not
+// influence any of the policies.
+type Policy interface {
+	// Get looks up a key, reporting whether it was a hit.
+	Get(key uint64) bool
+	// Add inserts a key after a miss.
+	Add(key uint64)
+}
+
+// Factory constructs a Policy holding up to size keys.
+type Factory func(size int) (Policy, error)
+
+var (
+	registryLock sync.RWMutex
+	registry     = map[string]Factory{}
+)
+
+// Register makes a policy available under name. Registering a name twice
+// replaces the earlier factory.
+func Register(name string, factory Factory) {
+	registryLock.Lock()
+	registry[name] = factory
+	registryLock.Unlock()
+}
+
+// New constructs the policy registered under name.
+func New(name string, size int) (Policy, error) {
+	registryLock.RLock()
+	factory, ok := registry[name]
+	registryLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown policy %q", name)
+	}
+	return factory(size)
+}
+
+// Policies returns the names of the registered policies and OPT, sorted.
+func Policies() []string {
+	registryLock.RLock()
+	names := make([]string, 0, len(registry)+1)
+	names = append(names, OPT)
+	for name := range registry {
+		names = append(names, name)
+	}
+	registryLock.RUnlock()
+	sort.Strings(names)
+	return names
+}
+
+// Result counts the hits and misses of a replay.
+type Result struct {
+	Hits   int
+	Misses int
+}
+
+// HitRatio returns the fraction of accesses that were hits.
+func (r Result) HitRatio() float64 {
+	if total := r.Hits + r.Misses; total > 0 {
+		return float64(r.Hits) / float64(total)
+	}
+	return 0
+}
+
+// Run replays trace through p: every access is a Get, and every miss is
+// followed by an Add.
+func Run(p Policy, trace []uint64) Result {
+	var r Result
+	for _, key := range trace {
+		if p.Get(key) {
+			r.Hits++
+			continue
+		}
+		r.Misses++
+		p.Add(key)
+	}
+	return r
+}
+
+// ReadTrace reads a trace with one key per line. Blank lines and lines
+// starting with '#' are skipped. Keys that are unsigned integers are used as
+// is; any other key is hashed.
+func ReadTrace(r io.Reader) ([]uint64, error) {
+	var trace []uint64
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		trace = append(trace, ParseKey(line))
+	}
+	return trace, scanner.Err()
+}
+
+// ParseKey maps a trace key to the integer key used by the simulator.
+func ParseKey(s string) uint64 {
+	if n, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return n
+	}
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+// Table replays trace through every combination of policy and size, where
+// a policy is a registered one or OPT. The result for policies[i] at sizes[j] is at [i][j].
+func Table(policies []string, sizes []int, trace []uint64) ([][]Result, error) {
+	if len(policies) == 0 || len(sizes) == 0 {
+		return nil, errors.New("must provide at least one policy and size")
+	}
+	results := make([][]Result, len(policies))
+	for i, name := range policies {
+		results[i] = make([]Result, len(sizes))
+		for j, size := range sizes {
+			if name == OPT {
+				results[i][j] = RunOPT(size, trace)
+				continue
+			}
+			p, err := New(name, size)
+			if err != nil {
+				return nil, err
+			}
+			results[i][j] = Run(p, trace)
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,43 @@
+package sim
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadTrace(t *testing.T) {
+	trace, err := ReadTrace(strings.NewReader("# comment\n1\n\nfoo\n2\nfoo\n"))
+	if err != nil {
+		t.Fatalf("ReadTrace error: %v", err)
+	}
+	if len(trace) != 4 || trace[0] != 1 || trace[2] != 2 || trace[1] != trace[3] {
+		t.Fatalf("bad trace: %v", trace)
+	}
+}
+
+func TestTable(t *testing.T) {
+	// a loop over 4 keys fits a cache of 4 but thrashes LRU at 3
+	var trace []uint64
+	for i := 0; i < 100; i++ {
+		trace = append(trace, uint64(i%4))
+	}
+	results, err := Table([]string{"lru"}, []int{3, 4}, trace)
+	if err != nil {
+		t.Fatalf("Table error: %v", err)
+	}
+	if results[0][0].Hits != 0 {
+		t.Fatalf("LRU should thrash on a loop larger than the cache: %+v", results[0][0])
+	}
+	if results[0][1].Misses != 4 || results[0][1].HitRatio() != 0.96 {
+		t.Fatalf("bad result for a loop that fits: %+v", results[0][1])
+	}
+
+	if _, err := Table([]string{"nope"}, []int{1}, trace); err == nil {
+		t.Fatalf("unknown policies should be rejected")
+	}
+	for _, name := range []string{"lru", "2q"} {
+		if _, err := New(name, 8); err != nil {
+			t.Fatalf("policy %q should be registered: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package sim
+
+import "math/rand"
+
+// Generator produces an endless sequence of keys following some access
+// pattern.
+type Generator interface {
+	Next() uint64
+}
+
+// GeneratorFunc adapts a function to the Generator interface.
+type GeneratorFunc func() uint64
+
+func (f GeneratorFunc) Next() uint64 {
+	return f()
+}
+
+// Take returns the next n keys of g as a trace for Run.
+func Take(g Generator, n int) []uint64 {
+	trace := make([]uint64, n)
+	for i := range trace {
+		trace[i] = g.Next()
+	}
+	return trace
+}
+
+// Uniform draws keys uniformly from [0, n).
+func Uniform(seed int64, n uint64) Generator {
+	r := rand.New(rand.NewSource(seed))
+	return GeneratorFunc(func() uint64 {
+		return r.Uint64() % n
+	})
+}
+
+// Zipf draws keys from [0, n) following a Zipf distribution with exponent
+// s > 1: key 0 is the most popular and popularity decays with rank. Larger
+// exponents make the workload more skewed.
+func Zipf(seed int64, s float64, n uint64) Generator {
+	z := rand.NewZipf(rand.New(rand.NewSource(seed)), s, 1, n-1)
+	return GeneratorFunc(z.Uint64)
+}
+
+// Scan yields start, start+1, start+2, ... and never repeats a key, like a
+// one-off sequential scan over a large dataset.
+func Scan(start uint64) Generator {
+	next := start
+	return GeneratorFunc(func() uint64 {
+		key := next
+		next++
+		return key
+	})
+}
+
+// Loop cycles through [0, n) in order, like repeatedly iterating a dataset
+// of n keys.
+func Loop(n uint64) Generator {
+	var next uint64
+	return GeneratorFunc(func() uint64 {
+		key := next
+		next = (next + 1) % n
+		return key
+	})
+}
+
+// Mix draws each key from one of gens, chosen at random in proportion to
+// weights. It is useful to combine a popular working set with scans that
+// should not flush it.
+func Mix(seed int64, gens []Generator, weights []float64) Generator {
+	r := rand.New(rand.NewSource(seed))
+	var total float64
+	for _, w := range weights {
+		total += w
+	}
+	return GeneratorFunc(func() uint64 {
+		x := r.Float64() * total
+		for i, w := range weights {
+			if x < w {
+				return gens[i].Next()
+			}
+			x -= w
+		}
+		return gens[len(gens)-1].Next()
+	})
+}
@@ -0,0 +1,44 @@
+package sim
+
+import "testing"
+
+func TestGenerators(t *testing.T) {
+	loop := Take(Loop(3), 7)
+	for i, k := range loop {
+		if k != uint64(i%3) {
+			t.Fatalf("bad loop: %v", loop)
+		}
+	}
+	scan := Take(Scan(10), 3)
+	if scan[0] != 10 || scan[2] != 12 {
+		t.Fatalf("bad scan: %v", scan)
+	}
+	for _, k := range Take(Uniform(1, 5), 100) {
+		if k >= 5 {
+			t.Fatalf("uniform key out of range: %v", k)
+		}
+	}
+
+	// the head of a zipfian workload is far more popular than its tail
+	counts := make(map[uint64]int)
+	for _, k := range Take(Zipf(1, 1.2, 1000), 10000) {
+		if k >= 1000 {
+			t.Fatalf("zipf key out of range: %v", k)
+		}
+		counts[k]++
+	}
+	if counts[0] < 10*counts[100] {
+		t.Fatalf("zipf is not skewed: %v vs %v", counts[0], counts[100])
+	}
+
+	// a hot working set mixed with scans: 2Q keeps the working set, LRU
+	// lets the scans flush it
+	trace := Take(Mix(1, []Generator{Zipf(2, 1.1, 500), Scan(1 << 32)}, []float64{0.7, 0.3}), 50000)
+	results, err := Table([]string{"lru", "2q"}, []int{100}, trace)
+	if err != nil {
+		t.Fatalf("Table error: %v", err)
+	}
+	if results[1][0].Hits <= results[0][0].Hits {
+		t.Fatalf("2Q should resist scans better than LRU: lru %+v, 2q %+v", results[0][0], results[1][0])
+	}
+}
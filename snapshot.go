@@ -0,0 +1,152 @@
+package dailzLRU
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// SnapshotFormat selects how a snapshot is encoded.
+type SnapshotFormat int
+
+const (
+	// SnapshotGob encodes snapshots as a gob stream.
+	SnapshotGob SnapshotFormat = iota
+	// SnapshotJSON encodes snapshots as a stream of JSON values.
+	SnapshotJSON
+)
+
+// snapshotEncoder is implemented by both gob and JSON encoders
+type snapshotEncoder interface {
+	Encode(v any) error
+}
+
+// snapshotDecoder is implemented by both gob and JSON decoders
+type snapshotDecoder interface {
+	Decode(v any) error
+}
+
+func (f SnapshotFormat) newEncoder(w io.Writer) (snapshotEncoder, error) {
+	switch f {
+	case SnapshotGob:
+		return gob.NewEncoder(w), nil
+	case SnapshotJSON:
+		return json.NewEncoder(w), nil
+	}
+	return nil, fmt.Errorf("unknown snapshot format %d", f)
+}
+
+func (f SnapshotFormat) newDecoder(r io.Reader) (snapshotDecoder, error) {
+	switch f {
+	case SnapshotGob:
+		return gob.NewDecoder(r), nil
+	case SnapshotJSON:
+		return json.NewDecoder(r), nil
+	}
+	return nil, fmt.Errorf("unknown snapshot format %d", f)
+}
+
+// snapshotVersion is the version of the snapshot format written by
+// WriteSnapshot
+const snapshotVersion = 1
+
+// SnapshotInfo is the header that starts every snapshot.
+type SnapshotInfo struct {
+	Version int
+	Created time.Time
+	Entries int
+}
+
+// SnapshotEntry is a single cache entry of a snapshot.
+type SnapshotEntry[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+// WriteSnapshotEntries writes entries, which should be ordered from oldest to
+// newest, as a snapshot in the given format.
+func WriteSnapshotEntries[K comparable, V any](w io.Writer, format SnapshotFormat, entries []SnapshotEntry[K, V]) error {
+	enc, err := format.newEncoder(w)
+	if err != nil {
+		return err
+	}
+	info := SnapshotInfo{Version: snapshotVersion, Created: time.Now(), Entries: len(entries)}
+	if err := enc.Encode(&info); err != nil {
+		return err
+	}
+	for i := range entries {
+		if err := enc.Encode(&entries[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadSnapshotEntries decodes a snapshot in the given format and calls fn for
+// every entry, oldest first, stopping at the first error fn returns. Returns
+// the snapshot's header and the number of entries passed to fn.
+func ReadSnapshotEntries[K comparable, V any](r io.Reader, format SnapshotFormat, fn func(key K, value V) error) (info SnapshotInfo, n int, err error) {
+	dec, err := format.newDecoder(r)
+	if err != nil {
+		return info, 0, err
+	}
+	if err := dec.Decode(&info); err != nil {
+		return info, 0, err
+	}
+	if info.Version != snapshotVersion {
+		return info, 0, fmt.Errorf("unsupported snapshot version %d", info.Version)
+	}
+	for ; n < info.Entries; n++ {
+		var entry SnapshotEntry[K, V]
+		if err := dec.Decode(&entry); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return info, n, err
+		}
+		if err := fn(entry.Key, entry.Value); err != nil {
+			return info, n, err
+		}
+	}
+	return info, n, nil
+}
+
+// WriteSnapshot writes the cache's entries, from oldest to newest, to w as a
+// gob stream. The entries are copied under a read lock and encoded after it
+// is released, so writers are only blocked while the copy is taken.
+func (c *Cache[K, V]) WriteSnapshot(w io.Writer) error {
+	return c.WriteSnapshotFormat(w, SnapshotGob)
+}
+
+// WriteSnapshotFormat is like WriteSnapshot but encodes the snapshot in the
+// given format.
+func (c *Cache[K, V]) WriteSnapshotFormat(w io.Writer, format SnapshotFormat) error {
+	c.lock.RLock()
+	entries := make([]SnapshotEntry[K, V], 0, c.lru.Len())
+	c.lru.Range(func(key K, value V) bool {
+		entries = append(entries, SnapshotEntry[K, V]{Key: key, Value: value})
+		return true
+	})
+	c.lock.RUnlock()
+	return WriteSnapshotEntries(w, format, entries)
+}
+
+// ReadSnapshot adds the entries of a snapshot written by WriteSnapshot to
+// the cache, oldest first, so their recency order is restored. If the
+// snapshot holds more entries than fit in the cache, the oldest ones are
+// evicted as usual. Returns the number of entries read.
+func (c *Cache[K, V]) ReadSnapshot(r io.Reader) (n int, err error) {
+	return c.ReadSnapshotFormat(r, SnapshotGob)
+}
+
+// ReadSnapshotFormat is like ReadSnapshot for a snapshot encoded in the
+// given format.
+func (c *Cache[K, V]) ReadSnapshotFormat(r io.Reader, format SnapshotFormat) (n int, err error) {
+	_, n, err = ReadSnapshotEntries(r, format, func(key K, value V) error {
+		c.Add(key, value)
+		return nil
+	})
+	return n, err
+}
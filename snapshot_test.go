@@ -0,0 +1,43 @@
+package dailzLRU
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCache_Snapshot(t *testing.T) {
+	src, err := New[string, int](8)
+	if err != nil {
+		t.Fatalf("LRU error: %v", err)
+	}
+	for i, k := range []string{"a", "b", "c", "d"} {
+		src.Add(k, i)
+	}
+	src.Get("a")
+
+	var buf bytes.Buffer
+	if err := src.WriteSnapshot(&buf); err != nil {
+		t.Fatalf("WriteSnapshot error: %v", err)
+	}
+	data := buf.Bytes()
+
+	dst, err := New[string, int](3)
+	if err != nil {
+		t.Fatalf("LRU error: %v", err)
+	}
+	n, err := dst.ReadSnapshot(bytes.NewReader(data))
+	if err != nil || n != 4 {
+		t.Fatalf("ReadSnapshot error: %v, n = %v", err, n)
+	}
+	keys := dst.Keys()
+	if len(keys) != 3 || keys[0] != "c" || keys[1] != "d" || keys[2] != "a" {
+		t.Fatalf("bad keys after ReadSnapshot: %v", keys)
+	}
+	if v, ok := dst.Get("a"); !ok || v != 0 {
+		t.Fatalf("bad value after ReadSnapshot: %v", v)
+	}
+
+	if _, err := dst.ReadSnapshot(bytes.NewReader(data[:len(data)-4])); err == nil {
+		t.Fatalf("a truncated snapshot should fail")
+	}
+}
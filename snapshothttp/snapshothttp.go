@@ -0,0 +1,243 @@
+// Package snapshothttp serves cache snapshots over HTTP, so a new replica
+// can be seeded from the warm cache of a peer.
+package snapshothttp
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/dailz1/dailzLRU"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MediaTypeGob is the media type of gob encoded snapshots.
+	MediaTypeGob = "application/x-gob"
+	// MediaTypeJSON is the media type of JSON encoded snapshots.
+	MediaTypeJSON = "application/json"
+)
+
+// DefaultMaxImportBytes is the largest snapshot Handler imports.
+const DefaultMaxImportBytes = 64 << 20
+
+// Store is implemented by caches that can write and read snapshots, such
+// as *dailzLRU.Cache.
+type Store interface {
+	WriteSnapshotFormat(w io.Writer, format dailzLRU.SnapshotFormat) error
+	ReadSnapshotFormat(r io.Reader, format dailzLRU.SnapshotFormat) (int, error)
+}
+
+// Handler returns a handler that exports a snapshot of c on GET and imports
+// one into c on PUT or POST.
+//
+// Exports are encoded in the format of the Accept header with the highest
+// quality value the handler supports, the first one among equals, gob if
+// there is none, and gzip compressed when the client accepts it. Imports
+// are decoded according to their Content-Type, gob if it is missing, and
+// decompressed when their Content-Encoding is gzip. Imports of more than
+// DefaultMaxImportBytes are rejected.
+func Handler(c Store) http.Handler {
+	return HandlerWithLimit(c, DefaultMaxImportBytes)
+}
+
+// HandlerWithLimit is like Handler, rejecting imports of more than maxBytes
+// with 413 Request Entity Too Large. The limit applies to both the request
+// body and, for compressed imports, the decompressed snapshot.
+func HandlerWithLimit(c Store, maxBytes int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			export(c, w, r)
+		case http.MethodPut, http.MethodPost:
+			ingest(c, w, r, maxBytes)
+		default:
+			w.Header().Set("Allow", "GET, HEAD, PUT, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// export writes a snapshot of c to the response
+func export(c Store, w http.ResponseWriter, r *http.Request) {
+	mediaType, format, ok := negotiate(r.Header.Get("Accept"))
+	if !ok {
+		http.Error(w, "unsupported snapshot format", http.StatusNotAcceptable)
+		return
+	}
+	w.Header().Set("Content-Type", mediaType)
+	w.Header().Add("Vary", "Accept")
+	w.Header().Add("Vary", "Accept-Encoding")
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	var out io.Writer = w
+	if acceptsGzip(r.Header.Get("Accept-Encoding")) {
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		defer gz.Close()
+		out = gz
+	}
+	// the status is already sent once encoding starts, so a failure can
+	// only be signalled by cutting the body short
+	c.WriteSnapshotFormat(out, format)
+}
+
+// ingest reads a snapshot of up to maxBytes from the request into c
+func ingest(c Store, w http.ResponseWriter, r *http.Request, maxBytes int64) {
+	format := dailzLRU.SnapshotGob
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		mediaType, _, err := mime.ParseMediaType(ct)
+		var ok bool
+		if err == nil {
+			format, ok = formatOf(mediaType)
+		}
+		if !ok {
+			http.Error(w, "unsupported snapshot format", http.StatusUnsupportedMediaType)
+			return
+		}
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxBytes)
+	var in io.Reader = body
+	switch strings.ToLower(r.Header.Get("Content-Encoding")) {
+	case "", "identity":
+	case "gzip":
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			importError(w, 0, err)
+			return
+		}
+		defer gz.Close()
+		// a small body can decompress to a huge snapshot
+		in = http.MaxBytesReader(w, gz, maxBytes)
+	default:
+		http.Error(w, "unsupported content encoding", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	n, err := c.ReadSnapshotFormat(in, format)
+	if err != nil {
+		importError(w, n, err)
+		return
+	}
+	fmt.Fprintf(w, "imported %d entries\n", n)
+}
+
+// importError reports an import that failed after n entries
+func importError(w http.ResponseWriter, n int, err error) {
+	code := http.StatusBadRequest
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		code = http.StatusRequestEntityTooLarge
+	}
+	http.Error(w, fmt.Sprintf("imported %d entries: %v", n, err), code)
+}
+
+// formatOf maps a media type to a snapshot format
+func formatOf(mediaType string) (dailzLRU.SnapshotFormat, bool) {
+	switch mediaType {
+	case MediaTypeGob:
+		return dailzLRU.SnapshotGob, true
+	case MediaTypeJSON:
+		return dailzLRU.SnapshotJSON, true
+	}
+	return 0, false
+}
+
+// negotiate picks the snapshot format for an Accept header: the supported
+// one with the highest quality value, the first one among equals
+func negotiate(accept string) (string, dailzLRU.SnapshotFormat, bool) {
+	if accept == "" {
+		return MediaTypeGob, dailzLRU.SnapshotGob, true
+	}
+	var best string
+	var bestFormat dailzLRU.SnapshotFormat
+	bestQ := 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q, ok := quality(params["q"])
+		if !ok || q <= bestQ {
+			continue
+		}
+		if mediaType == "*/*" || mediaType == "application/*" {
+			best, bestFormat, bestQ = MediaTypeGob, dailzLRU.SnapshotGob, q
+		} else if format, ok := formatOf(mediaType); ok {
+			best, bestFormat, bestQ = mediaType, format, q
+		}
+	}
+	return best, bestFormat, best != ""
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip, either
+// by name or through "*", with a quality value above 0
+func acceptsGzip(acceptEncoding string) bool {
+	gzipQ, anyQ := -1.0, -1.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if name, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.EqualFold(strings.TrimSpace(name), "q") {
+			var valid bool
+			if q, valid = quality(strings.TrimSpace(value)); !valid {
+				continue
+			}
+		}
+		switch coding = strings.TrimSpace(coding); {
+		case strings.EqualFold(coding, "gzip"):
+			gzipQ = q
+		case coding == "*":
+			anyQ = q
+		}
+	}
+	if gzipQ >= 0 {
+		return gzipQ > 0
+	}
+	return anyQ > 0
+}
+
+// quality parses a quality value, 1 if it is missing
+func quality(q string) (float64, bool) {
+	if q == "" {
+		return 1, true
+	}
+	v, err := strconv.ParseFloat(q, 64)
+	if err != nil || v < 0 || v > 1 {
+		return 0, false
+	}
+	return v, true
+}
+
+// Seed fetches a snapshot from url, typically a Handler of a warm peer, and
+// imports it into c. It asks for the given format; compression is handled
+// transparently by client. Returns the number of entries imported.
+func Seed(ctx context.Context, client *http.Client, url string, c Store, format dailzLRU.SnapshotFormat) (int, error) {
+	mediaType := MediaTypeGob
+	if format == dailzLRU.SnapshotJSON {
+		mediaType = MediaTypeJSON
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", mediaType)
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("fetching snapshot: %s", resp.Status)
+	}
+	return c.ReadSnapshotFormat(resp.Body, format)
+}
@@ -0,0 +1,150 @@
+package snapshothttp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"github.com/dailz1/dailzLRU"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSeed(t *testing.T) {
+	warm, err := dailzLRU.New[string, int](8)
+	if err != nil {
+		t.Fatalf("LRU error: %v", err)
+	}
+	for i, k := range []string{"a", "b", "c"} {
+		warm.Add(k, i)
+	}
+	srv := httptest.NewServer(Handler(warm))
+	defer srv.Close()
+
+	for _, format := range []dailzLRU.SnapshotFormat{dailzLRU.SnapshotGob, dailzLRU.SnapshotJSON} {
+		cold, _ := dailzLRU.New[string, int](8)
+		n, err := Seed(context.Background(), srv.Client(), srv.URL, cold, format)
+		if err != nil || n != 3 {
+			t.Fatalf("Seed error: %v, n = %v", err, n)
+		}
+		if keys := cold.Keys(); len(keys) != 3 || keys[0] != "a" || keys[2] != "c" {
+			t.Fatalf("bad keys after seeding: %v", keys)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	warm, _ := dailzLRU.New[string, int](8)
+	warm.Add("a", 1)
+	handler := Handler(warm)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "text/html, application/json;q=0.9")
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Header().Get("Content-Type") != MediaTypeJSON || rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("bad export headers: %v", rec.Header())
+	}
+	body := rec.Body.Bytes()
+
+	// the compressed JSON export can be imported as is
+	cold, _ := dailzLRU.New[string, int](8)
+	req = httptest.NewRequest(http.MethodPut, "/", bytes.NewReader(body))
+	req.Header.Set("Content-Type", MediaTypeJSON)
+	req.Header.Set("Content-Encoding", "gzip")
+	rec = httptest.NewRecorder()
+	Handler(cold).ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !cold.Contains("a") {
+		t.Fatalf("bad import: %v %q", rec.Code, rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "text/html")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotAcceptable {
+		t.Fatalf("expected 406, got %v", rec.Code)
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte("garbage"))
+	gz.Close()
+	req = httptest.NewRequest(http.MethodPost, "/", &buf)
+	req.Header.Set("Content-Encoding", "gzip")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %v", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %v", rec.Code)
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	for accept, want := range map[string]string{
+		"application/json;q=0.5, application/x-gob;q=0.8": MediaTypeGob,
+		"text/html, application/json;q=0.001":             MediaTypeJSON,
+		"*/*;q=0.1, application/json":                     MediaTypeJSON,
+		"application/json, application/x-gob":             MediaTypeJSON,
+		"application/json;q=0, text/html":                 "",
+		"application/json;q=2":                            "",
+	} {
+		if got, _, ok := negotiate(accept); got != want || ok != (want != "") {
+			t.Fatalf("bad format for %q: %q, %v", accept, got, ok)
+		}
+	}
+	for acceptEncoding, want := range map[string]bool{
+		"gzip;q=0.5":     true,
+		"gzip;q=0.0":     false,
+		"*;q=0.1":        true,
+		"*, gzip;q=0":    false,
+		"br, identity":   false,
+		"GZIP ; q=1.000": true,
+		"gzip;q=invalid": false,
+	} {
+		if got := acceptsGzip(acceptEncoding); got != want {
+			t.Fatalf("bad gzip acceptance for %q: %v", acceptEncoding, got)
+		}
+	}
+}
+
+func TestHandlerWithLimit(t *testing.T) {
+	warm, _ := dailzLRU.New[string, string](8)
+	for _, k := range []string{"a", "b", "c", "d"} {
+		warm.Add(k, strings.Repeat(k, 1000))
+	}
+	var snapshot bytes.Buffer
+	warm.WriteSnapshotFormat(&snapshot, dailzLRU.SnapshotJSON)
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write(snapshot.Bytes())
+	gz.Close()
+	if compressed.Len() >= 1000 {
+		t.Fatalf("snapshot compressed poorly: %d bytes", compressed.Len())
+	}
+
+	cold, _ := dailzLRU.New[string, string](8)
+	handler := HandlerWithLimit(cold, 1000)
+	for _, encoding := range []string{"", "gzip"} {
+		body := snapshot.Bytes()
+		if encoding == "gzip" {
+			body = compressed.Bytes()
+		}
+		req := httptest.NewRequest(http.MethodPut, "/", bytes.NewReader(body))
+		req.Header.Set("Content-Type", MediaTypeJSON)
+		req.Header.Set("Content-Encoding", encoding)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusRequestEntityTooLarge {
+			t.Fatalf("expected 413 for encoding %q, got %v %q", encoding, rec.Code, rec.Body.String())
+		}
+	}
+}
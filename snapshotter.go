@@ -0,0 +1,166 @@
+package dailzLRU
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SnapshotWriter is implemented by caches that can write a snapshot of
+// themselves, such as *Cache.
+type SnapshotWriter interface {
+	WriteSnapshot(w io.Writer) error
+}
+
+// Snapshotter periodically writes snapshots of a cache in the background.
+type Snapshotter struct {
+	src      SnapshotWriter
+	create   func() (io.WriteCloser, error)
+	onError  func(err error)
+	trigger  chan struct{}
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+	logger   atomic.Pointer[slog.Logger]
+	lock     sync.Mutex // serializes snapshots
+}
+
+// NewSnapshotter starts writing a snapshot of src every interval. Each
+// snapshot goes to a new writer obtained from create, which is closed once
+// the snapshot is complete; AtomicFileTarget provides writers that replace a
+// file atomically. Errors of background snapshots are passed to onError if
+// it is not nil.
+func NewSnapshotter(src SnapshotWriter, interval time.Duration, create func() (io.WriteCloser, error), onError func(err error)) (*Snapshotter, error) {
+	if interval <= 0 {
+		return nil, errors.New("must provide a positive interval")
+	}
+	s := &Snapshotter{
+		src:     src,
+		create:  create,
+		onError: onError,
+		trigger: make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go s.run(interval)
+	return s, nil
+}
+
+// run writes a snapshot on every tick or trigger until Stop is called
+func (s *Snapshotter) run(interval time.Duration) {
+	defer close(s.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		case <-s.trigger:
+		}
+		if err := s.Snapshot(); err != nil && s.onError != nil {
+			s.onError(err)
+		}
+	}
+}
+
+// Snapshot writes a snapshot right away and returns once it is complete. A
+// writer implementing Abort() error is aborted instead of closed when the
+// snapshot fails.
+func (s *Snapshotter) Snapshot() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	start := time.Now()
+	err := s.snapshot()
+	if l := s.logger.Load(); l != nil {
+		if err != nil {
+			l.Error("snapshot failed", "error", err)
+		} else {
+			l.Debug("snapshot written", "duration", time.Since(start))
+		}
+	}
+	return err
+}
+
+// snapshot writes a snapshot to a new writer; the lock must be held
+func (s *Snapshotter) snapshot() error {
+	w, err := s.create()
+	if err != nil {
+		return err
+	}
+	if err := s.src.WriteSnapshot(w); err != nil {
+		if a, ok := w.(interface{ Abort() error }); ok {
+			a.Abort()
+		} else {
+			w.Close()
+		}
+		return err
+	}
+	return w.Close()
+}
+
+// Trigger asks for a snapshot in the background without waiting for the
+// next interval, for example when a signal is received. It does not block;
+// triggers arriving while a snapshot is pending are merged.
+func (s *Snapshotter) Trigger() {
+	select {
+	case s.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Stop stops the background snapshots and waits for a running one to
+// finish.
+func (s *Snapshotter) Stop() {
+	s.stopOnce.Do(func() { close(s.stop) })
+	<-s.done
+}
+
+// AtomicFile is a file that replaces the file at its path only once it is
+// closed, so readers never observe a partially written file.
+type AtomicFile struct {
+	*os.File
+	path string
+}
+
+// CreateAtomicFile creates a temporary file next to path that replaces path
+// when closed.
+func CreateAtomicFile(path string) (*AtomicFile, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return nil, err
+	}
+	return &AtomicFile{File: f, path: path}, nil
+}
+
+// Close syncs the temporary file and renames it over the target path.
+func (f *AtomicFile) Close() error {
+	err := f.File.Sync()
+	if cerr := f.File.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.File.Name())
+		return err
+	}
+	return os.Rename(f.File.Name(), f.path)
+}
+
+// Abort discards the temporary file, leaving the target path untouched.
+func (f *AtomicFile) Abort() error {
+	f.File.Close()
+	return os.Remove(f.File.Name())
+}
+
+// AtomicFileTarget returns a writer factory for NewSnapshotter that
+// atomically replaces the file at path with every snapshot.
+func AtomicFileTarget(path string) func() (io.WriteCloser, error) {
+	return func() (io.WriteCloser, error) {
+		return CreateAtomicFile(path)
+	}
+}
@@ -0,0 +1,69 @@
+package dailzLRU
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type failingSnapshot struct{}
+
+func (failingSnapshot) WriteSnapshot(w io.Writer) error {
+	w.Write([]byte("partial"))
+	return errors.New("boom")
+}
+
+func TestSnapshotter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot")
+	cache, err := New[int, int](8)
+	if err != nil {
+		t.Fatalf("LRU error: %v", err)
+	}
+	cache.Add(1, 1)
+
+	s, err := NewSnapshotter(cache, time.Hour, AtomicFileTarget(path), nil)
+	if err != nil {
+		t.Fatalf("NewSnapshotter error: %v", err)
+	}
+	if err := s.Snapshot(); err != nil {
+		t.Fatalf("Snapshot error: %v", err)
+	}
+	s.Stop()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("snapshot file missing: %v", err)
+	}
+	restored, _ := New[int, int](8)
+	if _, err := restored.ReadSnapshot(f); err != nil || !restored.Contains(1) {
+		t.Fatalf("bad snapshot file: %v", err)
+	}
+	f.Close()
+
+	// a failed snapshot leaves the previous file in place
+	errs := make(chan error, 1)
+	s, err = NewSnapshotter(failingSnapshot{}, time.Hour, AtomicFileTarget(path), func(err error) {
+		errs <- err
+	})
+	if err != nil {
+		t.Fatalf("NewSnapshotter error: %v", err)
+	}
+	s.Trigger()
+	select {
+	case <-errs:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("triggered snapshot did not run")
+	}
+	s.Stop()
+	f, _ = os.Open(path)
+	defer f.Close()
+	if _, err := restored.ReadSnapshot(f); err != nil {
+		t.Fatalf("previous snapshot should survive a failed one: %v", err)
+	}
+	if tmp, _ := filepath.Glob(filepath.Join(filepath.Dir(path), ".snapshot.tmp*")); len(tmp) != 0 {
+		t.Fatalf("temporary files left behind: %v", tmp)
+	}
+}
@@ -0,0 +1,300 @@
+// Package sqlcache caches the results of database/sql queries in memory,
+// with a time to live, eviction by size and invalidation by tag.
+package sqlcache
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/dailz1/dailzLRU"
+)
+
+// Querier runs queries; *sql.DB and *sql.Conn implement it. So does *sql.Tx,
+// but a Cache must not run queries through a transaction: results read
+// inside it, including its own uncommitted writes, would be served to every
+// caller after it ends.
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// Result is the result set of a query. Results are shared between callers
+// and must not be modified.
+type Result struct {
+	Columns []string
+	Rows    [][]any
+}
+
+// entry is a cached result
+type entry struct {
+	result  *Result
+	tags    []string
+	expires time.Time
+}
+
+// Cache runs queries through a Querier and caches their results. Results
+// are weighed by size and evicted with GreedyDual-Size-Frequency, so small
+// and often repeated results are kept over large and rarely repeated ones.
+//
+// Queries are tagged, usually with the tables they read, and Invalidate
+// drops the results of every query with a given tag, usually after a write
+// to that table. A query running while one of its tags is invalidated is not
+// cached, as it may have read the data from before the write.
+type Cache struct {
+	q      Querier
+	ttl    time.Duration
+	store  *dailzLRU.GDSFCache[string, *entry]
+	tags   map[string]map[string]struct{} // keys of the results of each tag
+	tagged map[string]*entry              // the tagged result of each key
+	gens   map[string]uint64              // invalidations of each tag
+	lock   sync.Mutex
+}
+
+// New constructs a Cache running queries through q and keeping their results
+// for ttl, with a total size of up to capacity bytes.
+func New(q Querier, capacity int64, ttl time.Duration) (*Cache, error) {
+	if ttl <= 0 {
+		return nil, errors.New("must provide a positive ttl")
+	}
+	c := &Cache{
+		q:      q,
+		ttl:    ttl,
+		tags:   make(map[string]map[string]struct{}),
+		tagged: make(map[string]*entry),
+		gens:   make(map[string]uint64),
+	}
+	store, err := dailzLRU.NewGDSF[string, *entry](capacity, c.onEvicted)
+	if err != nil {
+		return nil, err
+	}
+	c.store = store
+	return c, nil
+}
+
+// onEvicted drops the tags of a result leaving the store
+func (c *Cache) onEvicted(key string, e *entry) {
+	c.lock.Lock()
+	c.untag(key, e)
+	c.lock.Unlock()
+}
+
+// tag records the tags of e, the result stored under key, in place of those
+// of the result it replaced; the lock must be held
+func (c *Cache) tag(key string, e *entry) {
+	if old := c.tagged[key]; old != nil {
+		c.untag(key, old)
+	}
+	c.tagged[key] = e
+	for _, tag := range e.tags {
+		if c.tags[tag] == nil {
+			c.tags[tag] = make(map[string]struct{})
+		}
+		c.tags[tag][key] = struct{}{}
+	}
+}
+
+// untag removes key from the tags of e, unless a newer result for key was
+// tagged since; the lock must be held
+func (c *Cache) untag(key string, e *entry) {
+	if c.tagged[key] != e {
+		return
+	}
+	delete(c.tagged, key)
+	for _, tag := range e.tags {
+		if keys := c.tags[tag]; keys != nil {
+			delete(keys, key)
+			if len(keys) == 0 {
+				delete(c.tags, tag)
+			}
+		}
+	}
+}
+
+// Query returns the result of query with args, from the cache if it holds
+// an unexpired one and from the database otherwise. tags are the tags of the
+// query, which Invalidate drops its result for.
+func (c *Cache) Query(ctx context.Context, tags []string, query string, args ...any) (*Result, error) {
+	key := cacheKey(query, args)
+	if e, ok := c.store.Get(key); ok {
+		if time.Now().Before(e.expires) {
+			return e.result, nil
+		}
+		c.store.RemoveIf(key, func(cur *entry) bool { return cur == e })
+	}
+
+	gens := c.generations(tags)
+	result, err := c.run(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	e := &entry{result: result, tags: append([]string(nil), tags...), expires: time.Now().Add(c.ttl)}
+	if _, err := c.store.Add(key, e, size(key, result), 1); err != nil {
+		// too large to cache
+		return result, nil
+	}
+	// the result is tagged once stored, so that the removal of the result it
+	// replaced cannot untag it, and only while it is still stored, so that
+	// its own removal cannot have run first; a tag invalidated since the
+	// query started drops it instead
+	c.lock.Lock()
+	stale := c.invalidated(tags, gens)
+	if cur, ok := c.store.Peek(key); ok && cur == e && !stale {
+		c.tag(key, e)
+	}
+	c.lock.Unlock()
+	if stale {
+		// a newer result may have replaced e since
+		c.store.RemoveIf(key, func(cur *entry) bool { return cur == e })
+	}
+	return result, nil
+}
+
+// generations returns the invalidation counts of tags
+func (c *Cache) generations(tags []string) []uint64 {
+	gens := make([]uint64, len(tags))
+	c.lock.Lock()
+	for i, tag := range tags {
+		gens[i] = c.gens[tag]
+	}
+	c.lock.Unlock()
+	return gens
+}
+
+// invalidated reports whether any of tags was invalidated since gens were
+// taken; the lock must be held
+func (c *Cache) invalidated(tags []string, gens []uint64) bool {
+	for i, tag := range tags {
+		if c.gens[tag] != gens[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// run runs query and reads its whole result set
+func (c *Cache) run(ctx context.Context, query string, args []any) (*Result, error) {
+	rows, err := c.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{Columns: columns}
+	for rows.Next() {
+		row := make([]any, len(columns))
+		dest := make([]any, len(columns))
+		for i := range row {
+			dest[i] = &row[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Invalidate drops the cached results of the queries with any of the given
+// tags.
+func (c *Cache) Invalidate(tags ...string) {
+	entries := make(map[string]*entry)
+	c.lock.Lock()
+	for _, tag := range tags {
+		c.gens[tag]++
+		for key := range c.tags[tag] {
+			entries[key] = c.tagged[key]
+		}
+	}
+	c.lock.Unlock()
+	// results stored since, after the invalidation, are kept
+	for key, e := range entries {
+		c.store.RemoveIf(key, func(cur *entry) bool { return cur == e })
+	}
+}
+
+// Purge drops every cached result.
+func (c *Cache) Purge() {
+	c.store.Purge()
+}
+
+// Len returns the number of cached results.
+func (c *Cache) Len() int {
+	return c.store.Len()
+}
+
+// cacheKey returns the key of the result of query with args. The arguments
+// are hashed along with their types, so 1 and "1" make different keys, after
+// being converted as the driver sees them: pointers are dereferenced and
+// driver.Valuers replaced by their values.
+func cacheKey(query string, args []any) string {
+	h := sha256.New()
+	for _, arg := range args {
+		arg = argValue(arg)
+		fmt.Fprintf(h, "%T\x00%#v\x00", arg, arg)
+	}
+	return query + "\x00" + hex.EncodeToString(h.Sum(nil))
+}
+
+var valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+
+// argValue returns the value passed to the driver for arg; a nil pointer is
+// nil, and arg itself is returned if its Value method fails, since the query
+// will fail too
+func argValue(arg any) any {
+	for {
+		rv := reflect.ValueOf(arg)
+		isPtr := rv.Kind() == reflect.Pointer
+		if vr, ok := arg.(driver.Valuer); ok {
+			if isPtr && rv.IsNil() && rv.Type().Elem().Implements(valuerType) {
+				// the method has a value receiver, as database/sql checks
+				return nil
+			}
+			v, err := vr.Value()
+			if err != nil {
+				return arg
+			}
+			return v
+		}
+		if !isPtr {
+			return arg
+		}
+		if rv.IsNil() {
+			return nil
+		}
+		arg = rv.Elem().Interface()
+	}
+}
+
+// size returns the approximate number of bytes result weighs under key
+func size(key string, result *Result) int64 {
+	n := int64(len(key))
+	for _, column := range result.Columns {
+		n += int64(len(column))
+	}
+	for _, row := range result.Rows {
+		for _, v := range row {
+			switch v := v.(type) {
+			case []byte:
+				n += int64(len(v))
+			case string:
+				n += int64(len(v))
+			default:
+				n += 8
+			}
+		}
+	}
+	return n
+}
@@ -0,0 +1,186 @@
+package sqlcache
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// fakeDB is a database answering every query with one row holding the
+// number of queries run so far and the first argument
+type fakeDB struct {
+	queries int
+	during  func() // called while a query runs
+}
+
+func (db *fakeDB) Connect(context.Context) (driver.Conn, error) { return fakeConn{db}, nil }
+func (db *fakeDB) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{c.db}, nil }
+func (c fakeConn) Close() error                              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+type fakeStmt struct{ db *fakeDB }
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.db.queries++
+	if s.db.during != nil {
+		s.db.during()
+	}
+	var arg driver.Value
+	if len(args) > 0 {
+		arg = args[0]
+	}
+	return &fakeRows{row: []driver.Value{int64(s.db.queries), arg}}, nil
+}
+
+type fakeRows struct {
+	row  []driver.Value
+	done bool
+}
+
+func (r *fakeRows) Columns() []string { return []string{"n", "arg"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	copy(dest, r.row)
+	return nil
+}
+
+func TestCache(t *testing.T) {
+	db := &fakeDB{}
+	c, err := New(sql.OpenDB(db), 1<<20, time.Minute)
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	ctx := context.Background()
+	query := func(tags []string, args ...any) int64 {
+		t.Helper()
+		r, err := c.Query(ctx, tags, "SELECT n FROM t WHERE id = ?", args...)
+		if err != nil {
+			t.Fatalf("Query error: %v", err)
+		}
+		if len(r.Columns) != 2 || len(r.Rows) != 1 {
+			t.Fatalf("bad result: %+v", r)
+		}
+		return r.Rows[0][0].(int64)
+	}
+
+	if query([]string{"t"}, 1) != 1 || query([]string{"t"}, 1) != 1 {
+		t.Fatalf("result not cached: %d queries", db.queries)
+	}
+	if query([]string{"t"}, 2) != 2 || query([]string{"t"}, "1") != 3 {
+		t.Fatalf("arguments not part of the key: %d queries", db.queries)
+	}
+	if query([]string{"u"}, 3) != 4 || c.Len() != 4 {
+		t.Fatalf("bad len: %v", c.Len())
+	}
+
+	c.Invalidate("t")
+	if c.Len() != 1 || query([]string{"t"}, 1) != 5 || query([]string{"u"}, 3) != 4 {
+		t.Fatalf("bad invalidation: %v cached, %d queries", c.Len(), db.queries)
+	}
+
+	// a query invalidated while running is not cached
+	db.during = func() { c.Invalidate("t") }
+	query([]string{"t"}, 9)
+	db.during = nil
+	if query([]string{"t"}, 9) != 7 {
+		t.Fatalf("result of an invalidated query cached")
+	}
+
+	c.Purge()
+	if c.Len() != 0 || len(c.tags) != 0 {
+		t.Fatalf("bad purge: %v cached, tags %v", c.Len(), c.tags)
+	}
+}
+
+func TestCache_Limits(t *testing.T) {
+	db := &fakeDB{}
+	c, err := New(sql.OpenDB(db), 16, time.Nanosecond)
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	ctx := context.Background()
+	// the key alone is larger than the cache
+	c.Query(ctx, []string{"t"}, "SELECT 1")
+	if c.Len() != 0 || len(c.tags) != 0 {
+		t.Fatalf("oversized result cached: %v, tags %v", c.Len(), c.tags)
+	}
+
+	c, err = New(sql.OpenDB(db), 1<<20, time.Nanosecond)
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	c.Query(ctx, nil, "SELECT 1")
+	time.Sleep(time.Millisecond)
+	if r, _ := c.Query(ctx, nil, "SELECT 1"); r.Rows[0][0].(int64) != 3 {
+		t.Fatalf("expired result served: %v", r.Rows)
+	}
+
+	if _, err := New(sql.OpenDB(db), 1<<20, 0); err == nil {
+		t.Fatalf("should reject a zero ttl")
+	}
+}
+
+func TestCache_Replaced(t *testing.T) {
+	db := &fakeDB{}
+	c, err := New(sql.OpenDB(db), 1<<20, time.Nanosecond)
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	ctx := context.Background()
+	key := cacheKey("SELECT 1", nil)
+	c.Query(ctx, []string{"t"}, "SELECT 1")
+	old, _ := c.store.Peek(key)
+	time.Sleep(time.Millisecond)
+	c.Query(ctx, []string{"t"}, "SELECT 1")
+
+	// the removal of the replaced result, however late, keeps the tags of
+	// the fresh one
+	c.onEvicted(key, old)
+	c.Invalidate("t")
+	if c.Len() != 0 || len(c.tags) != 0 || len(c.tagged) != 0 {
+		t.Fatalf("fresh result untagged: %v cached, tags %v", c.Len(), c.tags)
+	}
+}
+
+// valuer is a driver.Valuer holding its value behind a pointer
+type valuer struct{ n *int64 }
+
+func (v valuer) Value() (driver.Value, error) { return *v.n, nil }
+
+func TestCacheKey(t *testing.T) {
+	one, one2, two := int64(1), int64(1), int64(2)
+	key := cacheKey("q", []any{one})
+	if cacheKey("q", []any{&one}) != key || cacheKey("q", []any{&one2}) != key {
+		t.Fatalf("pointers hashed by address")
+	}
+	if cacheKey("q", []any{valuer{&one}}) != key || cacheKey("q", []any{&valuer{&one2}}) != key {
+		t.Fatalf("valuers hashed by address")
+	}
+	if cacheKey("q", []any{&two}) == key || cacheKey("q", []any{valuer{&two}}) == key {
+		t.Fatalf("different values make the same key")
+	}
+	var nilPtr *int64
+	if cacheKey("q", []any{nilPtr}) != cacheKey("q", []any{nil}) {
+		t.Fatalf("nil pointer not hashed as nil")
+	}
+}
@@ -0,0 +1,72 @@
+package dailzLRU
+
+// SyncMap exposes a Cache through the method names and semantics of
+// sync.Map, so code written against sync.Map can switch to a bounded cache
+// by changing the variable's type. Unlike sync.Map, entries may disappear
+// when the cache is full.
+type SyncMap[K comparable, V any] struct {
+	c *Cache[K, V]
+}
+
+// NewSyncMap constructs a SyncMap holding up to size entries.
+func NewSyncMap[K comparable, V any](size int) (*SyncMap[K, V], error) {
+	c, err := New[K, V](size)
+	if err != nil {
+		return nil, err
+	}
+	return &SyncMap[K, V]{c: c}, nil
+}
+
+// NewSyncMapFromCache constructs a SyncMap backed by c.
+func NewSyncMapFromCache[K comparable, V any](c *Cache[K, V]) *SyncMap[K, V] {
+	return &SyncMap[K, V]{c: c}
+}
+
+// Cache returns the cache backing m.
+func (m *SyncMap[K, V]) Cache() *Cache[K, V] {
+	return m.c
+}
+
+// Load returns the value stored for key, if any, marking it as recently
+// used.
+func (m *SyncMap[K, V]) Load(key K) (value V, ok bool) {
+	return m.c.Get(key)
+}
+
+// Store sets the value for key.
+func (m *SyncMap[K, V]) Store(key K, value V) {
+	m.c.Add(key, value)
+}
+
+// LoadOrStore returns the existing value for key if present. Otherwise it
+// stores and returns the given value. loaded is true if the value was
+// loaded, false if stored.
+func (m *SyncMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	if previous, ok, _ := m.c.PeekOrAdd(key, value); ok {
+		return previous, true
+	}
+	return value, false
+}
+
+// LoadAndDelete deletes the value for key, returning the previous value if
+// any. loaded reports whether the key was present.
+func (m *SyncMap[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
+	return m.c.Pop(key)
+}
+
+// Delete deletes the value for key.
+func (m *SyncMap[K, V]) Delete(key K) {
+	m.c.Remove(key)
+}
+
+// Range calls f for each key and value, from oldest to newest, stopping if f
+// returns false. As with sync.Map, Range does not correspond to a consistent
+// snapshot: entries added or removed during the iteration may or may not be
+// visited. f may call any method of m.
+func (m *SyncMap[K, V]) Range(f func(key K, value V) bool) {
+	for _, key := range m.c.Keys() {
+		if value, ok := m.c.Peek(key); ok && !f(key, value) {
+			return
+		}
+	}
+}
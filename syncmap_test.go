@@ -0,0 +1,38 @@
+package dailzLRU
+
+import "testing"
+
+func TestSyncMap(t *testing.T) {
+	m, err := NewSyncMap[string, int](2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	m.Store("a", 1)
+	if v, loaded := m.LoadOrStore("a", 2); !loaded || v != 1 {
+		t.Fatalf("bad load: %v, %v", v, loaded)
+	}
+	if v, loaded := m.LoadOrStore("b", 2); loaded || v != 2 {
+		t.Fatalf("bad store: %v, %v", v, loaded)
+	}
+	if v, ok := m.Load("b"); !ok || v != 2 {
+		t.Fatalf("bad value: %v, %v", v, ok)
+	}
+
+	var keys []string
+	m.Range(func(k string, v int) bool {
+		keys = append(keys, k)
+		m.Delete(k)
+		return true
+	})
+	if len(keys) != 2 || m.Cache().Len() != 0 {
+		t.Fatalf("bad range: %v, %v", keys, m.Cache().Len())
+	}
+
+	m.Store("c", 3)
+	if v, loaded := m.LoadAndDelete("c"); !loaded || v != 3 {
+		t.Fatalf("bad delete: %v, %v", v, loaded)
+	}
+	if _, ok := m.Load("c"); ok {
+		t.Fatalf("c should be gone")
+	}
+}
@@ -0,0 +1,140 @@
+package dailzLRU
+
+import (
+	"github.com/dailz1/dailzLRU/lru"
+	"sync"
+)
+
+// Taker is implemented by caches that can hand an entry over without
+// invoking their eviction callback for it, as *Cache does.
+type Taker[K comparable, V any] interface {
+	Take(key K) (value V, ok bool)
+}
+
+// Tiered is a thread-safe two-level cache. A small LRU sits in front of a
+// larger second level: hits in the second level are promoted into the first
+// one, and entries evicted from the first level are demoted into the second
+// one. A key lives in at most one level at a time. Entries moving out of a
+// second level implementing Taker do not invoke its eviction callback;
+// with other second levels they are removed, which may.
+type Tiered[K comparable, V any] struct {
+	l1       *lru.LRU[K, V]
+	l2       Interface[K, V]
+	dropping bool // set while entries leave l1 without being demoted
+	evicted  bool // set when a demotion evicted from l2
+	lock     sync.Mutex
+}
+
+// NewTiered constructs a Tiered cache with a first level of the given size in
+// front of l2. The second level must not be used directly while it is part
+// of a Tiered cache.
+func NewTiered[K comparable, V any](l1Size int, l2 Interface[K, V]) (*Tiered[K, V], error) {
+	t := &Tiered[K, V]{l2: l2}
+	l1, err := lru.NewLRU[K, V](l1Size, t.demote)
+	if err != nil {
+		return nil, err
+	}
+	t.l1 = l1
+	return t, nil
+}
+
+// demote moves an entry evicted from the first level into the second one
+func (t *Tiered[K, V]) demote(key K, value V) {
+	if t.dropping {
+		return
+	}
+	t.evicted = t.l2.Add(key, value)
+}
+
+// Get looks up a key's value, promoting it into the first level if it was
+// found in the second one.
+func (t *Tiered[K, V]) Get(key K) (value V, ok bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if value, ok = t.l1.Get(key); ok {
+		return value, ok
+	}
+	if value, ok = t.take(key); ok {
+		t.l1.Add(key, value)
+	}
+	return value, ok
+}
+
+// take moves key out of the second level
+func (t *Tiered[K, V]) take(key K) (value V, ok bool) {
+	if tk, isTaker := t.l2.(Taker[K, V]); isTaker {
+		return tk.Take(key)
+	}
+	if value, ok = t.l2.Get(key); ok {
+		t.l2.Remove(key)
+	}
+	return value, ok
+}
+
+// Peek returns the key value (or undefined if not found) from either level
+// without promoting it or updating its recent-ness.
+func (t *Tiered[K, V]) Peek(key K) (value V, ok bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if value, ok = t.l1.Peek(key); ok {
+		return value, ok
+	}
+	return t.l2.Peek(key)
+}
+
+// Contains checks if a key is in either level.
+func (t *Tiered[K, V]) Contains(key K) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.l1.Contains(key) || t.l2.Contains(key)
+}
+
+// Add adds a value to the first level. Returns true if an entry was evicted
+// from the second level as a result.
+func (t *Tiered[K, V]) Add(key K, value V) (evicted bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.take(key)
+	t.evicted = false
+	t.l1.Add(key, value)
+	return t.evicted
+}
+
+// Remove removes the provided key from whichever level holds it, returning
+// true if the key was contained.
+func (t *Tiered[K, V]) Remove(key K) (present bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.dropping = true
+	present = t.l1.Remove(key)
+	t.dropping = false
+	if present {
+		return true
+	}
+	return t.l2.Remove(key)
+}
+
+// Keys returns the keys of the first level followed by those of the second
+// level, each from oldest to newest.
+func (t *Tiered[K, V]) Keys() []K {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return append(t.l1.Keys(), t.l2.Keys()...)
+}
+
+// Len returns the number of items in both levels.
+func (t *Tiered[K, V]) Len() int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.l1.Len() + t.l2.Len()
+}
+
+// Purge is used to completely clear both levels.
+func (t *Tiered[K, V]) Purge() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.dropping = true
+	t.l1.Purge()
+	t.dropping = false
+	t.l2.Purge()
+}
@@ -0,0 +1,76 @@
+package dailzLRU
+
+import "testing"
+
+func TestTiered(t *testing.T) {
+	l2, err := New2Q[int, int](4)
+	if err != nil {
+		t.Fatalf("2Q error: %v", err)
+	}
+	cache, err := NewTiered[int, int](2, l2)
+	if err != nil {
+		t.Fatalf("Tiered error: %v", err)
+	}
+
+	for i := 0; i < 4; i++ {
+		if cache.Add(i, i) {
+			t.Fatalf("Tiered error: unexpected eviction adding %v", i)
+		}
+	}
+	// 0 and 1 were demoted
+	if cache.Len() != 4 || !l2.Contains(0) || !l2.Contains(1) || l2.Contains(3) {
+		t.Fatalf("Tiered error: bad demotion, l2 keys = %v", l2.Keys())
+	}
+
+	// a second level hit is promoted, demoting the first level's oldest entry
+	if v, ok := cache.Get(0); !ok || v != 0 {
+		t.Fatalf("Tiered error: missing key 0")
+	}
+	if l2.Contains(0) || !l2.Contains(2) {
+		t.Fatalf("Tiered error: bad promotion, l2 keys = %v", l2.Keys())
+	}
+
+	// removed entries are dropped, not demoted
+	if !cache.Remove(3) || cache.Contains(3) || l2.Contains(3) {
+		t.Fatalf("Tiered error: bad remove")
+	}
+	if !cache.Remove(1) || cache.Contains(1) {
+		t.Fatalf("Tiered error: bad remove from second level")
+	}
+
+	cache.Purge()
+	if cache.Len() != 0 || l2.Len() != 0 {
+		t.Fatalf("Tiered error: bad len after purge: %v", cache.Len())
+	}
+}
+
+func TestTiered_TakerCallback(t *testing.T) {
+	var evicted []int
+	l2, err := NewWithEvict[int, int](4, func(k, v int) {
+		evicted = append(evicted, k)
+	})
+	if err != nil {
+		t.Fatalf("LRU error: %v", err)
+	}
+	cache, err := NewTiered[int, int](1, l2)
+	if err != nil {
+		t.Fatalf("Tiered error: %v", err)
+	}
+	cache.Add(0, 0)
+	cache.Add(1, 1) // demotes 0
+
+	// promotions and overwrites move entries without reporting them
+	if v, ok := cache.Get(0); !ok || v != 0 || l2.Contains(0) {
+		t.Fatalf("Tiered error: bad promotion, l2 keys = %v", l2.Keys())
+	}
+	cache.Add(1, 10)
+	if len(evicted) != 0 {
+		t.Fatalf("Tiered error: second level callback fired: %v", evicted)
+	}
+
+	// removals still are reported
+	cache.Add(2, 2)
+	if !cache.Remove(0) || len(evicted) != 1 || evicted[0] != 0 {
+		t.Fatalf("Tiered error: bad remove: %v", evicted)
+	}
+}
@@ -0,0 +1,238 @@
+package dailzLRU
+
+import (
+	"log/slog"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ttlEntry is a value of a TTLMap along with its expiry bookkeeping
+type ttlEntry[V any] struct {
+	value     V
+	expiresAt time.Time
+	slot      int64  // expiry slot, as returned by expiryBuckets.add
+	seq       uint64 // order in which the key was first set
+}
+
+// TTLMap is a thread-safe map whose entries expire a fixed time after they
+// were last set. It has no size limit and never evicts an entry for space,
+// and lookups do not reorder entries. Expired entries are reclaimed by a
+// background sweeper using the same expiry buckets as ExpirableCache.
+type TTLMap[K comparable, V any] struct {
+	entries     map[K]ttlEntry[V]
+	ttl         time.Duration
+	expiry      *expiryBuckets[K]
+	seq         uint64
+	evictedKeys []K
+	evictedVals []V
+	onEvictedCB func(k K, v V)
+	expiredKeys []K
+	expiredVals []V
+	onExpireCB  func(k K, v V)
+	lenExpired  bool // Len counts expired entries
+	logger      atomic.Pointer[slog.Logger]
+	stop        chan struct{}
+	done        chan struct{}
+	stopOnce    sync.Once
+	lock        sync.Mutex
+}
+
+// NewTTLMap constructs a TTLMap whose entries expire ttl after they were
+// set. onEvicted, if not nil, is called outside of the map's lock for
+// entries that are removed or, unless OnExpire sets a callback of their
+// own, expire. Close must be called to stop the
+// background sweeper.
+func NewTTLMap[K comparable, V any](ttl time.Duration, onEvicted func(key K, value V)) (*TTLMap[K, V], error) {
+	expiry, err := newExpiryBuckets[K](ttl, DefaultExpirableBuckets)
+	if err != nil {
+		return nil, err
+	}
+	m := &TTLMap[K, V]{
+		entries:     make(map[K]ttlEntry[V]),
+		ttl:         ttl,
+		expiry:      expiry,
+		onEvictedCB: onEvicted,
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go runSweeper(expiry.width, m.stop, m.done, func(now time.Time) { m.sweep(now) })
+	return m, nil
+}
+
+// OnExpire sets a callback invoked outside of the map's lock for entries
+// removed because they expired, instead of the eviction callback, as
+// ExpirableCache.OnExpire does.
+func (m *TTLMap[K, V]) OnExpire(fn func(key K, value V)) {
+	m.lock.Lock()
+	m.onExpireCB = fn
+	m.lock.Unlock()
+}
+
+// remove deletes key and buffers the eviction callback, or the expiry
+// callback if the entry expired
+func (m *TTLMap[K, V]) remove(key K, e ttlEntry[V], expired bool) {
+	delete(m.entries, key)
+	m.expiry.remove(key, e.slot)
+	if expired && m.onExpireCB != nil {
+		m.expiredKeys = append(m.expiredKeys, key)
+		m.expiredVals = append(m.expiredVals, e.value)
+	} else if m.onEvictedCB != nil {
+		m.evictedKeys = append(m.evictedKeys, key)
+		m.evictedVals = append(m.evictedVals, e.value)
+	}
+}
+
+// takeEvicted hands back the buffered evictions and resets the buffers
+func (m *TTLMap[K, V]) takeEvicted() (ks []K, vs []V) {
+	ks, vs = m.evictedKeys, m.evictedVals
+	m.evictedKeys, m.evictedVals = nil, nil
+	return ks, vs
+}
+
+// notify invokes the eviction callback for buffered evictions
+func (m *TTLMap[K, V]) notify(ks []K, vs []V) {
+	for i := 0; i < len(ks); i++ {
+		m.onEvictedCB(ks[i], vs[i])
+	}
+}
+
+// sweep removes the entries expired by now, returning how many
+func (m *TTLMap[K, V]) sweep(now time.Time) (n int) {
+	m.lock.Lock()
+	m.expiry.sweep(now, func(k K) {
+		if e := m.entries[k]; !now.Before(e.expiresAt) {
+			m.remove(k, e, true)
+			n++
+		}
+	})
+	ks, vs := m.takeEvicted()
+	xks, xvs := m.expiredKeys, m.expiredVals
+	m.expiredKeys, m.expiredVals = nil, nil
+	onExpire := m.onExpireCB
+	m.lock.Unlock()
+	if l := m.logger.Load(); l != nil && n > 0 {
+		l.Debug("expired entries swept", "removed", n)
+	}
+	m.notify(ks, vs)
+	for i := 0; i < len(xks); i++ {
+		onExpire(xks[i], xvs[i])
+	}
+	return n
+}
+
+// EvictExpired removes every expired entry right away, invoking the expiry
+// callback set by OnExpire for each, or the eviction callback if there is
+// none, and returns how many were removed.
+func (m *TTLMap[K, V]) EvictExpired() int {
+	return m.sweep(time.Now())
+}
+
+// Set sets the value for key, resetting its expiry.
+func (m *TTLMap[K, V]) Set(key K, value V) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	e, ok := m.entries[key]
+	if ok {
+		m.expiry.remove(key, e.slot)
+	} else {
+		m.seq++
+		e.seq = m.seq
+	}
+	e.value = value
+	e.expiresAt = time.Now().Add(m.ttl)
+	e.slot = m.expiry.add(key, e.expiresAt)
+	m.entries[key] = e
+}
+
+// Get returns the value for key, if present and not expired.
+func (m *TTLMap[K, V]) Get(key K) (value V, ok bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if e, ok := m.entries[key]; ok && time.Now().Before(e.expiresAt) {
+		return e.value, true
+	}
+	return value, false
+}
+
+// Contains checks if key is present and not expired.
+func (m *TTLMap[K, V]) Contains(key K) bool {
+	_, ok := m.Get(key)
+	return ok
+}
+
+// Delete removes key, returning true if it was present.
+func (m *TTLMap[K, V]) Delete(key K) (present bool) {
+	m.lock.Lock()
+	e, present := m.entries[key]
+	if present {
+		m.remove(key, e, false)
+	}
+	ks, vs := m.takeEvicted()
+	m.lock.Unlock()
+	m.notify(ks, vs)
+	return present
+}
+
+// Keys returns the unexpired keys in the order they were first set.
+func (m *TTLMap[K, V]) Keys() []K {
+	m.lock.Lock()
+	now := time.Now()
+	keys := make([]K, 0, len(m.entries))
+	seqs := make(map[K]uint64, len(m.entries))
+	for k, e := range m.entries {
+		if now.Before(e.expiresAt) {
+			keys = append(keys, k)
+			seqs[k] = e.seq
+		}
+	}
+	m.lock.Unlock()
+	sort.Slice(keys, func(i, j int) bool { return seqs[keys[i]] < seqs[keys[j]] })
+	return keys
+}
+
+// Len returns the number of unexpired entries, or of all entries if
+// LenCountsExpired(true) was called.
+func (m *TTLMap[K, V]) Len() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.lenExpired {
+		return len(m.entries)
+	}
+	return len(m.entries) - m.expiry.expiredLen(time.Now())
+}
+
+// LenCountsExpired sets whether Len counts the expired entries that have
+// not been swept yet, as ExpirableCache.LenCountsExpired does.
+func (m *TTLMap[K, V]) LenCountsExpired(count bool) {
+	m.lock.Lock()
+	m.lenExpired = count
+	m.lock.Unlock()
+}
+
+// ExpiredLen returns the number of expired entries that have not been
+// swept yet, in constant time, as ExpirableCache.ExpiredLen does.
+func (m *TTLMap[K, V]) ExpiredLen() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.expiry.expiredLen(time.Now())
+}
+
+// Purge removes every entry.
+func (m *TTLMap[K, V]) Purge() {
+	m.lock.Lock()
+	for k, e := range m.entries {
+		m.remove(k, e, false)
+	}
+	ks, vs := m.takeEvicted()
+	m.lock.Unlock()
+	m.notify(ks, vs)
+}
+
+// Close stops the background sweeper. The map keeps working afterwards,
+// but expired entries are no longer reclaimed.
+func (m *TTLMap[K, V]) Close() {
+	m.stopOnce.Do(func() { close(m.stop) })
+	<-m.done
+}
@@ -0,0 +1,93 @@
+package dailzLRU
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTTLMap(t *testing.T) {
+	m, err := NewTTLMap[int, int](30*time.Millisecond, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer m.Close()
+
+	// no entry is evicted for space
+	for i := 0; i < 1000; i++ {
+		m.Set(i, i)
+	}
+	if m.Len() != 1000 {
+		t.Fatalf("bad len: %v", m.Len())
+	}
+	if v, ok := m.Get(500); !ok || v != 500 {
+		t.Fatalf("bad value: %v, %v", v, ok)
+	}
+	if !m.Delete(500) || m.Contains(500) {
+		t.Fatalf("bad delete")
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	if m.Contains(1) || len(m.Keys()) != 0 {
+		t.Fatalf("entries should have expired")
+	}
+	if m.Len() != 0 {
+		t.Fatalf("expired entries counted: %v", m.Len())
+	}
+	m.LenCountsExpired(true)
+	if n := m.Len(); n < m.ExpiredLen() {
+		t.Fatalf("expired entries not counted: %v", n)
+	}
+	deadline := time.Now().Add(time.Second)
+	for m.ExpiredLen() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expired entries were not swept: %v", m.ExpiredLen())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestTTLMap_Keys(t *testing.T) {
+	var evicted []int
+	m, err := NewTTLMap[int, int](time.Minute, func(k, v int) { evicted = append(evicted, k) })
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer m.Close()
+
+	for _, k := range []int{3, 1, 2, 1} {
+		m.Set(k, k)
+	}
+	if keys := m.Keys(); len(keys) != 3 || keys[0] != 3 || keys[1] != 1 || keys[2] != 2 {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	m.Purge()
+	if m.Len() != 0 || len(evicted) != 3 {
+		t.Fatalf("bad purge: %v, %v", m.Len(), evicted)
+	}
+}
+
+func TestTTLMap_OnExpire(t *testing.T) {
+	var removed, expired []int
+	m, err := NewTTLMap[int, int](20*time.Millisecond, func(k, v int) { removed = append(removed, k) })
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	m.Close()
+	m.OnExpire(func(k, v int) { expired = append(expired, k) })
+
+	m.Set(1, 1)
+	m.Set(2, 2)
+	m.Delete(1)
+	time.Sleep(30 * time.Millisecond)
+	if n := m.EvictExpired(); n != 1 || len(removed) != 1 || removed[0] != 1 || len(expired) != 1 || expired[0] != 2 {
+		t.Fatalf("bad callbacks: %v, %v, %v", n, removed, expired)
+	}
+
+	// without an expiry callback, the eviction callback is called
+	m.OnExpire(nil)
+	m.Set(3, 3)
+	time.Sleep(30 * time.Millisecond)
+	if n := m.EvictExpired(); n != 1 || len(removed) != 2 || removed[1] != 3 || len(expired) != 1 {
+		t.Fatalf("bad callbacks: %v, %v, %v", n, removed, expired)
+	}
+}
@@ -0,0 +1,89 @@
+package dailzLRU
+
+import "github.com/dailz1/dailzLRU/lru"
+
+// Txn is a view of the cache handed to the function passed to Cache.Do.
+// Every method runs under the cache's lock, so a sequence of calls is atomic
+// with respect to other users of the cache. A Txn must not be retained or
+// used after the function returns.
+type Txn[K comparable, V any] interface {
+	// Get looks up a key's value and updates its recent-ness.
+	Get(key K) (value V, ok bool)
+	// Peek looks up a key's value without updating its recent-ness.
+	Peek(key K) (value V, ok bool)
+	// Contains checks if a key is in the cache.
+	Contains(key K) bool
+	// Add adds a value to the cache. Returns true if an eviction occurred.
+	Add(key K, value V) (evicted bool)
+	// Remove removes the provided key, returning true if it was contained.
+	Remove(key K) (present bool)
+}
+
+// txn implements Txn on top of the cache's underlying LRU
+type txn[K comparable, V any] struct {
+	c   *Cache[K, V]
+	lru *lru.LRU[K, V]
+}
+
+func (t *txn[K, V]) Get(key K) (value V, ok bool) {
+	value, ok = t.lru.Get(key)
+	if ok && t.c.ages != nil {
+		t.c.ages.access(key)
+	}
+	if ok && t.c.views != nil && t.lru.Promotes() {
+		t.c.views.touch(key, value)
+	}
+	if ok && t.c.uses != nil {
+		t.c.use(key)
+	}
+	return value, ok
+}
+
+func (t *txn[K, V]) Peek(key K) (value V, ok bool) {
+	return t.lru.Peek(key)
+}
+
+func (t *txn[K, V]) Contains(key K) bool {
+	return t.lru.Contains(key)
+}
+
+func (t *txn[K, V]) Add(key K, value V) (evicted bool) {
+	t.c.beforeAdd(key, value)
+	return t.lru.Add(key, value)
+}
+
+func (t *txn[K, V]) Remove(key K) (present bool) {
+	return t.lru.Remove(key)
+}
+
+// Do runs fn with exclusive access to the cache and returns its error.
+// Changes made through the Txn are not rolled back when fn returns an error.
+// Eviction callbacks triggered inside fn are invoked after the lock is
+// released, in the order the evictions happened. Do fails with ErrFrozen
+// without calling fn while the cache is frozen.
+func (c *Cache[K, V]) Do(fn func(tx Txn[K, V]) error) error {
+	ks, vs, err := c.do(fn)
+	for i := 0; i < len(ks); i++ {
+		c.notifyEvicted(ks[i], vs[i])
+	}
+	return err
+}
+
+// do runs fn under the lock and hands back the evictions it caused, leaving
+// the eviction buffers empty even if fn panics
+func (c *Cache[K, V]) do(fn func(tx Txn[K, V]) error) (ks []K, vs []V, err error) {
+	c.lock.Lock()
+	if c.frozen {
+		c.lock.Unlock()
+		return nil, nil, ErrFrozen
+	}
+	defer func() {
+		if c.onEvictedCB != nil && len(c.evictedKeys) > 0 {
+			ks = c.evictedKeys
+			vs = c.evictedVals
+			c.initEvictBuffers()
+		}
+		c.lock.Unlock()
+	}()
+	return nil, nil, fn(&txn[K, V]{c: c, lru: c.lru})
+}
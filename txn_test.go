@@ -0,0 +1,57 @@
+package dailzLRU
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCache_Do(t *testing.T) {
+	var evicted []int
+	cache, err := NewWithEvict(2, func(k int, v int) {
+		evicted = append(evicted, k)
+	})
+	if err != nil {
+		t.Fatalf("LRU error: %v", err)
+	}
+	cache.Add(1, 1)
+	cache.Add(2, 2)
+
+	// remove 1 then insert 3 only if 2 exists
+	err = cache.Do(func(tx Txn[int, int]) error {
+		if !tx.Contains(2) {
+			return errors.New("missing dependency")
+		}
+		tx.Remove(1)
+		tx.Add(3, 3)
+		tx.Add(4, 4)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Do error: %v", err)
+	}
+	if len(evicted) != 2 || evicted[0] != 1 || evicted[1] != 2 {
+		t.Fatalf("bad evicted keys: %v", evicted)
+	}
+	if cache.Contains(1) || cache.Contains(2) || !cache.Contains(3) || !cache.Contains(4) {
+		t.Fatalf("bad keys after Do: %v", cache.Keys())
+	}
+
+	sentinel := errors.New("abort")
+	if err := cache.Do(func(tx Txn[int, int]) error { return sentinel }); err != sentinel {
+		t.Fatalf("Do should return fn's error, got %v", err)
+	}
+
+	// a panic inside fn must not leave stale evictions behind
+	func() {
+		defer func() { _ = recover() }()
+		_ = cache.Do(func(tx Txn[int, int]) error {
+			tx.Add(5, 5)
+			panic("boom")
+		})
+	}()
+	evicted = evicted[:0]
+	cache.Add(6, 6)
+	if len(evicted) != 1 || evicted[0] != 4 {
+		t.Fatalf("bad evicted keys after panic: %v", evicted)
+	}
+}
@@ -0,0 +1,59 @@
+package dailzLRU
+
+import "errors"
+
+// AddWithMaxUses adds a value that is removed from the cache once Get has
+// returned it n times, invoking the eviction callback, for values such as
+// one-time tokens that must not be served indefinitely. Peek and Contains
+// do not count as uses. The limit replaces any set by an earlier
+// AddWithMaxUses for key; a plain Add keeps the remaining uses. Tracking
+// uses makes Get on a FIFO cache take the write lock. While the cache is
+// frozen, a value that runs out of uses is not removed but Get stops
+// returning it, and it is removed on Thaw. Returns true if an eviction
+// occurred.
+func (c *Cache[K, V]) AddWithMaxUses(key K, value V, n int) (evicted bool, err error) {
+	if n <= 0 {
+		return false, errors.New("must provide a positive number of uses")
+	}
+	err = c.Do(func(tx Txn[K, V]) error {
+		evicted = tx.Add(key, value)
+		if c.uses == nil {
+			c.uses = make(map[K]int)
+		}
+		c.uses[key] = n
+		return nil
+	})
+	return evicted, err
+}
+
+// use counts a use of key, which must be in the cache, removing it if it
+// has none left, or leaving it exhausted if the cache is frozen; the lock
+// must be held
+func (c *Cache[K, V]) use(key K) (removed bool) {
+	n, ok := c.uses[key]
+	if !ok {
+		return false
+	}
+	if n > 1 || c.frozen {
+		c.uses[key] = n - 1
+		return false
+	}
+	return c.lru.Remove(key)
+}
+
+// exhausted reports whether key ran out of uses while the cache was
+// frozen; the lock must be held
+func (c *Cache[K, V]) exhausted(key K) bool {
+	n, ok := c.uses[key]
+	return ok && n <= 0
+}
+
+// removeExhausted removes the entries that ran out of uses while the cache
+// was frozen; the lock must be held
+func (c *Cache[K, V]) removeExhausted() {
+	for k, n := range c.uses {
+		if n <= 0 {
+			c.lru.Remove(k)
+		}
+	}
+}
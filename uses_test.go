@@ -0,0 +1,105 @@
+package dailzLRU
+
+import "testing"
+
+func TestCache_AddWithMaxUses(t *testing.T) {
+	var removed []string
+	c, err := NewWithEvict(8, func(k string, v int) {
+		removed = append(removed, k)
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := c.AddWithMaxUses("token", 1, 0); err == nil {
+		t.Fatalf("expected error for zero uses")
+	}
+	if _, err := c.AddWithMaxUses("token", 1, 2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	c.Add("plain", 2)
+
+	if _, ok := c.Peek("token"); !ok || !c.Contains("token") {
+		t.Fatalf("token should be present")
+	}
+	if v, ok := c.Get("token"); !ok || v != 1 {
+		t.Fatalf("bad value: %v, %v", v, ok)
+	}
+	if len(removed) != 0 {
+		t.Fatalf("removed too early: %v", removed)
+	}
+	// the last use returns the value and removes the entry
+	if v, ok := c.Get("token"); !ok || v != 1 {
+		t.Fatalf("bad value: %v, %v", v, ok)
+	}
+	if _, ok := c.Get("token"); ok {
+		t.Fatalf("token served after its last use")
+	}
+	if len(removed) != 1 || removed[0] != "token" {
+		t.Fatalf("bad removals: %v", removed)
+	}
+	for i := 0; i < 3; i++ {
+		if _, ok := c.Get("plain"); !ok {
+			t.Fatalf("plain entry removed")
+		}
+	}
+
+	// a plain Add keeps the remaining uses, and uses through a Txn count
+	c.AddWithMaxUses("token", 1, 2)
+	c.Add("token", 3)
+	c.Do(func(tx Txn[string, int]) error {
+		tx.Get("token")
+		return nil
+	})
+	if v, ok := c.Get("token"); !ok || v != 3 || c.Contains("token") {
+		t.Fatalf("bad last use: %v, %v", v, ok)
+	}
+
+	// removed entries forget their limit
+	c.AddWithMaxUses("token", 1, 1)
+	c.Remove("token")
+	c.Add("token", 4)
+	c.Get("token")
+	if !c.Contains("token") {
+		t.Fatalf("limit outlived its entry")
+	}
+}
+
+func TestCache_AddWithMaxUsesFIFO(t *testing.T) {
+	c, err := NewFIFO[int, int](4)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	c.AddWithMaxUses(1, 1, 1)
+	if _, ok := c.Get(1); !ok {
+		t.Fatalf("entry should be served once")
+	}
+	if c.Contains(1) {
+		t.Fatalf("entry not removed")
+	}
+}
+
+func TestCache_AddWithMaxUsesFrozen(t *testing.T) {
+	var removed []int
+	c, err := NewWithEvict(4, func(k, v int) {
+		removed = append(removed, k)
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	c.AddWithMaxUses(1, 1, 1)
+	c.Freeze()
+	if v, ok := c.Get(1); !ok || v != 1 {
+		t.Fatalf("entry should be served once: %v, %v", v, ok)
+	}
+	if !c.Contains(1) || len(removed) != 0 {
+		t.Fatalf("frozen cache modified: %v", removed)
+	}
+	if _, ok := c.Get(1); ok {
+		t.Fatalf("entry served after its last use")
+	}
+
+	c.Thaw()
+	if c.Contains(1) || len(removed) != 1 || removed[0] != 1 {
+		t.Fatalf("exhausted entry not removed on Thaw: %v", removed)
+	}
+}
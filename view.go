@@ -0,0 +1,231 @@
+package dailzLRU
+
+import "sync"
+
+// viewNode is a node of a persistent treap holding the entries of a cache
+// ordered by recency. A node is never modified once linked: updates copy
+// the path from the root, so older roots keep describing older states.
+type viewNode[K comparable, V any] struct {
+	seq         uint64 // position in recency order, larger is newer
+	key         K
+	value       V
+	left, right *viewNode[K, V]
+}
+
+// viewPriority returns the heap priority of the node for seq
+func viewPriority(seq uint64) uint64 {
+	return mix64(seq)
+}
+
+// viewMerge joins two treaps, every seq of a preceding every seq of b
+func viewMerge[K comparable, V any](a, b *viewNode[K, V]) *viewNode[K, V] {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	if viewPriority(a.seq) > viewPriority(b.seq) {
+		n := *a
+		n.right = viewMerge(a.right, b)
+		return &n
+	}
+	n := *b
+	n.left = viewMerge(a, b.left)
+	return &n
+}
+
+// viewRemove returns the treap without the node for seq
+func viewRemove[K comparable, V any](n *viewNode[K, V], seq uint64) *viewNode[K, V] {
+	if n == nil {
+		return nil
+	}
+	switch {
+	case seq < n.seq:
+		m := *n
+		m.left = viewRemove(n.left, seq)
+		return &m
+	case seq > n.seq:
+		m := *n
+		m.right = viewRemove(n.right, seq)
+		return &m
+	}
+	return viewMerge(n.left, n.right)
+}
+
+// viewReplace returns the treap with the value of the node for seq replaced
+func viewReplace[K comparable, V any](n *viewNode[K, V], seq uint64, value V) *viewNode[K, V] {
+	if n == nil {
+		return nil
+	}
+	m := *n
+	switch {
+	case seq < n.seq:
+		m.left = viewReplace(n.left, seq, value)
+	case seq > n.seq:
+		m.right = viewReplace(n.right, seq, value)
+	default:
+		m.value = value
+	}
+	return &m
+}
+
+// viewTracker mirrors the entries of a cache in a persistent treap, so a
+// View can share its nodes instead of copying them; the cache's lock must
+// be held to use it
+type viewTracker[K comparable, V any] struct {
+	root *viewNode[K, V]
+	seqs map[K]uint64
+	next uint64
+}
+
+// push makes key the newest entry
+func (t *viewTracker[K, V]) push(key K, value V) {
+	t.next++
+	t.seqs[key] = t.next
+	t.root = viewMerge(t.root, &viewNode[K, V]{seq: t.next, key: key, value: value})
+}
+
+// add records key being added with value; promote tells whether an
+// existing key becomes the newest entry
+func (t *viewTracker[K, V]) add(key K, value V, promote bool) {
+	seq, ok := t.seqs[key]
+	switch {
+	case !ok:
+		t.push(key, value)
+	case promote:
+		t.root = viewRemove(t.root, seq)
+		t.push(key, value)
+	default:
+		t.root = viewReplace(t.root, seq, value)
+	}
+}
+
+// touch records key becoming the newest entry
+func (t *viewTracker[K, V]) touch(key K, value V) {
+	if seq, ok := t.seqs[key]; ok {
+		t.root = viewRemove(t.root, seq)
+		t.push(key, value)
+	}
+}
+
+// remove records key leaving the cache
+func (t *viewTracker[K, V]) remove(key K) {
+	if seq, ok := t.seqs[key]; ok {
+		delete(t.seqs, key)
+		t.root = viewRemove(t.root, seq)
+	}
+}
+
+// View is an immutable view of the entries of a cache at one point in
+// time, in recency order. It is safe for concurrent use and never blocks,
+// or observes, later writes to the cache.
+type View[K comparable, V any] struct {
+	root  *viewNode[K, V]
+	n     int
+	index *viewIndex[K, V]
+}
+
+// viewIndex maps the keys of a View to their values, built on first use
+type viewIndex[K comparable, V any] struct {
+	once sync.Once
+	m    map[K]V
+}
+
+// Snapshot returns a View of the cache's entries in constant time. The view
+// shares its storage with the cache: writes copy the few nodes they change
+// instead of modifying them, so taking and reading a view neither copies
+// the cache nor blocks writers. The first call sets this up in time
+// proportional to the number of entries; from then on every write to the
+// cache costs a logarithmic number of extra allocations.
+func (c *Cache[K, V]) Snapshot() View[K, V] {
+	c.lock.RLock()
+	if t := c.views; t != nil {
+		v := View[K, V]{root: t.root, n: len(t.seqs), index: new(viewIndex[K, V])}
+		c.lock.RUnlock()
+		return v
+	}
+	c.lock.RUnlock()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.views == nil {
+		t := &viewTracker[K, V]{seqs: make(map[K]uint64, c.lru.Len())}
+		c.lru.Range(func(key K, value V) bool {
+			t.push(key, value)
+			return true
+		})
+		c.views = t
+	}
+	return View[K, V]{root: c.views.root, n: len(c.views.seqs), index: new(viewIndex[K, V])}
+}
+
+// Len returns the number of entries in the view.
+func (v View[K, V]) Len() int {
+	return v.n
+}
+
+// Range calls fn for each entry of the view, from oldest to newest, until
+// fn returns false.
+func (v View[K, V]) Range(fn func(key K, value V) bool) {
+	var stack []*viewNode[K, V]
+	for n := v.root; n != nil || len(stack) > 0; {
+		for ; n != nil; n = n.left {
+			stack = append(stack, n)
+		}
+		n = stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if !fn(n.key, n.value) {
+			return
+		}
+		n = n.right
+	}
+}
+
+// Keys returns a slice of the keys in the view, from oldest to newest.
+func (v View[K, V]) Keys() []K {
+	keys := make([]K, 0, v.n)
+	v.Range(func(key K, value V) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+// Values returns a slice of the values in the view, from oldest to newest.
+func (v View[K, V]) Values() []V {
+	values := make([]V, 0, v.n)
+	v.Range(func(key K, value V) bool {
+		values = append(values, value)
+		return true
+	})
+	return values
+}
+
+// Get returns the value of key in the view. The first call builds an index
+// of the keys.
+func (v View[K, V]) Get(key K) (value V, ok bool) {
+	if v.index == nil {
+		return value, false
+	}
+	v.index.once.Do(func() {
+		v.index.m = make(map[K]V, v.n)
+		v.Range(func(key K, value V) bool {
+			v.index.m[key] = value
+			return true
+		})
+	})
+	value, ok = v.index.m[key]
+	return value, ok
+}
+
+// Entries returns the entries of the view, from oldest to newest, as
+// expected by WriteSnapshotEntries.
+func (v View[K, V]) Entries() []SnapshotEntry[K, V] {
+	entries := make([]SnapshotEntry[K, V], 0, v.n)
+	v.Range(func(key K, value V) bool {
+		entries = append(entries, SnapshotEntry[K, V]{Key: key, Value: value})
+		return true
+	})
+	return entries
+}
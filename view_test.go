@@ -0,0 +1,127 @@
+package dailzLRU
+
+import (
+	"bytes"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+func TestView(t *testing.T) {
+	c, err := New[int, string](4)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	c.Add(1, "a")
+	c.Add(2, "b")
+	c.Add(3, "c")
+	c.Get(1)
+	v := c.Snapshot()
+
+	c.Add(4, "d")
+	c.Add(5, "e")
+	c.Remove(3)
+	if v.Len() != 3 {
+		t.Fatalf("bad len: %v", v.Len())
+	}
+	if k := v.Keys(); len(k) != 3 || k[0] != 2 || k[1] != 3 || k[2] != 1 {
+		t.Fatalf("bad keys: %v", k)
+	}
+	if vs := v.Values(); len(vs) != 3 || vs[0] != "b" || vs[2] != "a" {
+		t.Fatalf("bad values: %v", vs)
+	}
+	if val, ok := v.Get(3); !ok || val != "c" {
+		t.Fatalf("bad value: %v, %v", val, ok)
+	}
+	if _, ok := v.Get(4); ok {
+		t.Fatalf("view should not see later writes")
+	}
+	n := 0
+	v.Range(func(k int, val string) bool {
+		n++
+		return k != 3
+	})
+	if n != 2 {
+		t.Fatalf("range did not stop: %v", n)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSnapshotEntries(&buf, SnapshotGob, v.Entries()); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	c2, _ := New[int, string](4)
+	if n, err := c2.ReadSnapshot(&buf); err != nil || n != 3 {
+		t.Fatalf("bad read: %v, %v", n, err)
+	}
+	if k := c2.Keys(); len(k) != 3 || k[0] != 2 || k[2] != 1 {
+		t.Fatalf("bad keys: %v", k)
+	}
+}
+
+func TestView_Concurrent(t *testing.T) {
+	c, err := New[int, int](100)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		c.Add(i, i)
+	}
+	v := c.Snapshot()
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				if val, ok := v.Get(i); !ok || val != i {
+					t.Errorf("bad value for %v: %v, %v", i, val, ok)
+				}
+				c.Add(i+100, i)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestView_Consistent(t *testing.T) {
+	r := rand.New(rand.NewSource(getRand(t)))
+	for _, newCache := range []func(int) (*Cache[int, int], error){New[int, int], NewMRU[int, int], NewFIFO[int, int]} {
+		c, err := newCache(32)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		c.Snapshot()
+		var views []View[int, int]
+		var want [][]int
+		for i := 0; i < 2000; i++ {
+			k := r.Intn(64)
+			switch r.Intn(4) {
+			case 0, 1:
+				c.Add(k, i)
+			case 2:
+				c.Get(k)
+			case 3:
+				c.Remove(k)
+			}
+			if i%100 == 0 {
+				views = append(views, c.Snapshot())
+				want = append(want, c.Keys())
+			}
+			if i == 1000 {
+				c.Purge()
+			}
+		}
+		// each view still describes the cache as it was when taken
+		for i, v := range views {
+			got := v.Keys()
+			if len(got) != len(want[i]) || v.Len() != len(got) {
+				t.Fatalf("view %v: bad keys %v, want %v", i, got, want[i])
+			}
+			for j := range got {
+				if got[j] != want[i][j] {
+					t.Fatalf("view %v: bad keys %v, want %v", i, got, want[i])
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package dailzLRU
+
+import "sync"
+
+// EventKind is the kind of change an Event reports.
+type EventKind int
+
+const (
+	// EventAdd reports a key being added to the cache.
+	EventAdd EventKind = iota
+	// EventUpdate reports a new value for a key already in the cache.
+	EventUpdate
+	// EventRemove reports a key being removed through Remove, Pop,
+	// TakeOldestIf, RemoveOldest or Purge.
+	EventRemove
+	// EventEvict reports a key being evicted to make room for others.
+	EventEvict
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventAdd:
+		return "add"
+	case EventUpdate:
+		return "update"
+	case EventRemove:
+		return "remove"
+	case EventEvict:
+		return "evict"
+	}
+	return "unknown"
+}
+
+// Event is a change to a watched key. Value is the new value for EventAdd
+// and EventUpdate and the value that left the cache otherwise.
+type Event[K comparable, V any] struct {
+	Kind  EventKind
+	Key   K
+	Value V
+}
+
+// watcher queues the events of a key and delivers them on its channel. The
+// queue is unbounded so the cache never blocks on a slow watcher.
+type watcher[K comparable, V any] struct {
+	ch    chan Event[K, V]
+	queue []Event[K, V]
+	wake  chan struct{}
+	done  chan struct{}
+	lock  sync.Mutex
+}
+
+// push queues e for delivery
+func (w *watcher[K, V]) push(e Event[K, V]) {
+	w.lock.Lock()
+	w.queue = append(w.queue, e)
+	w.lock.Unlock()
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run delivers queued events until the watch is cancelled
+func (w *watcher[K, V]) run() {
+	defer close(w.ch)
+	for {
+		w.lock.Lock()
+		queue := w.queue
+		w.queue = nil
+		w.lock.Unlock()
+		for _, e := range queue {
+			select {
+			case w.ch <- e:
+			case <-w.done:
+				return
+			}
+		}
+		select {
+		case <-w.wake:
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// Watch returns a channel receiving the changes to key, in order, from now
+// until cancel is called, after which the channel is closed. Events are
+// queued without bound, so a slow receiver never blocks the cache.
+func (c *Cache[K, V]) Watch(key K) (events <-chan Event[K, V], cancel func()) {
+	w := &watcher[K, V]{
+		ch:   make(chan Event[K, V]),
+		wake: make(chan struct{}, 1),
+		done: make(chan struct{}),
+	}
+	c.lock.Lock()
+	if c.watchers == nil {
+		c.watchers = make(map[K][]*watcher[K, V])
+	}
+	c.watchers[key] = append(c.watchers[key], w)
+	c.lock.Unlock()
+	go w.run()
+
+	var once sync.Once
+	return w.ch, func() {
+		once.Do(func() {
+			c.lock.Lock()
+			ws := c.watchers[key]
+			for i := range ws {
+				if ws[i] == w {
+					ws = append(ws[:i], ws[i+1:]...)
+					break
+				}
+			}
+			if len(ws) == 0 {
+				delete(c.watchers, key)
+			} else {
+				c.watchers[key] = ws
+			}
+			c.lock.Unlock()
+			close(w.done)
+		})
+	}
+}
+
+// emit queues an event for the watchers of key; the lock must be held
+func (c *Cache[K, V]) emit(kind EventKind, key K, value V) {
+	for _, w := range c.watchers[key] {
+		w.push(Event[K, V]{Kind: kind, Key: key, Value: value})
+	}
+}
+
+// emitAdd queues the event for key being added with value, before it is
+// added; the lock must be held
+func (c *Cache[K, V]) emitAdd(key K, value V) {
+	if len(c.watchers) == 0 || len(c.watchers[key]) == 0 {
+		return
+	}
+	kind := EventAdd
+	if c.lru.Contains(key) {
+		kind = EventUpdate
+	}
+	c.emit(kind, key, value)
+}
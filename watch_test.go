@@ -0,0 +1,59 @@
+package dailzLRU
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCache_Watch(t *testing.T) {
+	l, err := New[int, int](2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	events, cancel := l.Watch(1)
+
+	l.Add(1, 10)
+	l.Add(1, 11)
+	l.Add(2, 20) // not watched
+	l.Remove(1)
+	l.Add(1, 12)
+	l.Add(3, 30)
+	l.Add(4, 40) // evicts 1
+	l.Do(func(tx Txn[int, int]) error {
+		tx.Add(1, 13)
+		return nil
+	})
+	l.Purge()
+
+	want := []Event[int, int]{
+		{EventAdd, 1, 10},
+		{EventUpdate, 1, 11},
+		{EventRemove, 1, 11},
+		{EventAdd, 1, 12},
+		{EventEvict, 1, 12},
+		{EventAdd, 1, 13},
+		{EventRemove, 1, 13},
+	}
+	for i, w := range want {
+		select {
+		case e := <-events:
+			if e != w {
+				t.Fatalf("bad event %d: got %v %+v, want %v %+v", i, e.Kind, e, w.Kind, w)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("missing event %d: %+v", i, w)
+		}
+	}
+
+	cancel()
+	cancel()
+	l.Add(1, 14)
+	for e := range events {
+		if e.Value == 14 {
+			t.Fatalf("event delivered after cancel: %+v", e)
+		}
+	}
+	if len(l.watchers) != 0 {
+		t.Fatalf("watcher not removed: %v", l.watchers)
+	}
+}
@@ -0,0 +1,116 @@
+package dailzLRU
+
+import (
+	"errors"
+	"sync"
+)
+
+// evictor is the background worker started by EvictInBackground
+type evictor struct {
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+// signal wakes the worker up without blocking
+func (e *evictor) signal() {
+	select {
+	case e.wake <- struct{}{}:
+	default:
+	}
+}
+
+// EvictInBackground switches the cache to high-watermark eviction. The
+// cache's size becomes a soft limit: writes are admitted without evicting
+// until the cache holds hard entries, and a background goroutine evicts the
+// oldest entries down to the size whenever it is exceeded. This takes the
+// eviction work, and the eviction callbacks, off the write path. Resize
+// changes the soft limit.
+//
+// The returned stop function ends background eviction and evicts down to
+// the size on the calling goroutine, or, while the cache is frozen, on Thaw. The mode is built on PauseEviction and
+// must not be combined with it.
+func (c *Cache[K, V]) EvictInBackground(hard int) (stop func(), err error) {
+	c.lock.Lock()
+	if c.evictor != nil {
+		c.lock.Unlock()
+		return nil, errors.New("background eviction already running")
+	}
+	if hard <= c.lru.Size() {
+		c.lock.Unlock()
+		return nil, errors.New("hard limit must exceed the cache size")
+	}
+	e := &evictor{
+		wake: make(chan struct{}, 1),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	c.evictor = e
+	c.lru.PauseEviction(hard)
+	if c.lru.Len() > c.lru.Size() {
+		e.signal()
+	}
+	c.lock.Unlock()
+	go c.runEvictor(e)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(e.stop)
+			<-e.done
+			var ks []K
+			var vs []V
+			c.lock.Lock()
+			c.evictor = nil
+			if c.frozen {
+				// evict once the cache is thawed, as ResumeEviction does
+				c.resumeThaw = true
+				c.lock.Unlock()
+				return
+			}
+			if evicted := c.lru.ResumeEviction(); c.onEvictedCB != nil && evicted > 0 {
+				ks = c.evictedKeys
+				vs = c.evictedVals
+				c.initEvictBuffers()
+			}
+			c.lock.Unlock()
+			c.notifyAll(ks, vs)
+		})
+	}, nil
+}
+
+// runEvictor evicts down to the soft limit each time e is signalled, until
+// e is stopped
+func (c *Cache[K, V]) runEvictor(e *evictor) {
+	defer close(e.done)
+	for {
+		select {
+		case <-e.stop:
+			return
+		case <-e.wake:
+			c.evictToSize()
+		}
+	}
+}
+
+// evictToSize evicts the oldest entries until the cache fits its size
+func (c *Cache[K, V]) evictToSize() {
+	var ks []K
+	var vs []V
+	c.lock.Lock()
+	if c.frozen {
+		c.lock.Unlock()
+		return
+	}
+	evicted := c.lru.Resize(c.lru.Size())
+	if c.onEvictedCB != nil && evicted > 0 {
+		ks = c.evictedKeys
+		vs = c.evictedVals
+		c.initEvictBuffers()
+	}
+	c.lock.Unlock()
+	if l := c.logger.Load(); l != nil && evicted > 0 {
+		l.Debug("evicted in background", "evicted", evicted)
+	}
+	c.notifyAll(ks, vs)
+}
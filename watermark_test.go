@@ -0,0 +1,94 @@
+package dailzLRU
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCache_EvictInBackground(t *testing.T) {
+	var evictions atomic.Int64
+	l, err := NewWithEvict(10, func(k, v int) { evictions.Add(1) })
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := l.EvictInBackground(10); err == nil {
+		t.Fatalf("should reject a hard limit not above the size")
+	}
+	stop, err := l.EvictInBackground(20)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := l.EvictInBackground(30); err == nil {
+		t.Fatalf("should reject a second background evictor")
+	}
+
+	for i := 0; i < 100; i++ {
+		l.Add(i, i)
+		if n := l.Len(); n > 20 {
+			t.Fatalf("len %d exceeds the hard limit", n)
+		}
+	}
+	deadline := time.Now().Add(time.Second)
+	for l.Len() > 10 || evictions.Load() < 90 {
+		if time.Now().After(deadline) {
+			t.Fatalf("background eviction did not reach the soft limit: len %d", l.Len())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if n := evictions.Load(); n != 90 {
+		t.Fatalf("bad evictions: %d", n)
+	}
+	for i := 90; i < 100; i++ {
+		if !l.Contains(i) {
+			t.Fatalf("newest key %d should be kept", i)
+		}
+	}
+
+	for i := 100; i < 115; i++ {
+		l.Add(i, i)
+	}
+	stop()
+	stop()
+	if n := l.Len(); n != 10 {
+		t.Fatalf("bad len after stop: %d", n)
+	}
+	if n := evictions.Load(); n != 105 {
+		t.Fatalf("bad evictions: %d", n)
+	}
+
+	// writes evict again once stopped
+	for i := 200; i < 220; i++ {
+		l.Add(i, i)
+		if n := l.Len(); n > 10 {
+			t.Fatalf("len %d exceeds the size after stop", n)
+		}
+	}
+}
+
+func TestCache_EvictInBackgroundFrozen(t *testing.T) {
+	l, err := New[int, int](2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	stop, err := l.EvictInBackground(8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	// fill past the size without waking the evictor, then freeze
+	l.lock.Lock()
+	for i := 0; i < 6; i++ {
+		l.lru.Add(i, i)
+	}
+	l.frozen = true
+	l.lock.Unlock()
+
+	stop()
+	if n := l.Len(); n != 6 {
+		t.Fatalf("stop evicted while frozen: len %d", n)
+	}
+	l.Thaw()
+	if n := l.Len(); n != 2 {
+		t.Fatalf("bad len after thaw: %d", n)
+	}
+}
@@ -0,0 +1,62 @@
+package dailzLRU_test
+
+import (
+	"github.com/dailz1/dailzLRU"
+	"github.com/dailz1/dailzLRU/sim"
+	"testing"
+)
+
+// benchCache is the part of a cache the workload benchmarks exercise
+type benchCache interface {
+	Get(key uint64) (uint64, bool)
+	Add(key, value uint64) bool
+}
+
+// workloads are the access patterns the workload benchmarks replay
+var workloads = []struct {
+	name string
+	gen  func() sim.Generator
+}{
+	{"Uniform", func() sim.Generator { return sim.Uniform(1, 32768) }},
+	{"Zipf", func() sim.Generator { return sim.Zipf(1, 1.1, 32768) }},
+	{"Loop", func() sim.Generator { return sim.Loop(9000) }},
+	{"ZipfScan", func() sim.Generator {
+		return sim.Mix(1, []sim.Generator{sim.Zipf(2, 1.1, 32768), sim.Scan(1 << 32)}, []float64{0.8, 0.2})
+	}},
+}
+
+func benchmarkWorkloads(b *testing.B, newCache func() (benchCache, error)) {
+	for _, w := range workloads {
+		w := w
+		b.Run(w.name, func(b *testing.B) {
+			l, err := newCache()
+			if err != nil {
+				b.Fatalf("err: %v", err)
+			}
+			trace := sim.Take(w.gen(), b.N)
+			b.ResetTimer()
+
+			var hit int
+			for _, k := range trace {
+				if _, ok := l.Get(k); ok {
+					hit++
+				} else {
+					l.Add(k, k)
+				}
+			}
+			b.ReportMetric(float64(hit)/float64(len(trace)), "hits/op")
+		})
+	}
+}
+
+func BenchmarkLRU_Workloads(b *testing.B) {
+	benchmarkWorkloads(b, func() (benchCache, error) {
+		return dailzLRU.New[uint64, uint64](8192)
+	})
+}
+
+func Benchmark2Q_Workloads(b *testing.B) {
+	benchmarkWorkloads(b, func() (benchCache, error) {
+		return dailzLRU.New2Q[uint64, uint64](8192)
+	})
+}